@@ -0,0 +1,130 @@
+// /backend/src/callnumber/callnumber.go
+
+// Package callnumber parses Dewey Decimal and Library of Congress call numbers and
+// derives sort keys that order them as books stand on the shelves.
+package callnumber
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Classification schemes.
+const (
+	DDC = "DDC" // Dewey Decimal Classification
+	LCC = "LCC" // Library of Congress Classification
+)
+
+var (
+	// ErrInvalid is returned for a value that is not a call number of its scheme.
+	ErrInvalid = errors.New("invalid call number")
+	// ErrUnknownScheme is returned for a scheme other than DDC and LCC.
+	ErrUnknownScheme = errors.New("call number scheme must be DDC or LCC")
+)
+
+// CallNumber is a parsed call number. Display is the call number in upper case with
+// single spaces; SortKey compares, byte by byte, in shelf order.
+type CallNumber struct {
+	Scheme  string
+	Display string
+	SortKey string
+}
+
+var (
+	ddcClass = regexp.MustCompile(`^(\d{3})(?:\.(\d+))?$`)
+	lccClass = regexp.MustCompile(`^([A-Z]{1,3}) ?(\d{1,4})(?:\.(\d+))?(.*)$`)
+	token    = regexp.MustCompile(`^[A-Z0-9]+$`)
+)
+
+// Detect guesses the scheme of a call number: DDC when it starts with a digit, LCC
+// when it starts with a letter, "" otherwise.
+func Detect(raw string) string {
+	raw = strings.TrimSpace(raw)
+	switch {
+	case raw == "":
+		return ""
+	case raw[0] >= '0' && raw[0] <= '9':
+		return DDC
+	case (raw[0] >= 'A' && raw[0] <= 'Z') || (raw[0] >= 'a' && raw[0] <= 'z'):
+		return LCC
+	}
+	return ""
+}
+
+// Parse reads a call number of the given scheme, detected from the call number when
+// scheme is empty.
+func Parse(scheme, raw string) (CallNumber, error) {
+	display := strings.ToUpper(strings.Join(strings.Fields(raw), " "))
+	if scheme == "" {
+		scheme = Detect(display)
+	}
+	cn := CallNumber{Scheme: strings.ToUpper(scheme), Display: display}
+	var err error
+	switch cn.Scheme {
+	case DDC:
+		cn.SortKey, err = ddcKey(display)
+	case LCC:
+		cn.SortKey, err = lccKey(display)
+	case "":
+		err = ErrInvalid
+	default:
+		err = ErrUnknownScheme
+	}
+	if err != nil {
+		return CallNumber{}, err
+	}
+	return cn, nil
+}
+
+// ddcKey keys a Dewey call number ("823.912 T649L 1954"): the three digits of the
+// class, its decimals, then the cutter and date tokens. Decimals compare digit by
+// digit, so "823.9" comes before "823.912" and both before "823.92".
+func ddcKey(display string) (string, error) {
+	fields := strings.Fields(display)
+	if len(fields) == 0 {
+		return "", ErrInvalid
+	}
+	// Prime marks and slashes only segment the class number.
+	class := strings.NewReplacer("/", "", "'", "").Replace(fields[0])
+	m := ddcClass.FindStringSubmatch(class)
+	if m == nil {
+		return "", fmt.Errorf("%w: %q is not a Dewey class number", ErrInvalid, fields[0])
+	}
+	rest, err := tokens(strings.Join(fields[1:], " "))
+	if err != nil {
+		return "", err
+	}
+	return m[1] + m[2] + rest, nil
+}
+
+// lccKey keys a Library of Congress call number ("QA76.73.G63 D66 2016"): the class
+// letters padded to three, the class number padded to four digits and its decimals,
+// then the cutter and date tokens. Cutters are decimals too, so ".G6" comes before
+// ".G63".
+func lccKey(display string) (string, error) {
+	m := lccClass.FindStringSubmatch(display)
+	if m == nil {
+		return "", fmt.Errorf("%w: %q does not start with an LC class", ErrInvalid, display)
+	}
+	rest, err := tokens(m[4])
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%-3s", m[1]) + strings.Repeat("0", 4-len(m[2])) + m[2] + m[3] + rest, nil
+}
+
+// tokens keys the part of a call number after the class: each cutter, date or other
+// token preceded by a space, which sorts before any letter or digit so that shorter
+// call numbers come first.
+func tokens(s string) (string, error) {
+	var key strings.Builder
+	for _, t := range strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == '.' }) {
+		if !token.MatchString(t) {
+			return "", fmt.Errorf("%w: unexpected %q", ErrInvalid, t)
+		}
+		key.WriteString(" " + t)
+	}
+	return key.String(), nil
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 
 	"github.com/swapxs/LibMS/backend/src/models"
+	"github.com/swapxs/LibMS/backend/src/services"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
@@ -28,9 +29,62 @@ func InitDB() *gorm.DB {
 		&models.BookInventory{},
 		&models.RequestEvent{},
 		&models.IssueRegistry{},
+		&models.Hold{},
+		&models.FinePolicy{},
+		&models.Fine{},
+		&models.FineTransaction{},
+		&models.BookItem{},
+		&models.RequestTransition{},
+		&models.LoanPolicy{},
+		&models.LibraryHoliday{},
+		&models.LibraryOpeningHours{},
+		&models.LibraryClosure{},
+		&models.Author{},
+		&models.AuthorNameVariant{},
+		&models.BookAuthor{},
+		&models.Subject{},
+		&models.BookSubject{},
+		&models.BookTag{},
+		&models.Work{},
+		&models.BookRevision{},
+		&models.BookChange{},
 	)
 	if err != nil {
 		log.Fatalf("Auto-migration failed: %v", err)
 	}
+
+	// Full-text search over the catalog.
+	if err := services.EnsureCatalogSearch(db); err != nil {
+		log.Fatalf("Catalog search setup failed: %v", err)
+	}
+	if err := services.EnsureCatalogSuggest(db); err != nil {
+		log.Fatalf("Catalog suggest setup failed: %v", err)
+	}
+
+	// Store every ISBN as an ISBN-13 and merge the duplicates this uncovers.
+	report, err := services.NormalizeStoredISBNs(db)
+	if err != nil {
+		log.Fatalf("ISBN normalization failed: %v", err)
+	}
+	if report.Normalized > 0 || report.Merged > 0 {
+		log.Printf("Normalized %d ISBNs, merged %d duplicate books", report.Normalized, report.Merged)
+	}
+	if len(report.Invalid) > 0 {
+		log.Printf("Left %d invalid ISBNs unchanged: %v", len(report.Invalid), report.Invalid)
+	}
+
+	// Link books from before author records to authors parsed from their author field.
+	linked, err := services.BackfillBookAuthors(db)
+	if err != nil {
+		log.Fatalf("Author backfill failed: %v", err)
+	}
+	if linked > 0 {
+		log.Printf("Linked %d books to authors", linked)
+	}
+
+	// Give requests created before the status column a kind, status and history.
+	if err := services.BackfillRequestStatuses(db); err != nil {
+		log.Fatalf("Request status backfill failed: %v", err)
+	}
 	return db
 }
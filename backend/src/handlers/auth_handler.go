@@ -42,7 +42,12 @@ func RegisterUser(db *gorm.DB) gin.HandlerFunc {
 
 		// Check if user already exists.
 		var user models.User
-		if err := db.Where("email = ?", input.Email).First(&user).Error; err == nil {
+		if err := db.Unscoped().Where("email = ?", input.Email).First(&user).Error; err == nil {
+			if user.DeletedAt.Valid {
+				// Only the library can bring back a deleted account.
+				c.JSON(http.StatusBadRequest, gin.H{"error": "This account was deleted; ask the library to restore it"})
+				return
+			}
 			c.JSON(http.StatusBadRequest, gin.H{"error": "User already exists"})
 			return
 		}
@@ -0,0 +1,236 @@
+// /backend/src/handlers/author_handler.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"github.com/swapxs/LibMS/backend/src/services"
+	"gorm.io/gorm"
+)
+
+// AuthorSummary is an author with the number of books of the library crediting them.
+type AuthorSummary struct {
+	models.Author
+	BookCount int64 `json:"book_count"`
+}
+
+// AuthorWork is a book crediting an author, with the roles the author has on it.
+type AuthorWork struct {
+	Book  models.BookInventory `json:"book"`
+	Roles []string             `json:"roles"`
+}
+
+// UpdateAuthorInput renames an author. Variants, when given, replace the author's
+// name variants.
+type UpdateAuthorInput struct {
+	Name     string    `json:"name" binding:"required"`
+	Variants *[]string `json:"variants"`
+}
+
+// MergeAuthorInput names the author folded into the one in the URL.
+type MergeAuthorInput struct {
+	AuthorID uint `json:"author_id" binding:"required"`
+}
+
+// findAuthor loads an author of the library by ID, replying 400 or 404 when it
+// cannot.
+func findAuthor(c *gin.Context, db *gorm.DB, libraryID uint, rawID string) (models.Author, bool) {
+	var author models.Author
+	id, err := strconv.Atoi(rawID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid author ID"})
+		return author, false
+	}
+	if err := db.Preload("Variants").Where("id = ? AND library_id = ?", id, libraryID).First(&author).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Author not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return author, false
+	}
+	return author, true
+}
+
+// liveBookAuthors selects the credits on books that are not deleted.
+func liveBookAuthors(db *gorm.DB) *gorm.DB {
+	return db.Model(&models.BookAuthor{}).
+		Joins("JOIN book_inventories ON book_inventories.id = book_authors.book_inventory_id AND book_inventories.deleted_at IS NULL")
+}
+
+// GetAuthors lists the authors of the caller's library by name, with the number of
+// books crediting them. q narrows the list to names or variants containing it.
+func GetAuthors(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := c.MustGet("user").(jwt.MapClaims)
+		libraryID, err := getUintFromClaim(claims, "library_id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		query := db.Preload("Variants").Where("library_id = ?", libraryID)
+		if key := services.AuthorKey(c.Query("q")); key != "" {
+			pattern := "%" + key + "%"
+			query = query.Where("name_key LIKE ? OR id IN (?)", pattern,
+				db.Model(&models.AuthorNameVariant{}).Select("author_id").Where("name_key LIKE ?", pattern))
+		}
+		var authors []models.Author
+		if err := query.Order("name ASC").Find(&authors).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var counts []struct {
+			AuthorID uint
+			Books    int64
+		}
+		if err := liveBookAuthors(db).Select("book_authors.author_id, COUNT(DISTINCT book_authors.book_inventory_id) AS books").
+			Where("book_inventories.library_id = ?", libraryID).Group("book_authors.author_id").Scan(&counts).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		books := map[uint]int64{}
+		for _, count := range counts {
+			books[count.AuthorID] = count.Books
+		}
+		summaries := make([]AuthorSummary, len(authors))
+		for i, author := range authors {
+			summaries[i] = AuthorSummary{Author: author, BookCount: books[author.ID]}
+		}
+		c.JSON(http.StatusOK, gin.H{"authors": summaries})
+	}
+}
+
+// GetAuthor returns an author of the caller's library with their name variants and
+// the books of the library crediting them, by title.
+func GetAuthor(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := c.MustGet("user").(jwt.MapClaims)
+		libraryID, err := getUintFromClaim(claims, "library_id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		author, ok := findAuthor(c, db, libraryID, c.Param("id"))
+		if !ok {
+			return
+		}
+
+		var links []models.BookAuthor
+		if err := liveBookAuthors(db).Where("book_authors.author_id = ?", author.ID).
+			Order("book_inventories.title ASC, book_authors.book_inventory_id ASC, book_authors.role ASC").
+			Find(&links).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		var bookIDs []uint
+		roles := map[uint][]string{}
+		for _, link := range links {
+			if roles[link.BookInventoryID] == nil {
+				bookIDs = append(bookIDs, link.BookInventoryID)
+			}
+			roles[link.BookInventoryID] = append(roles[link.BookInventoryID], link.Role)
+		}
+		var books []models.BookInventory
+		if err := db.Where("id IN ?", bookIDs).Find(&books).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		byID := map[uint]models.BookInventory{}
+		for _, book := range books {
+			byID[book.ID] = book
+		}
+		works := make([]AuthorWork, 0, len(bookIDs))
+		for _, id := range bookIDs {
+			works = append(works, AuthorWork{Book: byID[id], Roles: roles[id]})
+		}
+		c.JSON(http.StatusOK, gin.H{"author": author, "works": works})
+	}
+}
+
+// UpdateAuthor renames an author of the caller's library and, if given, replaces
+// their name variants. The author field of their books follows the new name.
+func UpdateAuthor(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		libraryID, ok := staffLibrary(c, "update authors")
+		if !ok {
+			return
+		}
+		author, ok := findAuthor(c, db, libraryID, c.Param("id"))
+		if !ok {
+			return
+		}
+		var input UpdateAuthorInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		var variants []string
+		if input.Variants != nil {
+			variants = *input.Variants
+		} else {
+			for _, v := range author.Variants {
+				variants = append(variants, v.Name)
+			}
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return services.UpdateAuthor(tx, &author, input.Name, variants)
+		})
+		if errors.Is(err, services.ErrAuthorNameTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrInvalidCredit) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Name is required"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Author updated", "author": author})
+	}
+}
+
+// MergeAuthors folds another author of the library (author_id) into the one in the
+// URL: their books credit the latter, and their names become its variants.
+func MergeAuthors(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		libraryID, ok := staffLibrary(c, "merge authors")
+		if !ok {
+			return
+		}
+		into, ok := findAuthor(c, db, libraryID, c.Param("id"))
+		if !ok {
+			return
+		}
+		var input MergeAuthorInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if input.AuthorID == into.ID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "An author cannot be merged into itself"})
+			return
+		}
+		from, ok := findAuthor(c, db, libraryID, strconv.Itoa(int(input.AuthorID)))
+		if !ok {
+			return
+		}
+
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			return services.MergeAuthors(tx, &into, from)
+		}); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Authors merged", "author": into})
+	}
+}
@@ -2,11 +2,21 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/swapxs/LibMS/backend/src/callnumber"
+	"github.com/swapxs/LibMS/backend/src/isbn"
+	"github.com/swapxs/LibMS/backend/src/metadata"
 	"github.com/swapxs/LibMS/backend/src/models"
+	"github.com/swapxs/LibMS/backend/src/services"
 	"gorm.io/gorm"
 )
 
@@ -14,24 +24,174 @@ import (
 type AddBookInput struct {
 	ISBN          string `json:"isbn" binding:"required"`
 	Title         string `json:"title"`  // Required only when creating a new book
-	Author        string `json:"author"` // Required only when creating a new book
+	Author        string `json:"author"` // Required only when creating a new book, unless Authors is given
 	Publisher     string `json:"publisher"`
 	Language      string `json:"language"` // Required only when creating a new book
 	Version       string `json:"version"`
 	Copies        int    `json:"copies" binding:"required,gt=0"`
 	IncrementOnly bool   `json:"increment_only"`
+	// Optional copy-level details. Barcodes are generated when omitted.
+	Barcodes      []string `json:"barcodes"`
+	Condition     string   `json:"condition"`
+	ShelfLocation string   `json:"shelf_location"`
+	// Optional credits with roles for a new book; Author is derived from them.
+	Authors []services.AuthorCredit `json:"authors"`
+	// Optional work a new book is an edition of.
+	WorkID *uint `json:"work_id"`
+	// Optional call number of a new book, DDC or LCC (detected when the scheme is
+	// empty), and where the book stands.
+	CallNumber       string `json:"call_number"`
+	CallNumberScheme string `json:"call_number_scheme"`
+	Floor            string `json:"floor"`
+	Section          string `json:"section"`
+	Shelf            string `json:"shelf"`
+}
+
+// normalizeISBN brings an ISBN from a request into its stored ISBN-13 form, replying
+// 400 when it is not a valid ISBN.
+func normalizeISBN(c *gin.Context, raw string) (string, bool) {
+	normalized, err := isbn.Normalize(raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ISBN: " + raw})
+		return "", false
+	}
+	return normalized, true
+}
+
+var (
+	errBarcodeCount        = errors.New("Number of barcodes must match copies")
+	errIncrementNotFound   = errors.New("Book not found, cannot increment copies. Please add new book details.")
+	errBookDetailsRequired = errors.New("Title, Author, and Language are required for a new book")
+)
+
+// prepareBookInput checks an AddBookInput against its binding rules, normalizes its
+// ISBN and call number and derives Author from the credits, if any.
+func prepareBookInput(input *AddBookInput) error {
+	if err := binding.Validator.ValidateStruct(input); err != nil {
+		return err
+	}
+	normalized, err := isbn.Normalize(input.ISBN)
+	if err != nil {
+		return errors.New("Invalid ISBN: " + input.ISBN)
+	}
+	input.ISBN = normalized
+	if len(input.Barcodes) > 0 && len(input.Barcodes) != input.Copies {
+		return errBarcodeCount
+	}
+	if input.CallNumber != "" {
+		cn, err := callnumber.Parse(input.CallNumberScheme, input.CallNumber)
+		if err != nil {
+			return err
+		}
+		input.CallNumber, input.CallNumberScheme = cn.Display, cn.Scheme
+	}
+	if len(input.Authors) > 0 {
+		if input.Authors, err = services.NormalizeCredits(input.Authors); err != nil {
+			return err
+		}
+		input.Author = services.AuthorDisplay(input.Authors)
+	}
+	return nil
+}
+
+// addOrIncrement adds a prepared AddBookInput to the library: copies are added to an
+// existing book, otherwise a new book is created. created reports which happened.
+func addOrIncrement(tx *gorm.DB, libraryID uint, input AddBookInput) (book models.BookInventory, created bool, err error) {
+	err = tx.Where("isbn = ? AND library_id = ?", input.ISBN, libraryID).First(&book).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// A book in the trash still holds its ISBN; adding it again brings it back.
+		book, err = services.FindTrashedBook(tx, libraryID, input.ISBN)
+		if err == nil {
+			err = services.RestoreBook(tx, &book)
+		} else if errors.Is(err, services.ErrNotInTrash) {
+			err = gorm.ErrRecordNotFound
+		}
+	}
+	if err == nil {
+		// Book record exists: Increment copies.
+		if err := services.EnsureBookItems(tx, &book); err != nil {
+			return book, false, err
+		}
+		if err := services.AddBookItems(tx, &book, input.Copies, input.Barcodes, input.Condition, input.ShelfLocation); err != nil {
+			return book, false, err
+		}
+		// New copies go to readers waiting in the hold queue first.
+		return book, false, services.OfferHolds(tx, book.ISBN, libraryID)
+	} else if err != gorm.ErrRecordNotFound {
+		// Some other error occurred.
+		return book, false, err
+	}
+
+	// Record not found.
+	if input.IncrementOnly {
+		// Cannot increment copies if record does not exist.
+		return book, false, errIncrementNotFound
+	}
+	// For a new book, require Title, Author, and Language.
+	if input.Title == "" || input.Author == "" || input.Language == "" {
+		return book, false, errBookDetailsRequired
+	}
+
+	if input.WorkID != nil {
+		if _, err := services.FindWork(tx, libraryID, *input.WorkID); err != nil {
+			return book, false, err
+		}
+	}
+
+	// Create a new book record.
+	book = models.BookInventory{
+		ISBN:      input.ISBN,
+		LibraryID: libraryID,
+		Title:     input.Title,
+		Author:    input.Author,
+		Publisher: input.Publisher,
+		Language:  input.Language,
+		Version:   input.Version,
+		WorkID:    input.WorkID,
+		Floor:     input.Floor,
+		Section:   input.Section,
+		Shelf:     input.Shelf,
+	}
+	if err := services.SetCallNumber(&book, input.CallNumberScheme, input.CallNumber); err != nil {
+		return book, true, err
+	}
+	if err := tx.Create(&book).Error; err != nil {
+		return book, true, err
+	}
+	credits := input.Authors
+	if len(credits) == 0 {
+		credits = services.CreditsFromAuthorField(input.Author)
+	}
+	if err := services.LinkBookAuthors(tx, &book, credits); err != nil {
+		return book, true, err
+	}
+	// Copies are tracked as items; the counters are derived from them.
+	return book, true, services.AddBookItems(tx, &book, input.Copies, input.Barcodes, input.Condition, input.ShelfLocation)
+}
+
+// isBookInputError reports whether err from addOrIncrement is the caller's mistake
+// rather than a server failure.
+func isBookInputError(err error) bool {
+	return errors.Is(err, errIncrementNotFound) || errors.Is(err, errBookDetailsRequired) ||
+		errors.Is(err, services.ErrBarcodeInUse) || errors.Is(err, services.ErrWorkNotFound)
 }
 
 // AddOrIncrementBook adds a new book or increments copies if the book already exists.
-// If the book exists, it ignores Title, Author, and Language.
+// If the book exists, it ignores Title, Author, and Language. A deleted book is
+// restored, keeping its details, and given the new copies.
 // If no record is found and IncrementOnly is true, it returns an error.
-func AddOrIncrementBook(db *gorm.DB) gin.HandlerFunc {
+// Details missing from a new book are looked up by ISBN with provider, if not nil.
+func AddOrIncrementBook(db *gorm.DB, provider metadata.Provider) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var input AddBookInput
 		if err := c.ShouldBindJSON(&input); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		if err := prepareBookInput(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 
 		claims := c.MustGet("user").(jwt.MapClaims)
 		libraryID, err := getUintFromClaim(claims, "library_id")
@@ -39,76 +199,168 @@ func AddOrIncrementBook(db *gorm.DB) gin.HandlerFunc {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-
+		fillFromMetadata(c.Request.Context(), db, provider, libraryID, &input)
 
 		var book models.BookInventory
-		err = db.Where("isbn = ? AND library_id = ?", input.ISBN, libraryID).First(&book).Error
+		var created bool
+		err = db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			book, created, err = addOrIncrement(tx, libraryID, input)
+			return err
+		})
+		if isBookInputError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if created {
+			c.JSON(http.StatusCreated, gin.H{"message": "Book added successfully", "book": book})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"message": "Book copies incremented", "book": book})
+	}
+}
 
-		if err == nil {
-			// Book record exists: Increment copies.
-			book.TotalCopies += input.Copies
-			book.AvailableCopies += input.Copies
-			if err := db.Save(&book).Error; err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-				return
-			}
-			c.JSON(http.StatusCreated, gin.H{"message": "Book copies incremented", "book": book})
+// GetBooks returns all books for the library, narrowed down by the filters of
+// SearchBooks when given.
+func GetBooks(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := c.MustGet("user").(jwt.MapClaims)
+		libraryID := uint(claims["library_id"].(float64))
+
+		// SELECT * FROM books ORDER BY creation_date ASC LIMIT 50;
+
+		search, ok := catalogFilters(c, libraryID)
+		if !ok {
 			return
-		} else if err != gorm.ErrRecordNotFound {
-			// Some other error occurred.
+		}
+		books, err := services.ListBooks(db, search)
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		c.JSON(http.StatusOK, gin.H{"books": books})
+	}
+}
 
-		// Record not found.
-		if input.IncrementOnly {
-			// Cannot increment copies if record does not exist.
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Book not found, cannot increment copies. Please add new book details."})
+// SearchBooks searches the catalog of the caller's library. Query parameters: q
+// (keywords), language, publisher, author_id, subject_id, tag, work_id, available
+// (true/false), sort, order, limit and cursor (the next_cursor of the previous page).
+func SearchBooks(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := c.MustGet("user").(jwt.MapClaims)
+		libraryID, err := getUintFromClaim(claims, "library_id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 
-		// For a new book, require Title, Author, and Language.
-		if input.Title == "" || input.Author == "" || input.Language == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Title, Author, and Language are required for a new book"})
+		search, ok := catalogFilters(c, libraryID)
+		if !ok {
 			return
 		}
+		search.Sort = c.Query("sort")
+		search.Order = c.Query("order")
+		search.Cursor = c.Query("cursor")
+		if v := c.Query("limit"); v != "" {
+			if search.Limit, err = strconv.Atoi(v); err != nil || search.Limit <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive number"})
+				return
+			}
+		}
 
-		// Create a new book record.
-		newBook := models.BookInventory{
-			ISBN:            input.ISBN,
-			LibraryID:       libraryID,
-			Title:           input.Title,
-			Author:          input.Author,
-			Publisher:       input.Publisher,
-			Language:        input.Language,
-			Version:         input.Version,
-			TotalCopies:     input.Copies,
-			AvailableCopies: input.Copies,
+		result, err := services.SearchBooks(db, search)
+		if errors.Is(err, services.ErrInvalidCursor) || errors.Is(err, services.ErrInvalidSort) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
 		}
-		if err := db.Create(&newBook).Error; err != nil {
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		c.JSON(http.StatusCreated, gin.H{"message": "Book added successfully", "book": newBook})
+		c.JSON(http.StatusOK, result)
 	}
 }
 
-// GetBooks returns all books for the library.
-func GetBooks(db *gorm.DB) gin.HandlerFunc {
+// catalogFilters reads the catalog filters shared by listing, search and export (q,
+// language, publisher, author_id, subject_id, tag, work_id, available). It replies
+// 400 and returns false when one is malformed.
+func catalogFilters(c *gin.Context, libraryID uint) (services.BookSearch, bool) {
+	search := services.BookSearch{
+		LibraryID: libraryID,
+		Query:     c.Query("q"),
+		Language:  c.Query("language"),
+		Publisher: c.Query("publisher"),
+		Tag:       c.Query("tag"),
+	}
+	if v := c.Query("available"); v != "" {
+		available, err := strconv.ParseBool(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "available must be true or false"})
+			return search, false
+		}
+		search.Available = &available
+	}
+	if v := c.Query("author_id"); v != "" {
+		authorID, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "author_id must be a number"})
+			return search, false
+		}
+		search.AuthorID = uint(authorID)
+	}
+	if v := c.Query("subject_id"); v != "" {
+		subjectID, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "subject_id must be a number"})
+			return search, false
+		}
+		search.SubjectID = uint(subjectID)
+	}
+	if v := c.Query("work_id"); v != "" {
+		workID, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "work_id must be a number"})
+			return search, false
+		}
+		search.WorkID = uint(workID)
+	}
+	return search, true
+}
+
+// SuggestBooks offers titles and authors of the caller's library matching a partly
+// typed query (q), tolerating typos. Used for type-ahead.
+func SuggestBooks(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		claims := c.MustGet("user").(jwt.MapClaims)
-		libraryID := uint(claims["library_id"].(float64))
-
-		// SELECT * FROM books ORDER BY creation_date ASC LIMIT 50;
-
+		libraryID, err := getUintFromClaim(claims, "library_id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
 
+		q := c.Query("q")
+		if q == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+			return
+		}
+		limit := 0
+		if v := c.Query("limit"); v != "" {
+			if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive number"})
+				return
+			}
+		}
 
-		var books []models.BookInventory
-		if err := db.Where("library_id = ?", libraryID).Find(&books).Error; err != nil {
+		suggestions, err := services.SuggestBooks(db, libraryID, q, limit)
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{"books": books})
+		c.JSON(http.StatusOK, gin.H{"suggestions": suggestions})
 	}
 }
 
@@ -118,68 +370,193 @@ func RemoveBook(db *gorm.DB) gin.HandlerFunc {
 		claims := c.MustGet("user").(jwt.MapClaims)
 		libraryID := uint(claims["library_id"].(float64))
 		var payload struct {
-			ISBN   string `json:"isbn" binding:"required"`
-			Copies int    `json:"copies" binding:"required,gt=0"`
+			ISBN     string   `json:"isbn" binding:"required"`
+			Copies   int      `json:"copies" binding:"required,gt=0"`
+			Barcodes []string `json:"barcodes"` // specific copies to retire
 		}
 		if err := c.ShouldBindJSON(&payload); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		var ok bool
+		if payload.ISBN, ok = normalizeISBN(c, payload.ISBN); !ok {
+			return
+		}
 		var book models.BookInventory
 		if err := db.Where("isbn = ? AND library_id = ?", payload.ISBN, libraryID).First(&book).Error; err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
 			return
 		}
-		// Check if removal is valid (can't remove more than available non-issued copies)
-		issued := book.TotalCopies - book.AvailableCopies
-		if payload.Copies > (book.TotalCopies - issued) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot remove issued copies"})
+		if len(payload.Barcodes) > 0 && len(payload.Barcodes) != payload.Copies {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Number of barcodes must match copies"})
 			return
 		}
-		if book.TotalCopies-payload.Copies == 0 {
-			// Delete record if resulting copies is zero.
-			if err := db.Delete(&book).Error; err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-				return
+		// Retire the copies; issued copies cannot be removed.
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := services.WithdrawItems(tx, &book, payload.Copies, payload.Barcodes); err != nil {
+				return err
 			}
-			c.JSON(http.StatusOK, gin.H{"message": "Book record deleted"})
+			if book.TotalCopies == 0 {
+				// Delete record if resulting copies is zero.
+				return tx.Delete(&book).Error
+			}
+			return nil
+		})
+		if errors.Is(err, services.ErrItemNotAvailable) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot remove issued copies"})
 			return
 		}
-		book.TotalCopies -= payload.Copies
-		if book.AvailableCopies >= payload.Copies {
-			book.AvailableCopies -= payload.Copies
-		} else {
-			book.AvailableCopies = 0
-		}
-		if err := db.Save(&book).Error; err != nil {
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		if book.TotalCopies == 0 {
+			c.JSON(http.StatusOK, gin.H{"message": "Book record deleted"})
+			return
+		}
 		c.JSON(http.StatusOK, gin.H{"message": "Book copies removed", "book": book})
 	}
 }
 
-// UpdateBook updates book details.
+// UpdateBookInput is the payload for editing a book's catalog details. Fields left
+// out are kept and any other field is refused: copies and their counters change
+// through Add Book and Remove Book, the work through the work endpoints.
+type UpdateBookInput struct {
+	ISBN   *string `json:"isbn"`
+	Title  *string `json:"title"`
+	Author *string `json:"author"`
+	// Credits replace the author field; a new author field replaces the credits.
+	Authors          *[]services.AuthorCredit `json:"authors"`
+	Publisher        *string                  `json:"publisher"`
+	Language         *string                  `json:"language"`
+	Version          *string                  `json:"version"`
+	CallNumber       *string                  `json:"call_number"`
+	CallNumberScheme *string                  `json:"call_number_scheme"`
+	Floor            *string                  `json:"floor"`
+	Section          *string                  `json:"section"`
+	Shelf            *string                  `json:"shelf"`
+}
+
+// bindUpdateBookInput reads an UpdateBookInput, refusing fields that cannot be
+// edited by name.
+func bindUpdateBookInput(c *gin.Context) (UpdateBookInput, error) {
+	var input UpdateBookInput
+	decoder := json.NewDecoder(c.Request.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&input); err != nil {
+		if field, unknown := strings.CutPrefix(err.Error(), "json: unknown field "); unknown {
+			return input, fmt.Errorf("%s cannot be edited", field)
+		}
+		return input, err
+	}
+	return input, nil
+}
+
+// bookEditValues checks an UpdateBookInput and turns it into the field values of
+// services.EditBook: the ISBN and call number normalized, the credits and author
+// field matching each other.
+func bookEditValues(input UpdateBookInput, book models.BookInventory) (map[string]string, error) {
+	values := map[string]string{}
+	if input.ISBN != nil {
+		normalized, err := isbn.Normalize(*input.ISBN)
+		if err != nil {
+			return nil, errors.New("Invalid ISBN: " + *input.ISBN)
+		}
+		values["isbn"] = normalized
+	}
+	for field, value := range map[string]*string{
+		"title": input.Title, "author": input.Author, "language": input.Language,
+		"publisher": input.Publisher, "version": input.Version,
+		"floor": input.Floor, "section": input.Section, "shelf": input.Shelf,
+	} {
+		if value == nil {
+			continue
+		}
+		values[field] = strings.TrimSpace(*value)
+		if values[field] == "" && (field == "title" || field == "author" || field == "language") {
+			return nil, fmt.Errorf("%s cannot be empty", field)
+		}
+	}
+
+	if input.Authors != nil {
+		credits, err := services.NormalizeCredits(*input.Authors)
+		if err != nil || len(credits) == 0 {
+			return nil, services.ErrInvalidCredit
+		}
+		values["authors"], values["author"] = services.EncodeCredits(credits), services.AuthorDisplay(credits)
+	} else if input.Author != nil {
+		credits, err := services.NormalizeCredits(services.CreditsFromAuthorField(values["author"]))
+		if err != nil {
+			return nil, err
+		}
+		values["authors"] = services.EncodeCredits(credits)
+	}
+
+	// A call number is stored parsed; a new scheme alone reparses the current one.
+	if input.CallNumber != nil || input.CallNumberScheme != nil {
+		raw, scheme := book.CallNumber, book.CallNumberScheme
+		if input.CallNumber != nil {
+			raw, scheme = *input.CallNumber, ""
+		}
+		if input.CallNumberScheme != nil {
+			scheme = *input.CallNumberScheme
+		}
+		parsed := book
+		if err := services.SetCallNumber(&parsed, scheme, raw); err != nil {
+			return nil, err
+		}
+		values["call_number"], values["call_number_scheme"] = parsed.CallNumber, parsed.CallNumberScheme
+	}
+	return values, nil
+}
+
+// UpdateBook edits the catalog details of a book, recording the fields that changed
+// as a revision; see GetBookRevisions.
 func UpdateBook(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		isbn := c.Param("isbn")
-		claims := c.MustGet("user").(jwt.MapClaims)
-		libraryID := uint(claims["library_id"].(float64))
+		libraryID, ok := staffLibrary(c, "update books")
+		if !ok {
+			return
+		}
+		editorID, err := getUintFromClaim(c.MustGet("user").(jwt.MapClaims), "id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		bookISBN, ok := normalizeISBN(c, c.Param("isbn"))
+		if !ok {
+			return
+		}
 		var book models.BookInventory
-		if err := db.Where("isbn = ? AND library_id = ?", isbn, libraryID).First(&book).Error; err != nil {
+		if err := db.Where("isbn = ? AND library_id = ?", bookISBN, libraryID).First(&book).Error; err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
 			return
 		}
-		var input map[string]interface{}
-		if err := c.ShouldBindJSON(&input); err != nil {
+		input, err := bindUpdateBookInput(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		values, err := bookEditValues(input, book)
+		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-		// Update only provided fields.
-		if err := db.Model(&book).Updates(input).Error; err != nil {
+
+		var revision *models.BookRevision
+		err = db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			revision, err = services.EditBook(tx, &book, editorID, values, nil)
+			return err
+		})
+		if errors.Is(err, services.ErrISBNInUse) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{"message": "Book updated", "book": book})
+		c.JSON(http.StatusOK, gin.H{"message": "Book updated", "book": book, "revision": revision})
 	}
 }
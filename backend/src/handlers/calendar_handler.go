@@ -0,0 +1,337 @@
+// /backend/src/handlers/calendar_handler.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"github.com/swapxs/LibMS/backend/src/services"
+	"gorm.io/gorm"
+)
+
+// HolidayInput is the payload for adding a holiday.
+type HolidayInput struct {
+	Date string `json:"date" binding:"required"` // YYYY-MM-DD
+	Name string `json:"name"`
+}
+
+// OpeningHoursInput is the weekly schedule of a library. An empty list means the
+// library is open every day.
+type OpeningHoursInput struct {
+	Hours []struct {
+		Weekday *int   `json:"weekday" binding:"required,min=0,max=6"` // 0 = Sunday
+		Opens   string `json:"opens" binding:"required"`               // HH:MM
+		Closes  string `json:"closes" binding:"required"`              // HH:MM
+	} `json:"hours" binding:"dive"`
+}
+
+// ClosureInput is the payload for adding an exceptional closure.
+type ClosureInput struct {
+	StartDate string `json:"start_date" binding:"required"` // YYYY-MM-DD
+	EndDate   string `json:"end_date" binding:"required"`   // YYYY-MM-DD, inclusive
+	Reason    string `json:"reason"`
+}
+
+// CalendarDay tells whether the library is open on a date.
+type CalendarDay struct {
+	Date string `json:"date"`
+	Open bool   `json:"open"`
+}
+
+// maxCalendarDays bounds the range returned by GetLibraryCalendar.
+const maxCalendarDays = 366
+
+// GetHolidays lists the holidays of the caller's library, oldest first.
+func GetHolidays(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := c.MustGet("user").(jwt.MapClaims)
+		libraryID, err := getUintFromClaim(claims, "library_id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		var holidays []models.LibraryHoliday
+		if err := db.Where("library_id = ?", libraryID).Order("date ASC").Find(&holidays).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "holidays": holidays})
+	}
+}
+
+// AddHoliday closes the caller's library on a date. Books do not fall due on holidays.
+func AddHoliday(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		libraryID, ok := staffLibrary(c, "manage holidays")
+		if !ok {
+			return
+		}
+		var input HolidayInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		date, err := time.Parse("2006-01-02", input.Date)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Date must be in YYYY-MM-DD format"})
+			return
+		}
+
+		var count int64
+		if err := db.Model(&models.LibraryHoliday{}).
+			Where("library_id = ? AND date = ?", libraryID, services.CalendarDate(date)).
+			Count(&count).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if count > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "This date is already a holiday"})
+			return
+		}
+		holiday := models.LibraryHoliday{LibraryID: libraryID, Date: services.CalendarDate(date), Name: input.Name}
+		if err := db.Create(&holiday).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"message": "Holiday added", "holiday": holiday})
+	}
+}
+
+// DeleteHoliday reopens the library on a holiday. Due dates already set are kept.
+func DeleteHoliday(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		libraryID, ok := staffLibrary(c, "manage holidays")
+		if !ok {
+			return
+		}
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid holiday ID"})
+			return
+		}
+		res := db.Unscoped().Where("id = ? AND library_id = ?", id, libraryID).Delete(&models.LibraryHoliday{})
+		if res.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": res.Error.Error()})
+			return
+		}
+		if res.RowsAffected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Holiday not found"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Holiday deleted"})
+	}
+}
+
+// UpdateOpeningHours replaces the weekly opening hours of the caller's library.
+func UpdateOpeningHours(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		libraryID, ok := staffLibrary(c, "manage opening hours")
+		if !ok {
+			return
+		}
+		var input OpeningHoursInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		hours := make([]models.LibraryOpeningHours, 0, len(input.Hours))
+		seen := map[int]bool{}
+		for _, h := range input.Hours {
+			opens, err1 := time.Parse("15:04", h.Opens)
+			closes, err2 := time.Parse("15:04", h.Closes)
+			if err1 != nil || err2 != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Opening hours must be in HH:MM format"})
+				return
+			}
+			if !closes.After(opens) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Closing time must be after opening time"})
+				return
+			}
+			if seen[*h.Weekday] {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Each weekday may appear only once"})
+				return
+			}
+			seen[*h.Weekday] = true
+			hours = append(hours, models.LibraryOpeningHours{
+				LibraryID: libraryID, Weekday: *h.Weekday, Opens: h.Opens, Closes: h.Closes,
+			})
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Unscoped().Where("library_id = ?", libraryID).Delete(&models.LibraryOpeningHours{}).Error; err != nil {
+				return err
+			}
+			if len(hours) == 0 {
+				return nil
+			}
+			return tx.Create(&hours).Error
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Opening hours updated", "hours": hours})
+	}
+}
+
+// GetClosures lists the exceptional closures of the caller's library, oldest first.
+func GetClosures(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := c.MustGet("user").(jwt.MapClaims)
+		libraryID, err := getUintFromClaim(claims, "library_id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		var closures []models.LibraryClosure
+		if err := db.Where("library_id = ?", libraryID).Order("start_date ASC").Find(&closures).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "closures": closures})
+	}
+}
+
+// AddClosure closes the caller's library for a range of days.
+func AddClosure(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		libraryID, ok := staffLibrary(c, "manage closures")
+		if !ok {
+			return
+		}
+		var input ClosureInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		start, err1 := time.Parse("2006-01-02", input.StartDate)
+		end, err2 := time.Parse("2006-01-02", input.EndDate)
+		if err1 != nil || err2 != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Dates must be in YYYY-MM-DD format"})
+			return
+		}
+		if end.Before(start) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "End date must not be before start date"})
+			return
+		}
+
+		closure := models.LibraryClosure{
+			LibraryID: libraryID,
+			StartDate: services.CalendarDate(start),
+			EndDate:   services.CalendarDate(end),
+			Reason:    input.Reason,
+		}
+		if err := db.Create(&closure).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"message": "Closure added", "closure": closure})
+	}
+}
+
+// DeleteClosure removes an exceptional closure. Due dates already set are kept.
+func DeleteClosure(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		libraryID, ok := staffLibrary(c, "manage closures")
+		if !ok {
+			return
+		}
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid closure ID"})
+			return
+		}
+		res := db.Unscoped().Where("id = ? AND library_id = ?", id, libraryID).Delete(&models.LibraryClosure{})
+		if res.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": res.Error.Error()})
+			return
+		}
+		if res.RowsAffected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Closure not found"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Closure deleted"})
+	}
+}
+
+// GetLibraryCalendar returns a library's opening hours, holidays and closures and,
+// for each day from from to to (YYYY-MM-DD, default the next 30 days), whether it is
+// open. It does not require authentication.
+func GetLibraryCalendar(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		libraryID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid library ID"})
+			return
+		}
+		var library models.Library
+		if err := db.First(&library, libraryID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Library not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+
+		from := services.CalendarDate(time.Now())
+		if v := c.Query("from"); v != "" {
+			if from, err = time.Parse("2006-01-02", v); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "from must be in YYYY-MM-DD format"})
+				return
+			}
+		}
+		to := from.AddDate(0, 0, 30)
+		if v := c.Query("to"); v != "" {
+			if to, err = time.Parse("2006-01-02", v); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "to must be in YYYY-MM-DD format"})
+				return
+			}
+		}
+		if to.Before(from) || to.Sub(from) > maxCalendarDays*24*time.Hour {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Range must be between 0 and 366 days"})
+			return
+		}
+
+		cal, err := services.LoadCalendar(db, library.ID, from, to)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		var hours []models.LibraryOpeningHours
+		var holidays []models.LibraryHoliday
+		var closures []models.LibraryClosure
+		if err := db.Where("library_id = ?", library.ID).Order("weekday ASC").Find(&hours).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if err := db.Where("library_id = ? AND date >= ? AND date <= ?", library.ID, from, to).
+			Order("date ASC").Find(&holidays).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if err := db.Where("library_id = ? AND start_date <= ? AND end_date >= ?", library.ID, to, from).
+			Order("start_date ASC").Find(&closures).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		days := []CalendarDay{}
+		for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+			days = append(days, CalendarDay{Date: day.Format("2006-01-02"), Open: cal.IsOpen(day)})
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"library_id": library.ID,
+			"hours":      hours,
+			"holidays":   holidays,
+			"closures":   closures,
+			"days":       days,
+		})
+	}
+}
@@ -0,0 +1,176 @@
+// /backend/src/handlers/cover_handler.go
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"github.com/swapxs/LibMS/backend/src/services"
+	"github.com/swapxs/LibMS/backend/src/storage"
+	"gorm.io/gorm"
+)
+
+// coverStore checks that covers can be stored at all.
+func coverStore(c *gin.Context, store storage.Store) bool {
+	if store == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Cover storage is not configured"})
+		return false
+	}
+	return true
+}
+
+// UploadBookCover stores the cover image sent as the "file" field of a multipart
+// form, replacing any earlier cover, along with a thumbnail. Covers may be JPEG, PNG
+// or GIF images of up to services.MaxCoverSize bytes.
+func UploadBookCover(db *gorm.DB, store storage.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		libraryID, ok := staffLibrary(c, "upload covers")
+		if !ok || !coverStore(c, store) {
+			return
+		}
+		book, ok := findLibraryBook(c, db, libraryID)
+		if !ok {
+			return
+		}
+
+		// Leave room for the multipart framing around the image.
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, services.MaxCoverSize+64<<10)
+		file, err := c.FormFile("file")
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": services.ErrCoverTooLarge.Error()})
+			} else {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "A cover image is required in the file field"})
+			}
+			return
+		}
+		if file.Size > services.MaxCoverSize {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": services.ErrCoverTooLarge.Error()})
+			return
+		}
+		f, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		data, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		contentType, thumbnail, err := services.ProcessCover(data)
+		switch {
+		case errors.Is(err, services.ErrCoverTooLarge):
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, services.ErrUnsupportedCover):
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, services.ErrInvalidCover):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx := c.Request.Context()
+		if err := store.Put(ctx, services.CoverKey(book, false), data, contentType); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if err := store.Put(ctx, services.CoverKey(book, true), thumbnail, "image/jpeg"); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if err := db.Model(&book).Update("cover_content_type", contentType).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Cover uploaded", "book": book})
+	}
+}
+
+// GetBookCover sends the cover of a book, or its thumbnail with ?size=thumb.
+func GetBookCover(db *gorm.DB, store storage.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := c.MustGet("user").(jwt.MapClaims)
+		libraryID, err := getUintFromClaim(claims, "library_id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !coverStore(c, store) {
+			return
+		}
+		book, ok := findLibraryBook(c, db, libraryID)
+		if !ok {
+			return
+		}
+		thumb := c.Query("size") == "thumb"
+		if !thumb && c.Query("size") != "" && c.Query("size") != "full" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "size must be full or thumb"})
+			return
+		}
+		if book.CoverContentType == "" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Book has no cover"})
+			return
+		}
+
+		obj, err := store.Get(c.Request.Context(), services.CoverKey(book, thumb))
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Book has no cover"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		defer obj.Close()
+		contentType := book.CoverContentType
+		if thumb {
+			contentType = "image/jpeg"
+		}
+		c.DataFromReader(http.StatusOK, obj.Size, contentType, obj, map[string]string{"Cache-Control": "private, max-age=3600"})
+	}
+}
+
+// DeleteBookCover removes the cover of a book and its thumbnail.
+func DeleteBookCover(db *gorm.DB, store storage.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		libraryID, ok := staffLibrary(c, "delete covers")
+		if !ok || !coverStore(c, store) {
+			return
+		}
+		book, ok := findLibraryBook(c, db, libraryID)
+		if !ok {
+			return
+		}
+		if err := deleteCover(c, store, book); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if err := db.Model(&book).Update("cover_content_type", "").Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Cover deleted"})
+	}
+}
+
+// deleteCover removes a book's cover and thumbnail from the store.
+func deleteCover(c *gin.Context, store storage.Store, book models.BookInventory) error {
+	for _, thumb := range []bool{false, true} {
+		if err := store.Delete(c.Request.Context(), services.CoverKey(book, thumb)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
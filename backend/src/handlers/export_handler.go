@@ -0,0 +1,234 @@
+// /backend/src/handlers/export_handler.go
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/swapxs/LibMS/backend/src/marc"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"github.com/swapxs/LibMS/backend/src/services"
+	"gorm.io/gorm"
+)
+
+// exportBatchSize is the number of books read at a time while streaming an export.
+const exportBatchSize = 500
+
+// ExportedBook is a book as it appears in CSV and JSON Lines exports.
+type ExportedBook struct {
+	ID              uint   `json:"id"`
+	ISBN            string `json:"isbn"`
+	Title           string `json:"title"`
+	Author          string `json:"author"`
+	Publisher       string `json:"publisher"`
+	Language        string `json:"language"`
+	Version         string `json:"version"`
+	TotalCopies     int    `json:"total_copies"`
+	AvailableCopies int    `json:"available_copies"`
+}
+
+// exportColumns is the header of CSV exports, in the order of ExportedBook.
+var exportColumns = []string{"id", "isbn", "title", "author", "publisher", "language", "version", "total_copies", "available_copies"}
+
+func exportedBook(book models.BookInventory) ExportedBook {
+	return ExportedBook{
+		ID:              book.ID,
+		ISBN:            book.ISBN,
+		Title:           book.Title,
+		Author:          book.Author,
+		Publisher:       book.Publisher,
+		Language:        book.Language,
+		Version:         book.Version,
+		TotalCopies:     book.TotalCopies,
+		AvailableCopies: book.AvailableCopies,
+	}
+}
+
+// catalogWriter writes an export one book at a time. Flush is called after every
+// batch, Close once all books are written.
+type catalogWriter interface {
+	Write(book models.BookInventory) error
+	Flush() error
+	Close() error
+}
+
+// exportFormat describes one of the formats ExportBooks can produce.
+type exportFormat struct {
+	contentType string
+	extension   string
+	newWriter   func(w io.Writer) (catalogWriter, error)
+}
+
+var exportFormats = map[string]exportFormat{
+	"csv":     {"text/csv; charset=utf-8", "csv", newCSVExport},
+	"jsonl":   {"application/x-ndjson", "jsonl", newJSONLExport},
+	"bibtex":  {"application/x-bibtex; charset=utf-8", "bib", newBibTeXExport},
+	"marcxml": {"application/marcxml+xml; charset=utf-8", "xml", newMARCExport},
+}
+
+// ExportBooks streams the catalog of the caller's library in the format given by
+// ?format= (csv, jsonl, bibtex or marcxml; default csv). The filters of SearchBooks
+// (q, language, publisher, available) narrow the export down.
+func ExportBooks(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format := strings.ToLower(c.DefaultQuery("format", "csv"))
+		if _, ok := exportFormats[format]; !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown format: " + format})
+			return
+		}
+		exportCatalog(c, db, format)
+	}
+}
+
+// exportCatalog streams the books of the caller's library matching the request's
+// filters in the given format.
+func exportCatalog(c *gin.Context, db *gorm.DB, format string) {
+	libraryID, ok := staffLibrary(c, "export books")
+	if !ok {
+		return
+	}
+	search, ok := catalogFilters(c, libraryID)
+	if !ok {
+		return
+	}
+
+	f := exportFormats[format]
+	c.Header("Content-Type", f.contentType)
+	c.Header("Content-Disposition", `attachment; filename="catalog.`+f.extension+`"`)
+	c.Status(http.StatusOK)
+	writer, err := f.newWriter(c.Writer)
+	count := 0
+	if err == nil {
+		err = services.EachBook(db, search, exportBatchSize, func(book models.BookInventory) error {
+			if err := writer.Write(book); err != nil {
+				return err
+			}
+			if count++; count%exportBatchSize == 0 {
+				if err := writer.Flush(); err != nil {
+					return err
+				}
+				c.Writer.Flush()
+			}
+			return nil
+		})
+	}
+	if err != nil {
+		// The status is already sent. The export is left without its end (no closing
+		// collection tag, or a partial last line) so the client can tell it is incomplete.
+		c.Error(err)
+		writer.Flush()
+		return
+	}
+	if err := writer.Close(); err != nil {
+		c.Error(err)
+	}
+}
+
+// csvExport writes a header row, then one row per book.
+type csvExport struct {
+	w *csv.Writer
+}
+
+func newCSVExport(w io.Writer) (catalogWriter, error) {
+	e := &csvExport{w: csv.NewWriter(w)}
+	return e, e.w.Write(exportColumns)
+}
+
+func (e *csvExport) Write(book models.BookInventory) error {
+	b := exportedBook(book)
+	return e.w.Write([]string{
+		strconv.FormatUint(uint64(b.ID), 10), b.ISBN, b.Title, b.Author, b.Publisher,
+		b.Language, b.Version, strconv.Itoa(b.TotalCopies), strconv.Itoa(b.AvailableCopies),
+	})
+}
+
+func (e *csvExport) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvExport) Close() error { return e.Flush() }
+
+// jsonlExport writes one JSON object per line.
+type jsonlExport struct {
+	encoder *json.Encoder
+}
+
+func newJSONLExport(w io.Writer) (catalogWriter, error) {
+	return &jsonlExport{encoder: json.NewEncoder(w)}, nil
+}
+
+func (e *jsonlExport) Write(book models.BookInventory) error {
+	return e.encoder.Encode(exportedBook(book))
+}
+
+func (e *jsonlExport) Flush() error { return nil }
+func (e *jsonlExport) Close() error { return nil }
+
+// bibtexEscaper escapes the characters LaTeX treats specially. Replacements are made
+// in one pass, so the braces they add are not escaped again.
+var bibtexEscaper = strings.NewReplacer(
+	`\`, `\textbackslash{}`, "{", `\{`, "}", `\}`, "&", `\&`, "%", `\%`, "$", `\$`,
+	"#", `\#`, "_", `\_`, "~", `\textasciitilde{}`, "^", `\textasciicircum{}`,
+)
+
+// bibtexExport writes one @book entry per book, keyed by ISBN.
+type bibtexExport struct {
+	w io.Writer
+}
+
+func newBibTeXExport(w io.Writer) (catalogWriter, error) {
+	return &bibtexExport{w: w}, nil
+}
+
+func (e *bibtexExport) Write(book models.BookInventory) error {
+	var entry strings.Builder
+	fmt.Fprintf(&entry, "@book{isbn%s,\n", book.ISBN)
+	for _, field := range [][2]string{
+		{"isbn", book.ISBN},
+		{"title", book.Title},
+		{"author", book.Author},
+		{"publisher", book.Publisher},
+		{"edition", book.Version},
+		{"language", book.Language},
+	} {
+		if field[1] != "" {
+			fmt.Fprintf(&entry, "  %s = {%s},\n", field[0], bibtexEscaper.Replace(field[1]))
+		}
+	}
+	entry.WriteString("}\n\n")
+	_, err := io.WriteString(e.w, entry.String())
+	return err
+}
+
+func (e *bibtexExport) Flush() error { return nil }
+func (e *bibtexExport) Close() error { return nil }
+
+// marcExport writes a MARCXML collection, one record per book.
+type marcExport struct {
+	w *marc.XMLWriter
+}
+
+func newMARCExport(w io.Writer) (catalogWriter, error) {
+	return &marcExport{w: marc.NewXMLWriter(w)}, nil
+}
+
+func (e *marcExport) Write(book models.BookInventory) error {
+	return e.w.Write(marc.BookRecord(strconv.Itoa(int(book.ID)), marc.Book{
+		ISBN:      book.ISBN,
+		Title:     book.Title,
+		Author:    book.Author,
+		Publisher: book.Publisher,
+		Language:  book.Language,
+		Edition:   book.Version,
+	}))
+}
+
+func (e *marcExport) Flush() error { return nil }
+func (e *marcExport) Close() error { return e.w.Close() }
@@ -0,0 +1,306 @@
+// /backend/src/handlers/fine_handler.go
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"github.com/swapxs/LibMS/backend/src/services"
+	"gorm.io/gorm"
+)
+
+// FineDetail is a fine together with its outstanding balance.
+type FineDetail struct {
+	models.Fine
+	BalanceCents int64 `json:"balance_cents"`
+}
+
+// ReaderBalance is the total a reader owes across all of their fines.
+type ReaderBalance struct {
+	ReaderID     uint   `json:"reader_id"`
+	ReaderName   string `json:"reader_name"`
+	BalanceCents int64  `json:"balance_cents"`
+}
+
+// FinePolicyInput is the payload for configuring a library's fine policy.
+type FinePolicyInput struct {
+	DailyRateCents      *int64 `json:"daily_rate_cents" binding:"required,min=0"`
+	GracePeriodDays     *int   `json:"grace_period_days" binding:"required,min=0"`
+	MaxFineCents        *int64 `json:"max_fine_cents" binding:"required,min=0"`
+	BlockThresholdCents *int64 `json:"block_threshold_cents" binding:"required,min=0"`
+}
+
+// FineTransactionInput is the payload for paying, waiving or adjusting a fine.
+type FineTransactionInput struct {
+	AmountCents int64  `json:"amount_cents"`
+	Note        string `json:"note"`
+}
+
+func isLibraryStaff(claims jwt.MapClaims) bool {
+	role, _ := claims["role"].(string)
+	return role == "LibraryAdmin" || role == "Owner"
+}
+
+// GetFines lists fines. Readers see their own fines; admins see every fine in the
+// library, optionally filtered by reader_id.
+func GetFines(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := c.MustGet("user").(jwt.MapClaims)
+		userID, err := getUintFromClaim(claims, "id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		libraryID, err := getUintFromClaim(claims, "library_id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		query := db.Where("library_id = ?", libraryID)
+		if !isLibraryStaff(claims) {
+			query = query.Where("reader_id = ?", userID)
+		} else if readerID := c.Query("reader_id"); readerID != "" {
+			query = query.Where("reader_id = ?", readerID)
+		}
+
+		var fines []models.Fine
+		if err := query.Order("id ASC").Find(&fines).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		details := make([]FineDetail, 0, len(fines))
+		for _, f := range fines {
+			details = append(details, FineDetail{Fine: f, BalanceCents: f.BalanceCents()})
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "fines": details})
+	}
+}
+
+// GetFineBalances returns outstanding balances per reader. Readers only see their own.
+func GetFineBalances(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := c.MustGet("user").(jwt.MapClaims)
+		userID, err := getUintFromClaim(claims, "id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		libraryID, err := getUintFromClaim(claims, "library_id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		query := db.Table("fines").
+			Select(`fines.reader_id AS reader_id, users.name AS reader_name,
+				SUM(fines.accrued_cents + fines.adjustment_cents - fines.paid_cents - fines.waived_cents) AS balance_cents`).
+			Joins("LEFT JOIN users ON users.id = fines.reader_id").
+			Where("fines.library_id = ? AND fines.deleted_at IS NULL", libraryID)
+		if !isLibraryStaff(claims) {
+			query = query.Where("fines.reader_id = ?", userID)
+		}
+
+		var balances []ReaderBalance
+		if err := query.Group("fines.reader_id, users.name").Order("fines.reader_id ASC").
+			Scan(&balances).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "balances": balances})
+	}
+}
+
+// GetFinePolicy returns the fine policy of the caller's library.
+func GetFinePolicy(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := c.MustGet("user").(jwt.MapClaims)
+		libraryID, err := getUintFromClaim(claims, "library_id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		policy, err := services.LoadFinePolicy(db, libraryID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"policy": policy})
+	}
+}
+
+// UpdateFinePolicy creates or replaces the fine policy of the caller's library.
+func UpdateFinePolicy(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := c.MustGet("user").(jwt.MapClaims)
+		if !isLibraryStaff(claims) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Only admin can change the fine policy"})
+			return
+		}
+		libraryID, err := getUintFromClaim(claims, "library_id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var input FinePolicyInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		policy, err := services.LoadFinePolicy(db, libraryID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		policy.DailyRateCents = *input.DailyRateCents
+		policy.GracePeriodDays = *input.GracePeriodDays
+		policy.MaxFineCents = *input.MaxFineCents
+		policy.BlockThresholdCents = *input.BlockThresholdCents
+		if err := db.Save(&policy).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Fine policy updated", "policy": policy})
+	}
+}
+
+var (
+	// errInvalidFineAmount is returned when a transaction would leave a negative balance.
+	errInvalidFineAmount = errors.New("amount exceeds the outstanding balance")
+	// errNothingToWaive is returned when a full waiver finds nothing owed.
+	errNothingToWaive = errors.New("nothing to waive")
+)
+
+// fineColumns maps each transaction type to the fine column it adds to.
+var fineColumns = map[string]string{
+	"Payment":    "paid_cents",
+	"Waiver":     "waived_cents",
+	"Adjustment": "adjustment_cents",
+}
+
+// recordFineTransaction applies a payment, waiver or adjustment to a fine and logs it.
+func recordFineTransaction(db *gorm.DB, txType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		fineID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid fine ID"})
+			return
+		}
+
+		claims := c.MustGet("user").(jwt.MapClaims)
+		if !isLibraryStaff(claims) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Only admin can manage fines"})
+			return
+		}
+		actorID, err := getUintFromClaim(claims, "id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		libraryID, err := getUintFromClaim(claims, "library_id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var input FineTransactionInput
+		if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var fine models.Fine
+		if err := db.Where("id = ? AND library_id = ?", fineID, libraryID).First(&fine).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Fine not found"})
+			return
+		}
+
+		amount := input.AmountCents
+		switch txType {
+		case "Payment":
+			if amount <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "amount_cents must be positive"})
+				return
+			}
+		case "Waiver":
+			if amount < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to waive"})
+				return
+			}
+		case "Adjustment":
+			if amount == 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "amount_cents must be non-zero"})
+				return
+			}
+		}
+
+		// Apply the amount to the stored columns rather than writing back the fine
+		// read above, so that concurrent payments and accruals are kept, then check the
+		// balance as it stands after the update.
+		column := fineColumns[txType]
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.First(&fine, fine.ID).Error; err != nil {
+				return err
+			}
+			// Waive the whole balance unless told otherwise.
+			if txType == "Waiver" && amount == 0 {
+				amount = fine.BalanceCents()
+				if amount <= 0 {
+					return errNothingToWaive
+				}
+			}
+			if err := tx.Model(&fine).Update(column, gorm.Expr(column+" + ?", amount)).Error; err != nil {
+				return err
+			}
+			if err := tx.First(&fine, fine.ID).Error; err != nil {
+				return err
+			}
+			if fine.BalanceCents() < 0 {
+				return errInvalidFineAmount
+			}
+			return tx.Create(&models.FineTransaction{
+				FineID:      fine.ID,
+				Type:        txType,
+				AmountCents: amount,
+				Note:        input.Note,
+				ActorID:     actorID,
+			}).Error
+		})
+		if errors.Is(err, errNothingToWaive) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to waive"})
+			return
+		}
+		if errors.Is(err, errInvalidFineAmount) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Fine updated", "fine": FineDetail{Fine: fine, BalanceCents: fine.BalanceCents()}})
+	}
+}
+
+// PayFine records a payment against a fine.
+func PayFine(db *gorm.DB) gin.HandlerFunc {
+	return recordFineTransaction(db, "Payment")
+}
+
+// WaiveFine waives part of a fine, or the whole outstanding balance when no amount is given.
+func WaiveFine(db *gorm.DB) gin.HandlerFunc {
+	return recordFineTransaction(db, "Waiver")
+}
+
+// AdjustFine adds a signed correction to a fine.
+func AdjustFine(db *gorm.DB) gin.HandlerFunc {
+	return recordFineTransaction(db, "Adjustment")
+}
@@ -0,0 +1,250 @@
+// /backend/src/handlers/hold_handler.go
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"github.com/swapxs/LibMS/backend/src/services"
+	"gorm.io/gorm"
+)
+
+// activeHoldStatuses are the statuses that keep a reader in the queue.
+var activeHoldStatuses = []string{"Waiting", "Offered"}
+
+// PlaceHoldInput represents the payload for placing a hold.
+type PlaceHoldInput struct {
+	BookID string `json:"bookID" binding:"required"`
+}
+
+// HoldDetail is a hold together with the reader's current place in the queue.
+// Position is 0 once a copy has been offered.
+type HoldDetail struct {
+	models.Hold
+	Position int64 `json:"position"`
+}
+
+// holdPosition returns the 1-based place of a waiting hold in its queue.
+func holdPosition(db *gorm.DB, hold models.Hold) (int64, error) {
+	if hold.Status != "Waiting" {
+		return 0, nil
+	}
+	var ahead int64
+	err := db.Model(&models.Hold{}).
+		Where("isbn = ? AND library_id = ? AND status = ? AND id < ?", hold.ISBN, hold.LibraryID, "Waiting", hold.ID).
+		Count(&ahead).Error
+	return ahead + 1, err
+}
+
+// PlaceHold adds the reader to the hold queue of a title that has no copies to spare.
+func PlaceHold(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input PlaceHoldInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		var ok bool
+		if input.BookID, ok = normalizeISBN(c, input.BookID); !ok {
+			return
+		}
+
+		claims := c.MustGet("user").(jwt.MapClaims)
+		readerID, err := getUintFromClaim(claims, "id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		libraryID, err := getUintFromClaim(claims, "library_id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var book models.BookInventory
+		if err := db.Where("isbn = ? AND library_id = ?", input.BookID, libraryID).First(&book).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Book not found in your library"})
+			return
+		}
+
+		var existing int64
+		if err := db.Model(&models.Hold{}).
+			Where("isbn = ? AND library_id = ? AND reader_id = ? AND status IN ?", book.ISBN, libraryID, readerID, activeHoldStatuses).
+			Count(&existing).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if existing > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "You already have a hold on this book"})
+			return
+		}
+
+		offered, err := services.CountOfferedHolds(db, book.ISBN, libraryID, 0)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if int64(book.AvailableCopies)-offered > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Book is available, raise an issue request instead"})
+			return
+		}
+
+		hold := models.Hold{
+			ISBN:      book.ISBN,
+			LibraryID: libraryID,
+			ReaderID:  readerID,
+			Status:    "Waiting",
+		}
+		if err := db.Create(&hold).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		position, err := holdPosition(db, hold)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"message": "Hold placed", "hold": HoldDetail{Hold: hold, Position: position}})
+	}
+}
+
+// GetHolds lists holds with their queue positions. Readers see their own active holds;
+// admins and owners see every hold in the library, optionally filtered by isbn and status.
+func GetHolds(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := c.MustGet("user").(jwt.MapClaims)
+		userID, err := getUintFromClaim(claims, "id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		libraryID, err := getUintFromClaim(claims, "library_id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		role, _ := claims["role"].(string)
+
+		query := db.Where("library_id = ?", libraryID)
+		if role == "Reader" {
+			query = query.Where("reader_id = ? AND status IN ?", userID, activeHoldStatuses)
+		} else {
+			if isbn := c.Query("isbn"); isbn != "" {
+				query = query.Where("isbn = ?", isbn)
+			}
+			if status := c.Query("status"); status != "" {
+				query = query.Where("status = ?", status)
+			}
+		}
+
+		var holds []models.Hold
+		if err := query.Order("id ASC").Find(&holds).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		details := make([]HoldDetail, 0, len(holds))
+		for _, h := range holds {
+			position, err := holdPosition(db, h)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			details = append(details, HoldDetail{Hold: h, Position: position})
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "holds": details})
+	}
+}
+
+// CancelHold removes a hold from its queue. Readers may cancel their own holds;
+// admins may cancel any hold in their library. A cancelled offer is passed on.
+func CancelHold(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		holdID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hold ID"})
+			return
+		}
+
+		claims := c.MustGet("user").(jwt.MapClaims)
+		userID, err := getUintFromClaim(claims, "id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		libraryID, err := getUintFromClaim(claims, "library_id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		role, _ := claims["role"].(string)
+
+		query := db.Where("id = ? AND library_id = ?", holdID, libraryID)
+		if role != "LibraryAdmin" && role != "Owner" {
+			query = query.Where("reader_id = ?", userID)
+		}
+		var hold models.Hold
+		if err := query.First(&hold).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Hold not found"})
+			return
+		}
+		if hold.Status != "Waiting" && hold.Status != "Offered" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Only waiting or offered holds can be cancelled"})
+			return
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			wasOffered := hold.Status == "Offered"
+			hold.Status = "Cancelled"
+			if err := tx.Save(&hold).Error; err != nil {
+				return err
+			}
+			if wasOffered {
+				return services.OfferHolds(tx, hold.ISBN, hold.LibraryID)
+			}
+			return nil
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Hold cancelled", "hold": hold})
+	}
+}
+
+// ExpireHoldOffers expires offers in the admin's library whose pickup deadline has passed.
+func ExpireHoldOffers(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := c.MustGet("user").(jwt.MapClaims)
+		role, _ := claims["role"].(string)
+		if role != "LibraryAdmin" && role != "Owner" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Only admin can expire hold offers"})
+			return
+		}
+		libraryID, err := getUintFromClaim(claims, "library_id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var expired int64
+		err = db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			expired, err = services.ExpireHoldOffers(tx, libraryID, time.Now())
+			return err
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Expired hold offers processed", "expired": expired})
+	}
+}
@@ -0,0 +1,293 @@
+// /backend/src/handlers/import_handler.go
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ImportBatchSize is the number of CSV rows committed together by ImportBooks.
+const ImportBatchSize = 100
+
+// importColumns are the CSV columns ImportBooks understands. isbn and copies are
+// required; barcodes are separated by semicolons.
+var importColumns = map[string]bool{
+	"isbn": true, "title": true, "author": true, "publisher": true, "language": true,
+	"version": true, "copies": true, "increment_only": true, "barcodes": true,
+	"condition": true, "shelf_location": true, "call_number": true, "call_number_scheme": true,
+	"floor": true, "section": true, "shelf": true,
+}
+
+// errDryRun rolls back a batch when nothing may be saved.
+var errDryRun = errors.New("dry run")
+
+// ImportRowResult reports what happened to one row of an import. Row is the line of
+// the CSV file the row starts on; the header is line 1.
+type ImportRowResult struct {
+	Row    int    `json:"row"`
+	ISBN   string `json:"isbn,omitempty"`
+	Status string `json:"status"` // "created", "incremented" or "error"
+	Error  string `json:"error,omitempty"`
+}
+
+// ImportReport is the outcome of an import.
+type ImportReport struct {
+	DryRun      bool              `json:"dry_run"`
+	Created     int               `json:"created"`
+	Incremented int               `json:"incremented"`
+	Failed      int               `json:"failed"`
+	Rows        []ImportRowResult `json:"rows"`
+}
+
+// importRow is a parsed CSV row waiting for its batch.
+type importRow struct {
+	line  int
+	input AddBookInput
+}
+
+// parseImportRow turns a CSV record into an AddBookInput.
+func parseImportRow(header []string, record []string) (AddBookInput, error) {
+	var input AddBookInput
+	for i, column := range header {
+		value := strings.TrimSpace(record[i])
+		switch column {
+		case "isbn":
+			input.ISBN = value
+		case "title":
+			input.Title = value
+		case "author":
+			input.Author = value
+		case "publisher":
+			input.Publisher = value
+		case "language":
+			input.Language = value
+		case "version":
+			input.Version = value
+		case "copies":
+			copies, err := strconv.Atoi(value)
+			if err != nil {
+				return input, errors.New("copies must be a number")
+			}
+			input.Copies = copies
+		case "increment_only":
+			if value != "" {
+				incrementOnly, err := strconv.ParseBool(value)
+				if err != nil {
+					return input, errors.New("increment_only must be true or false")
+				}
+				input.IncrementOnly = incrementOnly
+			}
+		case "barcodes":
+			for _, barcode := range strings.Split(value, ";") {
+				if barcode = strings.TrimSpace(barcode); barcode != "" {
+					input.Barcodes = append(input.Barcodes, barcode)
+				}
+			}
+		case "condition":
+			input.Condition = value
+		case "shelf_location":
+			input.ShelfLocation = value
+		case "call_number":
+			input.CallNumber = value
+		case "call_number_scheme":
+			input.CallNumberScheme = value
+		case "floor":
+			input.Floor = value
+		case "section":
+			input.Section = value
+		case "shelf":
+			input.Shelf = value
+		}
+	}
+	return input, prepareBookInput(&input)
+}
+
+// ImportBooks adds books from an uploaded CSV file (form field "file") with the same
+// rules as AddOrIncrementBook. Rows are committed in batches of ImportBatchSize; a
+// row that fails is reported and skipped without affecting the rest of its batch.
+// With dry_run=true every row is checked but nothing is saved.
+func ImportBooks(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		libraryID, ok := staffLibrary(c, "import books")
+		if !ok {
+			return
+		}
+		dryRun := false
+		if v := c.Query("dry_run"); v != "" {
+			var err error
+			if dryRun, err = strconv.ParseBool(v); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "dry_run must be true or false"})
+				return
+			}
+		}
+
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A CSV file is required in the file field"})
+			return
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		defer file.Close()
+
+		reader := csv.NewReader(file)
+		reader.TrimLeadingSpace = true
+		header, err := reader.Read()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Could not read the CSV header"})
+			return
+		}
+		for i, column := range header {
+			column = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+			if !importColumns[column] {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown column: " + column})
+				return
+			}
+			header[i] = column
+		}
+		for _, required := range []string{"isbn", "copies"} {
+			found := false
+			for _, column := range header {
+				found = found || column == required
+			}
+			if !found {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Missing column: " + required})
+				return
+			}
+		}
+
+		imp := newImporter(db, libraryID, dryRun)
+		for {
+			record, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			line, _ := reader.FieldPos(0)
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				line = parseErr.StartLine
+			} else if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "report": imp.report})
+				return
+			}
+			var input AddBookInput
+			if err == nil {
+				input, err = parseImportRow(header, record)
+			}
+			if err != nil {
+				imp.fail(line, input.ISBN, err)
+				continue
+			}
+			if err := imp.add(line, input); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "report": imp.report})
+				return
+			}
+		}
+		if err := imp.flush(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "report": imp.report})
+			return
+		}
+		c.JSON(http.StatusOK, imp.report)
+	}
+}
+
+// importer adds prepared rows to a library in batches and keeps the report.
+type importer struct {
+	db        *gorm.DB
+	libraryID uint
+	dryRun    bool
+	batch     []importRow
+	report    ImportReport
+}
+
+func newImporter(db *gorm.DB, libraryID uint, dryRun bool) *importer {
+	return &importer{db: db, libraryID: libraryID, dryRun: dryRun, report: ImportReport{DryRun: dryRun, Rows: []ImportRowResult{}}}
+}
+
+// fail reports a row that could not be read.
+func (imp *importer) fail(line int, isbn string, err error) {
+	imp.report.Failed++
+	imp.report.Rows = append(imp.report.Rows, ImportRowResult{Row: line, ISBN: isbn, Status: "error", Error: err.Error()})
+}
+
+// add queues a row, saving the batch once it is full. A dry run checks all rows in
+// one transaction, so that later rows see the books earlier rows would have created.
+func (imp *importer) add(line int, input AddBookInput) error {
+	imp.batch = append(imp.batch, importRow{line: line, input: input})
+	if len(imp.batch) < ImportBatchSize || imp.dryRun {
+		return nil
+	}
+	return imp.flush()
+}
+
+// flush saves the queued rows and reports them.
+func (imp *importer) flush() error {
+	results, err := importBatch(imp.db, imp.libraryID, imp.batch, imp.dryRun)
+	if err != nil {
+		return err
+	}
+	for _, result := range results {
+		switch result.Status {
+		case "created":
+			imp.report.Created++
+		case "incremented":
+			imp.report.Incremented++
+		default:
+			imp.report.Failed++
+		}
+		imp.report.Rows = append(imp.report.Rows, result)
+	}
+	// Rows that failed to parse were reported straight away; keep the file order.
+	sort.SliceStable(imp.report.Rows, func(i, j int) bool { return imp.report.Rows[i].Row < imp.report.Rows[j].Row })
+	imp.batch = imp.batch[:0]
+	return nil
+}
+
+// importBatch adds a batch of rows in one transaction. Each row runs under its own
+// savepoint, so a failing row is rolled back alone. In a dry run the whole batch is
+// rolled back once every row has been tried.
+func importBatch(db *gorm.DB, libraryID uint, batch []importRow, dryRun bool) ([]ImportRowResult, error) {
+	if len(batch) == 0 {
+		return nil, nil
+	}
+	var results []ImportRowResult
+	err := db.Transaction(func(tx *gorm.DB) error {
+		results = make([]ImportRowResult, 0, len(batch))
+		for i, row := range batch {
+			savepoint := "import_row_" + strconv.Itoa(i)
+			if err := tx.SavePoint(savepoint).Error; err != nil {
+				return err
+			}
+			result := ImportRowResult{Row: row.line, ISBN: row.input.ISBN, Status: "incremented"}
+			_, created, err := addOrIncrement(tx, libraryID, row.input)
+			if err != nil {
+				if err := tx.RollbackTo(savepoint).Error; err != nil {
+					return err
+				}
+				result.Status, result.Error = "error", err.Error()
+			} else if created {
+				result.Status = "created"
+			}
+			results = append(results, result)
+		}
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if errors.Is(err, errDryRun) {
+		err = nil
+	}
+	return results, err
+}
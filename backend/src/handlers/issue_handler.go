@@ -2,6 +2,8 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
@@ -9,6 +11,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/swapxs/LibMS/backend/src/models"
+	"github.com/swapxs/LibMS/backend/src/services"
 	"gorm.io/gorm"
 )
 
@@ -28,10 +31,16 @@ type IssueRequestDetail struct {
     ApprovalDate       *time.Time `json:"-"` // same
     ApproverID         *uint      `json:"ApproverID"`
     IssueApproverEmail *string    `json:"IssueApproverEmail"`
-    RequestType        string     `json:"RequestType"`
-    IssueStatus        string     `json:"IssueStatus"`
+    RequestType        string     `json:"RequestType"` // "Issue", "Return" or "Renew"
+    IssueStatus        string     `json:"IssueStatus"` // the request's lifecycle status
     ReturnApproverEmail *string   `json:"ReturnApproverEmail"`
     ReturnStatus       string     `json:"ReturnStatus"`
+    // Where to find the book, and the shelf of the copy set aside once approved.
+    CallNumber         string     `json:"CallNumber"`
+    Floor              string     `json:"Floor"`
+    Section            string     `json:"Section"`
+    Shelf              string     `json:"Shelf"`
+    ShelfLocation      *string    `json:"ShelfLocation"`
 
     // Here are the string versions that we'll populate manually:
     RequestDateStr     string `json:"RequestDate"`
@@ -78,22 +87,25 @@ func GetIssueRequests(db *gorm.DB) gin.HandlerFunc {
 				re.approver_id AS "ApproverID",
 				ia.email AS "IssueApproverEmail",
 				re.request_type AS "RequestType",
-				CASE 
-					WHEN re.request_type = 'Approve' OR ir.issue_status = 'Approved' THEN 'Approved'
-					WHEN re.request_type = 'Issue' THEN 'Pending'
-					WHEN re.request_type = 'Reject' THEN 'Rejected'
-					ELSE 'Pending'
-				END AS "IssueStatus",
+				re.status AS "IssueStatus",
 				ret_ia.email AS "ReturnApproverEmail",
 				CASE 
 					WHEN ir.return_date IS NOT NULL THEN 'Returned'
+					WHEN re.request_type = 'Return' AND re.status = 'Requested' THEN 'Return Requested'
+					WHEN re.request_type = 'Return' AND re.status = 'Rejected' THEN 'Return Rejected'
 					ELSE 'Not Returned'
-				END AS "ReturnStatus"
+				END AS "ReturnStatus",
+				bi.call_number AS "CallNumber",
+				bi.floor AS "Floor",
+				bi.section AS "Section",
+				bi.shelf AS "Shelf",
+				itm.shelf_location AS "ShelfLocation"
 			FROM request_events re
 			JOIN book_inventories bi ON re.book_id = bi.isbn
+			LEFT JOIN book_items itm ON re.item_id = itm.id
 			JOIN users ru ON re.reader_id = ru.id
 			LEFT JOIN users ia ON re.approver_id = ia.id
-			LEFT JOIN issue_registries ir ON re.book_id = ir.isbn AND re.reader_id = ir.reader_id
+			LEFT JOIN issue_registries ir ON ir.id = re.issue_id
 			LEFT JOIN users ret_ia ON ir.return_approver_id = ret_ia.id
 			WHERE bi.library_id = ?
 		`
@@ -108,6 +120,12 @@ func GetIssueRequests(db *gorm.DB) gin.HandlerFunc {
 		}
 		// --- END NEW CODE ---
 
+		// Optionally filter by lifecycle status.
+		if status := c.Query("status"); status != "" {
+			rawQuery += " AND re.status = ?"
+			args = append(args, status)
+		}
+
 		rawQuery += " ORDER BY re.req_id ASC"
 
 		var details []IssueRequestDetail
@@ -142,17 +160,12 @@ func UpdateIssueRequestStatus(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		// Retrieve the issue request from the database.
-		var reqEvent models.RequestEvent
-		if err := db.First(&reqEvent, reqID).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Request not found"})
-			return
-		}
-
 		// Bind the input JSON payload.
 		var input struct {
 			RequestType        string     `json:"request_type" binding:"required"`
 			ExpectedReturnDate *time.Time `json:"expected_return_date"`
+			Barcode            string     `json:"barcode"` // copy to issue; any available copy when empty
+			Reason             string     `json:"reason"`
 		}
 		if err := c.ShouldBindJSON(&input); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -167,6 +180,11 @@ func UpdateIssueRequestStatus(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 		approverID := uint(claims["id"].(float64))
+		libraryID, err := getUintFromClaim(claims, "library_id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
 
 		// Reject unknown request types.
 		if input.RequestType != "Approve" && input.RequestType != "Reject" {
@@ -174,53 +192,77 @@ func UpdateIssueRequestStatus(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		// For Approve, check if the book is available.
-		if input.RequestType == "Approve" {
-			var book models.BookInventory
-			if err := db.Where("isbn = ?", reqEvent.BookID).First(&book).Error; err != nil {
-				c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
-				return
-			}
-
-			if book.AvailableCopies < 1 {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "No available copies left to issue"})
-				return
-			}
-
-			book.AvailableCopies -= 1
-			if err := db.Save(&book).Error; err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update book availability"})
-				return
-			}
-		}
-
-		// Update the request event with the new status and approver information.
-		now := time.Now()
-		reqEvent.ApprovalDate = &now
-		reqEvent.RequestType = input.RequestType
-		reqEvent.ApproverID = &approverID
-
-		if input.ExpectedReturnDate != nil {
-			reqEvent.ApprovalDate = input.ExpectedReturnDate
+		// Decide the request; approval sets a copy aside in the same transaction.
+		reqEvent, err := services.DecideIssueRequest(db, services.IssueDecision{
+			RequestID:          uint(reqID),
+			ApproverID:         approverID,
+			LibraryID:          libraryID,
+			Approve:            input.RequestType == "Approve",
+			Barcode:            input.Barcode,
+			ExpectedReturnDate: input.ExpectedReturnDate,
+			Reason:             input.Reason,
+		})
+		switch {
+		case errors.Is(err, services.ErrRequestNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Request not found"})
+			return
+		case errors.Is(err, services.ErrBookNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
+			return
+		case errors.Is(err, services.ErrRequestNotPending):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, services.ErrNoAvailableItem), errors.Is(err, services.ErrItemNotAvailable),
+			errors.Is(err, services.ErrInvalidDueDate):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
 		}
 
-		if err := db.Save(&reqEvent).Error; err != nil {
+		// An approved request has been issued; return the new issue with its due date.
+		location, err := services.RequestLocation(db, reqEvent, libraryID)
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-
-		c.JSON(http.StatusOK, gin.H{"message": "Issue request status updated and available copies adjusted", "request": reqEvent})
+		response := gin.H{"message": "Issue request status updated and available copies adjusted", "request": reqEvent, "location": location}
+		if reqEvent.IssueID != nil {
+			var issue models.IssueRegistry
+			if err := db.First(&issue, *reqEvent.IssueID).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			response["issue"] = issue
+		}
+		c.JSON(http.StatusOK, response)
 	}
 }
 
-// IssueBook creates an issuance record in the issue_registries table.
+// IssueBook creates an issuance record in the issue_registries table. Only admins
+// issue books, in their own library and as the approver of the issue.
 func IssueBook(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		libraryID, ok := staffLibrary(c, "issue books")
+		if !ok {
+			return
+		}
+		approverID, err := getUintFromClaim(c.MustGet("user").(jwt.MapClaims), "id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
 		var payload models.IssueRegistry
 		if err := c.ShouldBindJSON(&payload); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		payload.LibraryID = libraryID
+		payload.IssueApproverID = approverID
+		if payload.ISBN, ok = normalizeISBN(c, payload.ISBN); !ok {
+			return
+		}
 		// Set issue date to now.
 		payload.IssueDate = time.Now()
 		// Check that expected_return_date is not zero.
@@ -228,7 +270,44 @@ func IssueBook(db *gorm.DB) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Expected return date is required"})
 			return
 		}
-		if err := db.Create(&payload).Error; err != nil {
+		// Only readers of the library can be issued its books.
+		var readers int64
+		if err := db.Model(&models.User{}).Where("id = ? AND library_id = ?", payload.ReaderID, libraryID).
+			Count(&readers).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if readers == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found in your library"})
+			return
+		}
+		// The due date may not go past the reader's loan period.
+		policy, err := services.ResolveLoanPolicy(db, payload.LibraryID, payload.ReaderID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if payload.ExpectedReturnDate.After(payload.IssueDate.Add(policy.LoanPeriod())) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Expected return date exceeds the loan period of %d days", policy.LoanPeriodDays)})
+			return
+		}
+		// Books are never due on a day the library is closed.
+		payload.ExpectedReturnDate, err = services.NextOpenDay(db, payload.LibraryID, payload.ExpectedReturnDate)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		// Link the issued copy: an explicit barcode, or the copy set aside on approval.
+		err = services.IssueCopy(db, &payload)
+		if errors.Is(err, services.ErrItemNotAvailable) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrItemOnLoan) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
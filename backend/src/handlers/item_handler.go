@@ -0,0 +1,141 @@
+// /backend/src/handlers/item_handler.go
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"github.com/swapxs/LibMS/backend/src/services"
+	"gorm.io/gorm"
+)
+
+// UpdateItemInput represents the editable details of a single copy.
+type UpdateItemInput struct {
+	Condition     *string `json:"condition" binding:"omitempty,oneof=New Good Fair Poor Damaged"`
+	ShelfLocation *string `json:"shelf_location"`
+	// Status may only move a copy between the shelf and the damaged/lost states;
+	// issuing, returning and withdrawing go through their own endpoints.
+	Status *string `json:"status" binding:"omitempty,oneof=Available Damaged Lost"`
+}
+
+// GetBookItems lists the physical copies of a title in the caller's library.
+func GetBookItems(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := c.MustGet("user").(jwt.MapClaims)
+		libraryID, err := getUintFromClaim(claims, "library_id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		bookISBN, ok := normalizeISBN(c, c.Param("isbn"))
+		if !ok {
+			return
+		}
+		var book models.BookInventory
+		if err := db.Where("isbn = ? AND library_id = ?", bookISBN, libraryID).First(&book).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
+			return
+		}
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			return services.EnsureBookItems(tx, &book)
+		}); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var items []models.BookItem
+		if err := db.Where("book_inventory_id = ?", book.ID).Order("id ASC").Find(&items).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"book": book, "items": items})
+	}
+}
+
+// UpdateItem changes the condition, shelf location or status of a copy identified by barcode.
+func UpdateItem(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := c.MustGet("user").(jwt.MapClaims)
+		if !isLibraryStaff(claims) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Only admin can update copies"})
+			return
+		}
+		libraryID, err := getUintFromClaim(claims, "library_id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var input UpdateItemInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var item models.BookItem
+		if err := db.Where("barcode = ? AND library_id = ?", c.Param("barcode"), libraryID).First(&item).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Copy not found"})
+			return
+		}
+		if item.Status == "Withdrawn" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Copy has been withdrawn"})
+			return
+		}
+		// A copy issued or on loan can only be reported lost; it comes back through a
+		// return, also once it is lost.
+		if input.Status != nil && *input.Status != "Lost" {
+			var onLoan int64
+			if err := db.Model(&models.IssueRegistry{}).
+				Where("item_id = ? AND return_date IS NULL", item.ID).Count(&onLoan).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if item.Status == "Issued" || onLoan > 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Issued copies can only be marked as Lost"})
+				return
+			}
+		}
+
+		updates := map[string]interface{}{}
+		if input.Condition != nil {
+			updates["condition"] = *input.Condition
+		}
+		if input.ShelfLocation != nil {
+			updates["shelf_location"] = *input.ShelfLocation
+		}
+		if input.Status != nil {
+			updates["status"] = *input.Status
+		}
+		if len(updates) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to update"})
+			return
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&item).UpdateColumns(updates).Error; err != nil {
+				return err
+			}
+			var book models.BookInventory
+			if err := tx.First(&book, item.BookInventoryID).Error; err != nil {
+				return err
+			}
+			if err := services.SyncBookCounters(tx, &book); err != nil {
+				return err
+			}
+			// A copy back on the shelf goes to the hold queue first.
+			return services.OfferHolds(tx, book.ISBN, book.LibraryID)
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if err := db.First(&item, item.ID).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Copy updated", "item": item})
+	}
+}
@@ -66,3 +66,42 @@ func GetLibraries(db *gorm.DB) gin.HandlerFunc {
 		c.JSON(http.StatusOK, gin.H{"libraries": libraries})
 	}
 }
+
+// RenewalLimitInput is the payload for configuring a library's renewal limit.
+type RenewalLimitInput struct {
+	MaxRenewals *int `json:"max_renewals" binding:"required,min=0"`
+}
+
+// UpdateRenewalLimit sets how many times an issue may be renewed in the owner's library.
+func UpdateRenewalLimit(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := c.MustGet("user").(jwt.MapClaims)
+		if claims["role"] != "Owner" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Only owner can change the renewal limit"})
+			return
+		}
+		libraryID, err := getUintFromClaim(claims, "library_id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var input RenewalLimitInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var lib models.Library
+		if err := db.First(&lib, libraryID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Library not found"})
+			return
+		}
+		// UpdateColumn so that a limit of zero is written rather than skipped.
+		if err := db.Model(&lib).UpdateColumn("max_renewals", *input.MaxRenewals).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Renewal limit updated", "library": lib})
+	}
+}
@@ -0,0 +1,223 @@
+// /backend/src/handlers/loan_policy_handler.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"github.com/swapxs/LibMS/backend/src/services"
+	"gorm.io/gorm"
+)
+
+// LoanPolicyInput is the payload for creating or replacing a loan policy rule. Limits
+// left out are not overridden by the rule.
+type LoanPolicyInput struct {
+	Role                string `json:"role" binding:"omitempty,oneof=Reader LibraryAdmin Owner"`
+	PatronCategory      string `json:"patron_category"`
+	MaxActiveRequests   *int   `json:"max_active_requests" binding:"omitempty,min=0"`
+	LoanPeriodDays      *int   `json:"loan_period_days" binding:"omitempty,min=1"`
+	MaxRenewals         *int   `json:"max_renewals" binding:"omitempty,min=0"`
+	DailyRateCents      *int64 `json:"daily_rate_cents" binding:"omitempty,min=0"`
+	GracePeriodDays     *int   `json:"grace_period_days" binding:"omitempty,min=0"`
+	MaxFineCents        *int64 `json:"max_fine_cents" binding:"omitempty,min=0"`
+	BlockThresholdCents *int64 `json:"block_threshold_cents" binding:"omitempty,min=0"`
+}
+
+// apply copies the input onto a rule.
+func (in LoanPolicyInput) apply(rule *models.LoanPolicy) {
+	rule.Role = in.Role
+	rule.PatronCategory = in.PatronCategory
+	rule.MaxActiveRequests = in.MaxActiveRequests
+	rule.LoanPeriodDays = in.LoanPeriodDays
+	rule.MaxRenewals = in.MaxRenewals
+	rule.DailyRateCents = in.DailyRateCents
+	rule.GracePeriodDays = in.GracePeriodDays
+	rule.MaxFineCents = in.MaxFineCents
+	rule.BlockThresholdCents = in.BlockThresholdCents
+}
+
+// loanPolicyScopeTaken reports whether another rule of the library already covers the
+// same role and patron category.
+func loanPolicyScopeTaken(db *gorm.DB, rule models.LoanPolicy) (bool, error) {
+	var count int64
+	err := db.Unscoped().Model(&models.LoanPolicy{}).
+		Where("library_id = ? AND role = ? AND patron_category = ? AND id <> ?",
+			rule.LibraryID, rule.Role, rule.PatronCategory, rule.ID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// staffLibrary returns the caller's library if they are library staff.
+func staffLibrary(c *gin.Context, action string) (uint, bool) {
+	claims := c.MustGet("user").(jwt.MapClaims)
+	if !isLibraryStaff(claims) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Only admin can " + action})
+		return 0, false
+	}
+	libraryID, err := getUintFromClaim(claims, "library_id")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return 0, false
+	}
+	return libraryID, true
+}
+
+// GetLoanPolicies lists the loan policy rules of the caller's library.
+func GetLoanPolicies(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		libraryID, ok := staffLibrary(c, "view loan policies")
+		if !ok {
+			return
+		}
+		var rules []models.LoanPolicy
+		if err := db.Where("library_id = ?", libraryID).
+			Order("role ASC, patron_category ASC").Find(&rules).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "policies": rules})
+	}
+}
+
+// CreateLoanPolicy adds a rule for a role and/or patron category of the library.
+func CreateLoanPolicy(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		libraryID, ok := staffLibrary(c, "change loan policies")
+		if !ok {
+			return
+		}
+		var input LoanPolicyInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		rule := models.LoanPolicy{LibraryID: libraryID}
+		input.apply(&rule)
+		taken, err := loanPolicyScopeTaken(db, rule)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if taken {
+			c.JSON(http.StatusConflict, gin.H{"error": "A rule for this role and patron category already exists"})
+			return
+		}
+		if err := db.Create(&rule).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"message": "Loan policy created", "policy": rule})
+	}
+}
+
+// findLoanPolicy loads a rule of the library from the :id parameter.
+func findLoanPolicy(c *gin.Context, db *gorm.DB, libraryID uint) (models.LoanPolicy, bool) {
+	var rule models.LoanPolicy
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid loan policy ID"})
+		return rule, false
+	}
+	if err := db.Where("id = ? AND library_id = ?", id, libraryID).First(&rule).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Loan policy not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return rule, false
+	}
+	return rule, true
+}
+
+// UpdateLoanPolicy replaces a rule of the caller's library.
+func UpdateLoanPolicy(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		libraryID, ok := staffLibrary(c, "change loan policies")
+		if !ok {
+			return
+		}
+		rule, ok := findLoanPolicy(c, db, libraryID)
+		if !ok {
+			return
+		}
+		var input LoanPolicyInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		input.apply(&rule)
+		taken, err := loanPolicyScopeTaken(db, rule)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if taken {
+			c.JSON(http.StatusConflict, gin.H{"error": "A rule for this role and patron category already exists"})
+			return
+		}
+		if err := db.Save(&rule).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Loan policy updated", "policy": rule})
+	}
+}
+
+// DeleteLoanPolicy removes a rule; its scope falls back to less specific rules.
+func DeleteLoanPolicy(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		libraryID, ok := staffLibrary(c, "change loan policies")
+		if !ok {
+			return
+		}
+		rule, ok := findLoanPolicy(c, db, libraryID)
+		if !ok {
+			return
+		}
+		// Deleted rules are removed for good so their scope can be configured again.
+		if err := db.Unscoped().Delete(&rule).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Loan policy deleted"})
+	}
+}
+
+// GetEffectiveLoanPolicy returns the limits that apply to the caller. Admins may pass
+// user_id to see the limits of another user of the library.
+func GetEffectiveLoanPolicy(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := c.MustGet("user").(jwt.MapClaims)
+		userID, err := getUintFromClaim(claims, "id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		libraryID, err := getUintFromClaim(claims, "library_id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if param := c.Query("user_id"); param != "" && isLibraryStaff(claims) {
+			id, err := strconv.Atoi(param)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+				return
+			}
+			userID = uint(id)
+		}
+
+		policy, err := services.ResolveLoanPolicy(db, libraryID, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"policy": policy})
+	}
+}
@@ -0,0 +1,114 @@
+// /backend/src/handlers/marc_handler.go
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/swapxs/LibMS/backend/src/marc"
+	"gorm.io/gorm"
+)
+
+// MARCImportReport is the outcome of a MARC import. Rows are numbered by the
+// position of the record in the file; Unmapped counts, by tag, the records carrying
+// fields the catalog has no place for.
+type MARCImportReport struct {
+	ImportReport
+	Unmapped map[string]int `json:"unmapped"`
+}
+
+// ImportMARC adds books from an uploaded MARC21 or MARCXML file (form field "file"),
+// with the same rules as AddOrIncrementBook. Each record adds the number of copies
+// given by ?copies= (default 1). With dry_run=true nothing is saved.
+func ImportMARC(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		libraryID, ok := staffLibrary(c, "import books")
+		if !ok {
+			return
+		}
+		dryRun := false
+		if v := c.Query("dry_run"); v != "" {
+			var err error
+			if dryRun, err = strconv.ParseBool(v); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "dry_run must be true or false"})
+				return
+			}
+		}
+		copies := 1
+		if v := c.Query("copies"); v != "" {
+			var err error
+			if copies, err = strconv.Atoi(v); err != nil || copies <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "copies must be a positive number"})
+				return
+			}
+		}
+
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A MARC file is required in the file field"})
+			return
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		defer file.Close()
+		data, err := io.ReadAll(file)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		records, err := marc.Parse(data)
+		if errors.Is(err, marc.ErrMalformed) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		imp := newImporter(db, libraryID, dryRun)
+		unmapped := map[string]int{}
+		for i, record := range records {
+			book, tags := marc.MapRecord(record)
+			for _, tag := range tags {
+				unmapped[tag]++
+			}
+			input := AddBookInput{
+				ISBN:      book.ISBN,
+				Title:     book.Title,
+				Author:    book.Author,
+				Publisher: book.Publisher,
+				Language:  book.Language,
+				Version:   book.Edition,
+				Copies:    copies,
+			}
+			if err := prepareBookInput(&input); err != nil {
+				imp.fail(i+1, book.ISBN, err)
+				continue
+			}
+			if err := imp.add(i+1, input); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "report": imp.report})
+				return
+			}
+		}
+		if err := imp.flush(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "report": imp.report})
+			return
+		}
+		c.JSON(http.StatusOK, MARCImportReport{ImportReport: imp.report, Unmapped: unmapped})
+	}
+}
+
+// ExportMARC streams the catalog of the caller's library as a MARCXML collection,
+// one record per book. It is ExportBooks with format=marcxml.
+func ExportMARC(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		exportCatalog(c, db, "marcxml")
+	}
+}
@@ -0,0 +1,77 @@
+// /backend/src/handlers/metadata_handler.go
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/swapxs/LibMS/backend/src/metadata"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"gorm.io/gorm"
+)
+
+// fillFromMetadata completes the details missing from a new book with what the
+// provider knows about its ISBN. Details given by the caller are kept. Nothing is
+// looked up for increments, books the library already has, or without a provider;
+// a failed lookup leaves the input as it was.
+func fillFromMetadata(ctx context.Context, db *gorm.DB, provider metadata.Provider, libraryID uint, input *AddBookInput) {
+	if provider == nil || input.IncrementOnly {
+		return
+	}
+	if input.Title != "" && input.Author != "" && input.Publisher != "" && input.Language != "" && input.Version != "" {
+		return
+	}
+	var count int64
+	if err := db.Model(&models.BookInventory{}).Where("isbn = ? AND library_id = ?", input.ISBN, libraryID).Count(&count).Error; err != nil || count > 0 {
+		return
+	}
+	md, err := provider.Lookup(ctx, input.ISBN)
+	if err != nil {
+		return
+	}
+	for _, field := range []struct {
+		dst *string
+		src string
+	}{
+		{&input.Title, md.Title},
+		{&input.Author, md.Author},
+		{&input.Publisher, md.Publisher},
+		{&input.Language, md.Language},
+		{&input.Version, md.Version},
+	} {
+		if *field.dst == "" {
+			*field.dst = field.src
+		}
+	}
+}
+
+// LookupBookMetadata returns what the metadata provider knows about an ISBN, so
+// that it can be checked before the book is added.
+func LookupBookMetadata(provider metadata.Provider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := staffLibrary(c, "look up books"); !ok {
+			return
+		}
+		isbn, ok := normalizeISBN(c, c.Param("isbn"))
+		if !ok {
+			return
+		}
+		if provider == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Metadata lookup is not configured"})
+			return
+		}
+
+		md, err := provider.Lookup(c.Request.Context(), isbn)
+		if errors.Is(err, metadata.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Metadata lookup failed: " + err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"metadata": md})
+	}
+}
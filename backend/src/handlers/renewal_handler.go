@@ -0,0 +1,162 @@
+// /backend/src/handlers/renewal_handler.go
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"github.com/swapxs/LibMS/backend/src/services"
+	"gorm.io/gorm"
+)
+
+// RenewInput represents the optional payload for renewing an issue.
+type RenewInput struct {
+	// Days extends the due date by the given number of days, at most the reader's
+	// loan period. When omitted the loan period is used.
+	Days int `json:"days" binding:"omitempty,gt=0"`
+}
+
+// errRenewalConflict signals that the issue changed while the renewal was being applied.
+var errRenewalConflict = errors.New("issue was modified concurrently, please retry")
+
+// RenewIssue extends the expected return date of an active issue. Readers may renew
+// their own issues; a LibraryAdmin may renew on a reader's behalf. Each renewal is
+// recorded as a "Renew" request event.
+func RenewIssue(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		issueID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid issue ID"})
+			return
+		}
+
+		var input RenewInput
+		if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		claims := c.MustGet("user").(jwt.MapClaims)
+		callerID, err := getUintFromClaim(claims, "id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		libraryID, err := getUintFromClaim(claims, "library_id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		role, _ := claims["role"].(string)
+		if role != "Reader" && role != "LibraryAdmin" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Only readers or admins can renew issues"})
+			return
+		}
+
+		query := db.Where("id = ? AND library_id = ?", issueID, libraryID)
+		if role == "Reader" {
+			query = query.Where("reader_id = ?", callerID)
+		}
+		var issue models.IssueRegistry
+		if err := query.First(&issue).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Issue record not found"})
+			return
+		}
+		if issue.ReturnDate != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot renew a returned book"})
+			return
+		}
+
+		policy, err := services.ResolveLoanPolicy(db, libraryID, issue.ReaderID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if issue.RenewalCount >= policy.MaxRenewals {
+			c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Maximum of %d renewals reached", policy.MaxRenewals)})
+			return
+		}
+
+		// Refuse the renewal while another reader of this library waits for the book.
+		var waiting int64
+		if err := db.Model(&models.RequestEvent{}).
+			Where("book_id = ? AND request_type = ? AND status = ? AND reader_id <> ?",
+				issue.ISBN, services.KindIssue, services.StatusRequested, issue.ReaderID).
+			Where("reader_id IN (?)", db.Model(&models.User{}).Select("id").Where("library_id = ?", libraryID)).
+			Count(&waiting).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check pending requests"})
+			return
+		}
+		if waiting > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Another reader has a pending request for this book"})
+			return
+		}
+
+		if input.Days > policy.LoanPeriodDays {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Renewal may not exceed the loan period of %d days", policy.LoanPeriodDays)})
+			return
+		}
+		extension := policy.LoanPeriod()
+		if input.Days > 0 {
+			extension = time.Duration(input.Days) * 24 * time.Hour
+		}
+		newDueDate, err := services.NextOpenDay(db, libraryID, issue.ExpectedReturnDate.Add(extension))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		now := time.Now()
+
+		renewal := models.RequestEvent{
+			BookID:       issue.ISBN,
+			ReaderID:     issue.ReaderID,
+			RequestDate:  now,
+			ApprovalDate: &now,
+			ApproverID:   &callerID,
+			RequestType:  services.KindRenew,
+			IssueID:      &issue.ID,
+		}
+		updates := map[string]interface{}{
+			"expected_return_date": newDueDate,
+			"renewal_count":        issue.RenewalCount + 1,
+		}
+		// A renewal that moves the due date into the future clears the overdue flag.
+		if issue.IssueStatus == "Overdue" && newDueDate.After(now) {
+			updates["issue_status"] = "Issued"
+		}
+		err = db.Transaction(func(tx *gorm.DB) error {
+			res := tx.Model(&models.IssueRegistry{}).
+				Where("id = ? AND renewal_count = ? AND return_date IS NULL", issue.ID, issue.RenewalCount).
+				Updates(updates)
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				return errRenewalConflict
+			}
+			return services.CreateRequest(tx, &renewal, &callerID, "")
+		})
+		if errors.Is(err, errRenewalConflict) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		issue.ExpectedReturnDate = newDueDate
+		issue.RenewalCount++
+		if status, ok := updates["issue_status"].(string); ok {
+			issue.IssueStatus = status
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Issue renewed", "issue": issue, "request": renewal})
+	}
+}
@@ -2,20 +2,31 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/swapxs/LibMS/backend/src/models"
+	"github.com/swapxs/LibMS/backend/src/services"
 	"gorm.io/gorm"
 )
 
+// RaiseRequestInput names the book requested, either by ISBN (BookID) or as any
+// edition of a work (WorkID), optionally in a given Language.
 type RaiseRequestInput struct {
-	BookID string `json:"bookID" binding:"required"`
+	BookID   string `json:"bookID"`
+	WorkID   uint   `json:"workID"`
+	Language string `json:"language"`
 }
 
-// RaiseRequest allows a reader to raise an issue request with a limit of 4 active requests.
+// RaiseRequest allows a reader to raise an issue request, up to the number of active
+// requests their loan policy allows. A request for a work is for whichever of its
+// editions has a copy free.
 func RaiseRequest(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var input RaiseRequestInput
@@ -23,6 +34,16 @@ func RaiseRequest(db *gorm.DB) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		if (input.BookID == "") == (input.WorkID == 0) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Either bookID or workID is required"})
+			return
+		}
+		if input.BookID != "" {
+			var ok bool
+			if input.BookID, ok = normalizeISBN(c, input.BookID); !ok {
+				return
+			}
+		}
 
 		claims, exists := c.Get("user")
 		if !exists {
@@ -41,43 +62,195 @@ func RaiseRequest(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		// Count active requests (Pending or Approved) for the user.
+		policy, err := services.ResolveLoanPolicy(db, libraryID, readerID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Count active issue requests (requested, approved or issued) for the user.
 		var activeRequests int64
 		if err := db.Model(&models.RequestEvent{}).
-			Where("reader_id = ? AND request_type IN (?)", readerID, []string{"Issue", "Approve"}).
+			Where("reader_id = ? AND request_type = ? AND status IN (?)", readerID, services.KindIssue, services.ActiveIssueStatuses).
 			Count(&activeRequests).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count active requests"})
 			return
 		}
 
-		if activeRequests >= 4 {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Maximum of 4 active requests reached"})
+		if activeRequests >= int64(policy.MaxActiveRequests) {
+			c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Maximum of %d active requests reached", policy.MaxActiveRequests)})
+			return
+		}
+
+		// Readers owing more than the library allows cannot borrow.
+		balance, err := services.OutstandingBalance(db, readerID, libraryID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check outstanding fines"})
+			return
+		}
+		if balance > policy.Fine.BlockThresholdCents {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Outstanding fines must be paid before raising new requests", "balance_cents": balance})
 			return
 		}
 
 		// Check if the book is available.
 		var book models.BookInventory
-		if err := db.Where("isbn = ? AND library_id = ?", input.BookID, libraryID).First(&book).Error; err != nil {
+		var workID *uint
+		if input.WorkID != 0 {
+			work, err := services.FindWork(db, libraryID, input.WorkID)
+			if errors.Is(err, services.ErrWorkNotFound) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Work not found in your library"})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			book, err = services.PickEdition(db, work, readerID, input.Language)
+			if errors.Is(err, services.ErrNoEditionAvailable) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "No edition of the work is available for issue"})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			input.BookID, workID = book.ISBN, &work.ID
+		} else if err := db.Where("isbn = ? AND library_id = ?", input.BookID, libraryID).First(&book).Error; err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Book not found in your library"})
 			return
 		}
-		if book.AvailableCopies < 1 {
+
+		// Copies offered to other readers from the hold queue are set aside for them.
+		offeredToOthers, err := services.CountOfferedHolds(db, book.ISBN, libraryID, readerID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if int64(book.AvailableCopies)-offeredToOthers < 1 {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Book not available for issue"})
 			return
 		}
 
-		// Create the request event.
+		// Create the request event, claiming the reader's offered hold if there is one.
 		reqEvent := models.RequestEvent{
 			BookID:      input.BookID,
 			ReaderID:    readerID,
 			RequestDate: time.Now(),
-			RequestType: "Issue",
+			RequestType: services.KindIssue,
+			WorkID:      workID,
 		}
-		if err := db.Create(&reqEvent).Error; err != nil {
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := services.CreateRequest(tx, &reqEvent, &readerID, ""); err != nil {
+				return err
+			}
+			return tx.Model(&models.Hold{}).
+				Where("isbn = ? AND library_id = ? AND reader_id = ? AND status = ?", book.ISBN, libraryID, readerID, "Offered").
+				Update("status", "Fulfilled").Error
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"message": "Issue request raised", "request": reqEvent, "location": services.BookLocation(book)})
+	}
+}
+
+// GetRequestHistory returns the status changes of a request, oldest first. Readers
+// may only see the history of their own requests.
+func GetRequestHistory(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reqID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request ID"})
+			return
+		}
+
+		claims := c.MustGet("user").(jwt.MapClaims)
+		userID, err := getUintFromClaim(claims, "id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		libraryID, err := getUintFromClaim(claims, "library_id")
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 
-		c.JSON(http.StatusCreated, gin.H{"message": "Issue request raised", "request": reqEvent})
+		query := db.Where("req_id = ?", reqID).
+			Where("reader_id IN (?)", db.Model(&models.User{}).Select("id").Where("library_id = ?", libraryID))
+		if !isLibraryStaff(claims) {
+			query = query.Where("reader_id = ?", userID)
+		}
+		var reqEvent models.RequestEvent
+		if err := query.First(&reqEvent).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Request not found"})
+			return
+		}
+
+		var history []models.RequestTransition
+		if err := db.Where("request_id = ?", reqEvent.ReqID).Order("id ASC").Find(&history).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"request": reqEvent, "history": history})
+	}
+}
+
+// CancelRequestInput is the optional body of a cancellation.
+type CancelRequestInput struct {
+	Reason string `json:"reason"`
+}
+
+// CancelRequest withdraws a pending issue or return request. Readers may cancel their
+// own requests; library staff may cancel any request in their library. Cancelling an
+// approved issue request puts the copy set aside for it back on the shelf.
+func CancelRequest(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reqID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request ID"})
+			return
+		}
+		var input CancelRequestInput
+		if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		claims := c.MustGet("user").(jwt.MapClaims)
+		userID, err := getUintFromClaim(claims, "id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		libraryID, err := getUintFromClaim(claims, "library_id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		readerID := userID
+		if isLibraryStaff(claims) {
+			readerID = 0
+		}
+
+		reqEvent, err := services.CancelRequest(db, uint(reqID), userID, libraryID, readerID, input.Reason)
+		switch {
+		case errors.Is(err, services.ErrRequestNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Request not found"})
+			return
+		case errors.Is(err, services.ErrRequestNotPending):
+			c.JSON(http.StatusConflict, gin.H{"error": "Request can no longer be cancelled"})
+			return
+		case errors.Is(err, services.ErrItemOnLoan):
+			c.JSON(http.StatusConflict, gin.H{"error": "Book has already been issued; raise a return request instead"})
+			return
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Request cancelled", "request": reqEvent})
 	}
 }
@@ -0,0 +1,160 @@
+// /backend/src/handlers/return_handler.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"github.com/swapxs/LibMS/backend/src/services"
+	"gorm.io/gorm"
+)
+
+// RaiseReturnInput represents the payload for requesting a book return.
+type RaiseReturnInput struct {
+	IssueID uint `json:"issue_id" binding:"required"`
+}
+
+// RaiseReturnRequest lets a reader ask to hand back a book they currently hold.
+// The request stays pending until a LibraryAdmin approves it.
+func RaiseReturnRequest(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input RaiseReturnInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		claims := c.MustGet("user").(jwt.MapClaims)
+		readerID, err := getUintFromClaim(claims, "id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		libraryID, err := getUintFromClaim(claims, "library_id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Readers may only return books issued to themselves.
+		var issue models.IssueRegistry
+		if err := db.Where("id = ? AND reader_id = ? AND library_id = ?", input.IssueID, readerID, libraryID).
+			First(&issue).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Issue record not found"})
+			return
+		}
+		if issue.ReturnDate != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Book already returned"})
+			return
+		}
+
+		// Only one pending return request per issue.
+		var pending int64
+		if err := db.Model(&models.RequestEvent{}).
+			Where("issue_id = ? AND request_type = ? AND status = ?", issue.ID, services.KindReturn, services.StatusRequested).
+			Count(&pending).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing return requests"})
+			return
+		}
+		if pending > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Return already requested for this issue"})
+			return
+		}
+
+		reqEvent := models.RequestEvent{
+			BookID:      issue.ISBN,
+			ReaderID:    readerID,
+			RequestDate: time.Now(),
+			RequestType: services.KindReturn,
+			IssueID:     &issue.ID,
+		}
+		if err := services.CreateRequest(db, &reqEvent, &readerID, ""); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"message": "Return request raised", "request": reqEvent})
+	}
+}
+
+// UpdateReturnRequestStatus approves or rejects a pending return request.
+// Approval closes the issue registry row, restores the book's available copies
+// and frees the reader's slot in the active request limit.
+func UpdateReturnRequestStatus(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reqID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request ID"})
+			return
+		}
+
+		var input struct {
+			RequestType string `json:"request_type" binding:"required"`
+			Condition   string `json:"condition" binding:"omitempty,oneof=New Good Fair Poor Damaged"` // state the copy came back in
+			Reason      string `json:"reason"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		claims := c.MustGet("user").(jwt.MapClaims)
+		role, ok := claims["role"].(string)
+		if !ok || role != "LibraryAdmin" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Only admin can update return request status"})
+			return
+		}
+		approverID, err := getUintFromClaim(claims, "id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		libraryID, err := getUintFromClaim(claims, "library_id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if input.RequestType != "Approve" && input.RequestType != "Reject" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request type. Must be 'Approve' or 'Reject'."})
+			return
+		}
+
+		reqEvent, issue, err := services.DecideReturnRequest(db, services.ReturnDecision{
+			RequestID:  uint(reqID),
+			ApproverID: approverID,
+			LibraryID:  libraryID,
+			Approve:    input.RequestType == "Approve",
+			Condition:  input.Condition,
+			Reason:     input.Reason,
+		})
+		switch {
+		case errors.Is(err, services.ErrRequestNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Return request not found"})
+			return
+		case errors.Is(err, services.ErrIssueNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Issue record not found"})
+			return
+		case errors.Is(err, services.ErrRequestNotPending):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, services.ErrAlreadyReturned):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Book already returned"})
+			return
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if input.RequestType == "Reject" {
+			c.JSON(http.StatusOK, gin.H{"message": "Return request rejected", "request": reqEvent})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Return approved and available copies restored", "request": reqEvent, "issue": issue})
+	}
+}
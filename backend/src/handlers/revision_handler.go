@@ -0,0 +1,77 @@
+// /backend/src/handlers/revision_handler.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"github.com/swapxs/LibMS/backend/src/services"
+	"gorm.io/gorm"
+)
+
+// GetBookRevisions lists the edits made to a book, latest first: who made each one,
+// when, and the old and new value of every field it changed.
+func GetBookRevisions(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		libraryID, ok := staffLibrary(c, "view book history")
+		if !ok {
+			return
+		}
+		book, ok := findLibraryBook(c, db, libraryID)
+		if !ok {
+			return
+		}
+		revisions, err := services.BookRevisions(db, book.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"isbn": book.ISBN, "revisions": revisions})
+	}
+}
+
+// RevertBookRevision undoes an edit to a book, as a new revision. An edit whose
+// fields have changed again since is refused; revert the later edits first.
+func RevertBookRevision(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		libraryID, ok := staffLibrary(c, "revert book edits")
+		if !ok {
+			return
+		}
+		editorID, err := getUintFromClaim(c.MustGet("user").(jwt.MapClaims), "id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		revisionID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid revision ID"})
+			return
+		}
+		book, ok := findLibraryBook(c, db, libraryID)
+		if !ok {
+			return
+		}
+
+		var revision *models.BookRevision
+		err = db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			revision, err = services.RevertRevision(tx, &book, uint(revisionID), editorID)
+			return err
+		})
+		switch {
+		case errors.Is(err, services.ErrRevisionNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrRevisionConflict), errors.Is(err, services.ErrISBNInUse):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusOK, gin.H{"message": "Revision reverted", "book": book, "revision": revision})
+		}
+	}
+}
@@ -0,0 +1,45 @@
+// /backend/src/handlers/shelf_handler.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/swapxs/LibMS/backend/src/callnumber"
+	"github.com/swapxs/LibMS/backend/src/services"
+	"gorm.io/gorm"
+)
+
+// GetShelfList lists the books of the caller's library with a call number in shelf
+// order, for shelf reading and inventory. Query parameters: floor, section, scheme
+// (DDC or LCC) and the call number range from and to.
+func GetShelfList(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := c.MustGet("user").(jwt.MapClaims)
+		libraryID, err := getUintFromClaim(claims, "library_id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		books, err := services.ShelfList(db, services.ShelfListQuery{
+			LibraryID: libraryID,
+			Floor:     c.Query("floor"),
+			Section:   c.Query("section"),
+			Scheme:    c.Query("scheme"),
+			From:      c.Query("from"),
+			To:        c.Query("to"),
+		})
+		if errors.Is(err, callnumber.ErrInvalid) || errors.Is(err, callnumber.ErrUnknownScheme) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"books": books})
+	}
+}
@@ -0,0 +1,274 @@
+// /backend/src/handlers/taxonomy_handler.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"github.com/swapxs/LibMS/backend/src/services"
+	"gorm.io/gorm"
+)
+
+// SubjectInput names a subject and places it under ParentID, or at the root when
+// ParentID is null.
+type SubjectInput struct {
+	Name     string `json:"name" binding:"required"`
+	ParentID *uint  `json:"parent_id"`
+}
+
+// ClassificationInput replaces a book's subjects and tags. Lists left out are kept.
+type ClassificationInput struct {
+	SubjectIDs *[]uint   `json:"subject_ids"`
+	Tags       *[]string `json:"tags"`
+}
+
+// taxonomyError replies to the errors of the taxonomy services. It reports whether
+// err was one of them. Subjects the services cannot find are named in the request
+// body (a parent, a subject to file under), so they are the caller's mistake.
+func taxonomyError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, services.ErrSubjectNameTaken), errors.Is(err, services.ErrSubjectHasChildren):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrSubjectNotFound), errors.Is(err, services.ErrSubjectCycle), errors.Is(err, services.ErrInvalidTag):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		return false
+	}
+	return true
+}
+
+// findSubject loads a subject of the library from the id parameter, replying 400 or
+// 404 when it cannot.
+func findSubject(c *gin.Context, db *gorm.DB, libraryID uint) (models.Subject, bool) {
+	var subject models.Subject
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subject ID"})
+		return subject, false
+	}
+	if err := db.Where("id = ? AND library_id = ?", id, libraryID).First(&subject).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Subject not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return subject, false
+	}
+	return subject, true
+}
+
+// GetSubjects returns the subject hierarchy of the caller's library with the number
+// of books under each subject.
+func GetSubjects(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := c.MustGet("user").(jwt.MapClaims)
+		libraryID, err := getUintFromClaim(claims, "library_id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		tree, err := services.SubjectTree(db, libraryID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"subjects": tree})
+	}
+}
+
+// CreateSubject adds a subject to the caller's library.
+func CreateSubject(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		libraryID, ok := staffLibrary(c, "manage subjects")
+		if !ok {
+			return
+		}
+		var input SubjectInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var subject models.Subject
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			subject, err = services.CreateSubject(tx, libraryID, input.Name, input.ParentID)
+			return err
+		})
+		if taxonomyError(c, err) {
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"message": "Subject created", "subject": subject})
+	}
+}
+
+// UpdateSubject renames a subject of the caller's library and moves it, with the
+// subjects under it, to the given parent.
+func UpdateSubject(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		libraryID, ok := staffLibrary(c, "manage subjects")
+		if !ok {
+			return
+		}
+		subject, ok := findSubject(c, db, libraryID)
+		if !ok {
+			return
+		}
+		var input SubjectInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return services.UpdateSubject(tx, &subject, input.Name, input.ParentID)
+		})
+		if taxonomyError(c, err) {
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Subject updated", "subject": subject})
+	}
+}
+
+// DeleteSubject removes a subject of the caller's library that has no subjects under
+// it. Its books are no longer filed under it.
+func DeleteSubject(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		libraryID, ok := staffLibrary(c, "manage subjects")
+		if !ok {
+			return
+		}
+		subject, ok := findSubject(c, db, libraryID)
+		if !ok {
+			return
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return services.DeleteSubject(tx, subject)
+		})
+		if taxonomyError(c, err) {
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Subject deleted"})
+	}
+}
+
+// GetTags lists the tags used in the caller's library, most used first.
+func GetTags(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := c.MustGet("user").(jwt.MapClaims)
+		libraryID, err := getUintFromClaim(claims, "library_id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		tags, err := services.LibraryTags(db, libraryID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"tags": tags})
+	}
+}
+
+// findLibraryBook loads a book of the caller's library from the isbn parameter,
+// replying 400 or 404 when it cannot.
+func findLibraryBook(c *gin.Context, db *gorm.DB, libraryID uint) (models.BookInventory, bool) {
+	var book models.BookInventory
+	bookISBN, ok := normalizeISBN(c, c.Param("isbn"))
+	if !ok {
+		return book, false
+	}
+	if err := db.Where("isbn = ? AND library_id = ?", bookISBN, libraryID).First(&book).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return book, false
+	}
+	return book, true
+}
+
+// replyClassification sends the subjects and tags of a book.
+func replyClassification(c *gin.Context, db *gorm.DB, book models.BookInventory) {
+	subjects, tags, err := services.BookClassification(db, book.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"isbn": book.ISBN, "subjects": subjects, "tags": tags})
+}
+
+// GetBookClassification returns the subjects and tags of a book of the caller's
+// library.
+func GetBookClassification(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := c.MustGet("user").(jwt.MapClaims)
+		libraryID, err := getUintFromClaim(claims, "library_id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		book, ok := findLibraryBook(c, db, libraryID)
+		if !ok {
+			return
+		}
+		replyClassification(c, db, book)
+	}
+}
+
+// SetBookClassification replaces the subjects (subject_ids) and tags of a book of the
+// caller's library.
+func SetBookClassification(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		libraryID, ok := staffLibrary(c, "classify books")
+		if !ok {
+			return
+		}
+		book, ok := findLibraryBook(c, db, libraryID)
+		if !ok {
+			return
+		}
+		var input ClassificationInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if input.SubjectIDs != nil {
+				if err := services.SetBookSubjects(tx, book, *input.SubjectIDs); err != nil {
+					return err
+				}
+			}
+			if input.Tags != nil {
+				return services.SetBookTags(tx, book, *input.Tags)
+			}
+			return nil
+		})
+		if taxonomyError(c, err) {
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		replyClassification(c, db, book)
+	}
+}
@@ -0,0 +1,172 @@
+// /backend/src/handlers/trash_handler.go
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"github.com/swapxs/LibMS/backend/src/services"
+	"github.com/swapxs/LibMS/backend/src/storage"
+	"gorm.io/gorm"
+)
+
+// GetTrash lists the deleted books and reader accounts of the caller's library.
+func GetTrash(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		libraryID, ok := staffLibrary(c, "view the trash")
+		if !ok {
+			return
+		}
+		books, err := services.TrashedBooks(db, libraryID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		users, err := services.TrashedUsers(db, libraryID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"books": books, "users": users})
+	}
+}
+
+// findTrashedBook loads the deleted book named by the :isbn parameter. It replies
+// 400 or 404 and returns false when there is none.
+func findTrashedBook(c *gin.Context, db *gorm.DB, libraryID uint) (models.BookInventory, bool) {
+	bookISBN, ok := normalizeISBN(c, c.Param("isbn"))
+	if !ok {
+		return models.BookInventory{}, false
+	}
+	book, err := services.FindTrashedBook(db, libraryID, bookISBN)
+	if errors.Is(err, services.ErrNotInTrash) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Book not found in trash"})
+		return book, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return book, false
+	}
+	return book, true
+}
+
+// findTrashedUser loads the deleted account named by the :id parameter. It replies
+// 400 or 404 and returns false when there is none.
+func findTrashedUser(c *gin.Context, db *gorm.DB, libraryID uint) (models.User, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return models.User{}, false
+	}
+	user, err := services.FindTrashedUser(db, libraryID, uint(id))
+	if errors.Is(err, services.ErrNotInTrash) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found in trash"})
+		return user, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return user, false
+	}
+	return user, true
+}
+
+// RestoreBook takes a book out of the trash. It comes back without copies; add
+// them as for any book.
+func RestoreBook(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		libraryID, ok := staffLibrary(c, "restore books")
+		if !ok {
+			return
+		}
+		book, ok := findTrashedBook(c, db, libraryID)
+		if !ok {
+			return
+		}
+		if err := services.RestoreBook(db, &book); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Book restored", "book": book})
+	}
+}
+
+// PurgeBook deletes a book in the trash for good, along with its cover.
+func PurgeBook(db *gorm.DB, store storage.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		libraryID, ok := staffLibrary(c, "purge books")
+		if !ok {
+			return
+		}
+		book, ok := findTrashedBook(c, db, libraryID)
+		if !ok {
+			return
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return services.PurgeBook(tx, book)
+		})
+		if errors.Is(err, services.ErrBookInUse) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		// The cover goes once the book is gone; a cover left behind is only wasted space.
+		if store != nil && book.CoverContentType != "" {
+			if err := deleteCover(c, store, book); err != nil {
+				log.Printf("Could not delete the cover of purged book %s: %v", book.ISBN, err)
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Book purged", "isbn": book.ISBN})
+	}
+}
+
+// RestoreUser takes a reader account out of the trash.
+func RestoreUser(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		libraryID, ok := staffLibrary(c, "restore users")
+		if !ok {
+			return
+		}
+		user, ok := findTrashedUser(c, db, libraryID)
+		if !ok {
+			return
+		}
+		if err := services.RestoreUser(db, &user); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "User restored", "user_id": user.ID})
+	}
+}
+
+// PurgeUser deletes a reader account in the trash for good.
+func PurgeUser(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		libraryID, ok := staffLibrary(c, "purge users")
+		if !ok {
+			return
+		}
+		user, ok := findTrashedUser(c, db, libraryID)
+		if !ok {
+			return
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return services.PurgeUser(tx, user)
+		})
+		if errors.Is(err, services.ErrUserInUse) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "User purged", "user_id": user.ID})
+	}
+}
@@ -2,11 +2,14 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/swapxs/LibMS/backend/src/models"
+	"github.com/swapxs/LibMS/backend/src/services"
 	"gorm.io/gorm"
 )
 
@@ -52,3 +55,82 @@ func GetUserIssueInfo(db *gorm.DB) gin.HandlerFunc {
         })
 	}
 }
+
+// PatronCategoryInput is the payload for setting a user's patron category.
+type PatronCategoryInput struct {
+	PatronCategory string `json:"patron_category"`
+}
+
+// UpdatePatronCategory sets the patron category that selects a user's loan policy
+// rules. An empty category clears it.
+func UpdatePatronCategory(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		libraryID, ok := staffLibrary(c, "change patron categories")
+		if !ok {
+			return
+		}
+		userID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+		var input PatronCategoryInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var user models.User
+		if err := db.Where("id = ? AND library_id = ?", userID, libraryID).First(&user).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		if err := db.Model(&user).UpdateColumn("patron_category", input.PatronCategory).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Patron category updated", "user_id": user.ID, "patron_category": input.PatronCategory})
+	}
+}
+
+// DeleteUser moves a reader account of the caller's library to the trash, from where
+// it can be restored or purged. Readers with books out, pending requests, holds or
+// fines owed cannot be removed.
+func DeleteUser(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		libraryID, ok := staffLibrary(c, "delete users")
+		if !ok {
+			return
+		}
+		userID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+
+		var user models.User
+		if err := db.Where("id = ? AND library_id = ?", userID, libraryID).First(&user).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		if user.Role != "Reader" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Only reader accounts can be deleted"})
+			return
+		}
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := services.CheckUserRemovable(tx, user); err != nil {
+				return err
+			}
+			return tx.Delete(&user).Error
+		})
+		if errors.Is(err, services.ErrUserInUse) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "User moved to trash", "user_id": user.ID})
+	}
+}
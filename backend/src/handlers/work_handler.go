@@ -0,0 +1,221 @@
+// /backend/src/handlers/work_handler.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"github.com/swapxs/LibMS/backend/src/services"
+	"gorm.io/gorm"
+)
+
+// WorkInput describes a work. ISBNs, when given, replace its editions.
+type WorkInput struct {
+	Title  string    `json:"title" binding:"required"`
+	Author string    `json:"author"`
+	ISBNs  *[]string `json:"isbns"`
+}
+
+// WorkSummary is a work with the number of its editions and of their free copies.
+type WorkSummary struct {
+	models.Work
+	EditionCount    int64 `json:"edition_count"`
+	AvailableCopies int64 `json:"available_copies"`
+}
+
+// findWork loads a work of the library from the id parameter, replying 400 or 404
+// when it cannot.
+func findWork(c *gin.Context, db *gorm.DB, libraryID uint) (models.Work, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid work ID"})
+		return models.Work{}, false
+	}
+	work, err := services.FindWork(db, libraryID, uint(id))
+	if err != nil {
+		if errors.Is(err, services.ErrWorkNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Work not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return work, false
+	}
+	return work, true
+}
+
+// normalizeISBNs brings the ISBNs of a request into stored form, replying 400 on the
+// first invalid one.
+func normalizeISBNs(c *gin.Context, raw []string) ([]string, bool) {
+	isbns := make([]string, 0, len(raw))
+	for _, r := range raw {
+		normalized, ok := normalizeISBN(c, r)
+		if !ok {
+			return nil, false
+		}
+		isbns = append(isbns, normalized)
+	}
+	return isbns, true
+}
+
+// saveWork stores a work and, if isbns is not nil, its editions, replying on failure.
+func saveWork(c *gin.Context, db *gorm.DB, work *models.Work, isbns []string) bool {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(work).Error; err != nil {
+			return err
+		}
+		if isbns == nil {
+			return nil
+		}
+		return services.SetWorkEditions(tx, *work, isbns)
+	})
+	if errors.Is(err, services.ErrEditionNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// replyWork sends a work with its editions.
+func replyWork(c *gin.Context, db *gorm.DB, status int, work models.Work) {
+	editions, err := services.WorkEditions(db, work.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(status, gin.H{"work": work, "editions": editions})
+}
+
+// GetWorks lists the works of the caller's library by title, with edition and free
+// copy counts. q narrows the list to titles or authors containing it.
+func GetWorks(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := c.MustGet("user").(jwt.MapClaims)
+		libraryID, err := getUintFromClaim(claims, "library_id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		query := db.Model(&models.Work{}).
+			Select("works.*, COUNT(book_inventories.id) AS edition_count, COALESCE(SUM(book_inventories.available_copies), 0) AS available_copies").
+			Joins("LEFT JOIN book_inventories ON book_inventories.work_id = works.id AND book_inventories.deleted_at IS NULL").
+			Where("works.library_id = ?", libraryID).Group("works.id")
+		if q := strings.ToLower(strings.TrimSpace(c.Query("q"))); q != "" {
+			query = query.Where("LOWER(works.title) LIKE ? OR LOWER(works.author) LIKE ?", "%"+q+"%", "%"+q+"%")
+		}
+		works := []WorkSummary{}
+		if err := query.Order("works.title ASC, works.id ASC").Scan(&works).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"works": works})
+	}
+}
+
+// GetWork returns a work of the caller's library with its editions.
+func GetWork(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := c.MustGet("user").(jwt.MapClaims)
+		libraryID, err := getUintFromClaim(claims, "library_id")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		work, ok := findWork(c, db, libraryID)
+		if !ok {
+			return
+		}
+		replyWork(c, db, http.StatusOK, work)
+	}
+}
+
+// CreateWork adds a work to the caller's library, grouping the books given by ISBN
+// as its editions.
+func CreateWork(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		libraryID, ok := staffLibrary(c, "manage works")
+		if !ok {
+			return
+		}
+		var input WorkInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		isbns := []string{}
+		if input.ISBNs != nil {
+			if isbns, ok = normalizeISBNs(c, *input.ISBNs); !ok {
+				return
+			}
+		}
+
+		work := models.Work{LibraryID: libraryID, Title: strings.TrimSpace(input.Title), Author: strings.TrimSpace(input.Author)}
+		if !saveWork(c, db, &work, isbns) {
+			return
+		}
+		replyWork(c, db, http.StatusCreated, work)
+	}
+}
+
+// UpdateWork renames a work of the caller's library and, if isbns is given, replaces
+// its editions.
+func UpdateWork(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		libraryID, ok := staffLibrary(c, "manage works")
+		if !ok {
+			return
+		}
+		work, ok := findWork(c, db, libraryID)
+		if !ok {
+			return
+		}
+		var input WorkInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		var isbns []string
+		if input.ISBNs != nil {
+			if isbns, ok = normalizeISBNs(c, *input.ISBNs); !ok {
+				return
+			}
+		}
+
+		work.Title, work.Author = strings.TrimSpace(input.Title), strings.TrimSpace(input.Author)
+		if !saveWork(c, db, &work, isbns) {
+			return
+		}
+		replyWork(c, db, http.StatusOK, work)
+	}
+}
+
+// DeleteWork removes a work of the caller's library. Its editions stay in the
+// catalog, no longer grouped.
+func DeleteWork(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		libraryID, ok := staffLibrary(c, "manage works")
+		if !ok {
+			return
+		}
+		work, ok := findWork(c, db, libraryID)
+		if !ok {
+			return
+		}
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			return services.DeleteWork(tx, work)
+		}); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Work deleted"})
+	}
+}
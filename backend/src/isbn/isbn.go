@@ -0,0 +1,84 @@
+// /backend/src/isbn/isbn.go
+
+// Package isbn validates ISBNs and brings them into the one form the catalog
+// stores: the 13 digits of the ISBN-13, without hyphens or spaces.
+package isbn
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrInvalid is returned for a value that is not a valid ISBN-10 or ISBN-13.
+var ErrInvalid = errors.New("invalid ISBN")
+
+// Normalize strips hyphens and spaces from s, checks its check digit and returns
+// it as an ISBN-13. ISBN-10s are converted with the 978 prefix.
+func Normalize(s string) (string, error) {
+	digits := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(s)))
+	switch len(digits) {
+	case 10:
+		if !valid10(digits) {
+			return "", ErrInvalid
+		}
+		return to13(digits), nil
+	case 13:
+		if !valid13(digits) {
+			return "", ErrInvalid
+		}
+		return digits, nil
+	}
+	return "", ErrInvalid
+}
+
+// Valid reports whether s is a valid ISBN-10 or ISBN-13, hyphens and spaces aside.
+func Valid(s string) bool {
+	_, err := Normalize(s)
+	return err == nil
+}
+
+// valid10 checks an ISBN-10: nine digits and a check digit (0-9 or X for 10) such
+// that the sum of each digit times its weight, 10 down to 1, is divisible by 11.
+func valid10(s string) bool {
+	sum := 0
+	for i, r := range s {
+		var d int
+		switch {
+		case r >= '0' && r <= '9':
+			d = int(r - '0')
+		case r == 'X' && i == 9:
+			d = 10
+		default:
+			return false
+		}
+		sum += d * (10 - i)
+	}
+	return sum%11 == 0
+}
+
+// valid13 checks an ISBN-13: thirteen digits whose sum, weighted alternately 1 and
+// 3, is divisible by 10. Only the 978 and 979 (Bookland) prefixes are ISBNs.
+func valid13(s string) bool {
+	if !strings.HasPrefix(s, "978") && !strings.HasPrefix(s, "979") {
+		return false
+	}
+	sum := 0
+	for i, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+		sum += int(r-'0') * (1 + 2*(i%2))
+	}
+	return sum%10 == 0
+}
+
+// to13 converts a valid ISBN-10 to its ISBN-13 by prefixing 978 and recomputing
+// the check digit.
+func to13(s string) string {
+	body := "978" + s[:9]
+	sum := 0
+	for i, r := range body {
+		sum += int(r-'0') * (1 + 2*(i%2))
+	}
+	return body + string(rune('0'+(10-sum%10)%10))
+}
@@ -4,10 +4,14 @@ package main
 import (
 	"log"
 	"os"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/swapxs/LibMS/backend/src/db"
+	"github.com/swapxs/LibMS/backend/src/metadata"
 	"github.com/swapxs/LibMS/backend/src/routes"
+	"github.com/swapxs/LibMS/backend/src/services"
+	"github.com/swapxs/LibMS/backend/src/storage"
 )
 
 func main() {
@@ -19,8 +23,46 @@ func main() {
 	// Initialize the database.
 	database := db.InitDB()
 
+	// Mark overdue issues and accrue fines in the background.
+	sweepInterval := time.Hour
+	if v := os.Getenv("OVERDUE_SWEEP_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			log.Fatalf("Invalid OVERDUE_SWEEP_INTERVAL %q", v)
+		}
+		sweepInterval = d
+	}
+	stopScheduler := services.StartOverdueScheduler(database, sweepInterval)
+	defer stopScheduler()
+
+	// Look up book details by ISBN in an Open Library compatible service, if one is
+	// configured.
+	var provider metadata.Provider
+	if v := os.Getenv("METADATA_BASE_URL"); v != "" {
+		provider = metadata.NewOpenLibrary(v)
+	}
+
+	// Keep cover images in an S3-compatible bucket when one is configured, and on
+	// the local disk otherwise.
+	var store storage.Store
+	if bucket := os.Getenv("S3_BUCKET"); bucket != "" {
+		store = storage.NewS3(storage.S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    bucket,
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+		})
+	} else {
+		coverDir := os.Getenv("COVER_DIR")
+		if coverDir == "" {
+			coverDir = "uploads"
+		}
+		store = storage.NewLocal(coverDir)
+	}
+
 	// Set up the router with all endpoints.
-	router := routes.SetupRouter(database)
+	router := routes.SetupRouter(database, provider, store)
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -0,0 +1,118 @@
+// /backend/src/marc/binary.go
+package marc
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strconv"
+)
+
+// ISO 2709 delimiters.
+const (
+	subfieldDelimiter = 0x1F
+	fieldTerminator   = 0x1E
+	recordTerminator  = 0x1D
+)
+
+// ReadBinary reads MARC21 records in their binary exchange form. Line breaks between
+// records are tolerated. Field data is taken as UTF-8 (leader position 9 "a").
+func ReadBinary(r io.Reader) ([]Record, error) {
+	br := bufio.NewReader(r)
+	var records []Record
+	for n := 1; ; n++ {
+		// Skip line breaks some exporters put between records.
+		for {
+			b, err := br.ReadByte()
+			if err == io.EOF {
+				return records, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			if b != '\n' && b != '\r' {
+				br.UnreadByte()
+				break
+			}
+		}
+
+		head := make([]byte, 5)
+		if _, err := io.ReadFull(br, head); err != nil {
+			return nil, malformed(n, "truncated record length")
+		}
+		length, ok := number(head)
+		if !ok || length < 25 {
+			return nil, malformed(n, "invalid record length %q", head)
+		}
+		raw := make([]byte, length)
+		copy(raw, head)
+		if _, err := io.ReadFull(br, raw[5:]); err != nil {
+			return nil, malformed(n, "record shorter than its length %d", length)
+		}
+		record, err := parseBinaryRecord(n, raw)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+}
+
+// parseBinaryRecord decodes one record: the leader, a directory of 12 byte entries
+// (tag, field length, offset) and the field data starting at the base address.
+func parseBinaryRecord(n int, raw []byte) (Record, error) {
+	if raw[len(raw)-1] != recordTerminator {
+		return Record{}, malformed(n, "missing record terminator")
+	}
+	record := Record{Leader: string(raw[:24])}
+	base, ok := number(raw[12:17])
+	if !ok || base < 25 || base > len(raw) || raw[base-1] != fieldTerminator {
+		return Record{}, malformed(n, "invalid base address %q", raw[12:17])
+	}
+	directory := raw[24 : base-1]
+	if len(directory)%12 != 0 {
+		return Record{}, malformed(n, "invalid directory length %d", len(directory))
+	}
+	data := raw[base:]
+
+	for i := 0; i < len(directory); i += 12 {
+		entry := directory[i : i+12]
+		tag := string(entry[:3])
+		length, ok1 := number(entry[3:7])
+		start, ok2 := number(entry[7:12])
+		if !ok1 || !ok2 || length < 1 || start < 0 || start+length > len(data) {
+			return Record{}, malformed(n, "invalid directory entry for %s", tag)
+		}
+		value := bytes.TrimRight(data[start:start+length], string([]byte{fieldTerminator}))
+
+		field := Field{Tag: tag}
+		if field.IsControl() {
+			field.Value = string(value)
+			record.Fields = append(record.Fields, field)
+			continue
+		}
+		if len(value) < 2 {
+			return Record{}, malformed(n, "field %s has no indicators", tag)
+		}
+		field.Ind1, field.Ind2 = string(value[0]), string(value[1])
+		for _, part := range bytes.Split(value[2:], []byte{subfieldDelimiter}) {
+			if len(part) == 0 {
+				continue
+			}
+			field.Subfields = append(field.Subfields, Subfield{Code: string(part[0]), Value: string(part[1:])})
+		}
+		record.Fields = append(record.Fields, field)
+	}
+	return record, nil
+}
+
+// number reads a fixed-width number of the leader or directory, which is all ASCII
+// digits; signs and spaces are refused.
+func number(b []byte) (int, bool) {
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+	}
+	n, err := strconv.Atoi(string(b))
+	return n, err == nil
+}
@@ -0,0 +1,162 @@
+// /backend/src/marc/book.go
+package marc
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/swapxs/LibMS/backend/src/isbn"
+)
+
+// Book is the catalog data carried by a bibliographic record.
+type Book struct {
+	ISBN      string // 020 $a
+	Title     string // 245 $a and $b
+	Author    string // 100 $a
+	Publisher string // 264 $b (publication) or 260 $b
+	Language  string // 041 $a, or 008/35-37
+	Edition   string // 250 $a
+}
+
+// mappedTags are the tags MapRecord reads.
+var mappedTags = map[string]bool{
+	"008": true, "020": true, "041": true, "100": true, "245": true, "250": true, "260": true, "264": true,
+}
+
+// languageNames maps MARC language codes to the names the catalog uses. Other codes
+// are kept as they are.
+var languageNames = map[string]string{
+	"ara": "Arabic", "ben": "Bengali", "chi": "Chinese", "dut": "Dutch", "eng": "English",
+	"fre": "French", "ger": "German", "gre": "Greek", "heb": "Hebrew", "hin": "Hindi",
+	"ita": "Italian", "jpn": "Japanese", "kan": "Kannada", "kor": "Korean", "mal": "Malayalam",
+	"mar": "Marathi", "per": "Persian", "pol": "Polish", "por": "Portuguese", "rus": "Russian",
+	"spa": "Spanish", "swe": "Swedish", "tam": "Tamil", "tel": "Telugu", "tur": "Turkish",
+	"urd": "Urdu",
+}
+
+// trimISBD removes the ISBD punctuation MARC puts at the end of subfields
+// ("Title :", "Publisher,", "Author.").
+func trimISBD(s string) string {
+	return strings.TrimSpace(strings.TrimRight(strings.TrimSpace(s), " /:;,.="))
+}
+
+// MapRecord maps a record to catalog fields. It also returns, sorted, the tags of
+// the record it had no use for.
+func MapRecord(record Record) (Book, []string) {
+	var book Book
+
+	// The first ISBN that validates; qualifiers such as "(pbk.)" are dropped.
+	for _, f := range record.FieldsByTag("020") {
+		value := strings.Fields(f.Subfield("a"))
+		if len(value) == 0 {
+			continue
+		}
+		if book.ISBN == "" {
+			book.ISBN = value[0]
+		}
+		if isbn.Valid(value[0]) {
+			book.ISBN = value[0]
+			break
+		}
+	}
+	if fields := record.FieldsByTag("100"); len(fields) > 0 {
+		book.Author = trimISBD(fields[0].Subfield("a"))
+	}
+	if fields := record.FieldsByTag("245"); len(fields) > 0 {
+		book.Title = trimISBD(fields[0].Subfield("a"))
+		if subtitle := trimISBD(fields[0].Subfield("b")); subtitle != "" {
+			book.Title += ": " + subtitle
+		}
+	}
+	if fields := record.FieldsByTag("250"); len(fields) > 0 {
+		book.Edition = trimISBD(fields[0].Subfield("a"))
+	}
+	// 264 with second indicator 1 is the publication statement of RDA records; 260
+	// is its AACR2 predecessor.
+	for _, f := range record.FieldsByTag("264") {
+		if f.Ind2 == "1" && book.Publisher == "" {
+			book.Publisher = trimISBD(f.Subfield("b"))
+		}
+	}
+	if fields := record.FieldsByTag("260"); len(fields) > 0 && book.Publisher == "" {
+		book.Publisher = trimISBD(fields[0].Subfield("b"))
+	}
+
+	code := ""
+	if fields := record.FieldsByTag("041"); len(fields) > 0 {
+		code = fields[0].Subfield("a")
+	}
+	if fields := record.FieldsByTag("008"); code == "" && len(fields) > 0 && len(fields[0].Value) >= 38 {
+		code = fields[0].Value[35:38]
+	}
+	book.Language = LanguageName(code)
+
+	seen := map[string]bool{}
+	var unmapped []string
+	for _, f := range record.Fields {
+		if !mappedTags[f.Tag] && !seen[f.Tag] {
+			seen[f.Tag] = true
+			unmapped = append(unmapped, f.Tag)
+		}
+	}
+	sort.Strings(unmapped)
+	return book, unmapped
+}
+
+// LanguageName returns the catalog name of a MARC language code. Unknown codes are
+// returned as they are; undetermined ones ("und", "|||") give "".
+func LanguageName(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if name, ok := languageNames[code]; ok {
+		return name
+	}
+	if code == "und" || code == "|||" {
+		return ""
+	}
+	return code
+}
+
+// languageCode returns the MARC code for a catalog language name, or "und" when it
+// is unknown.
+func languageCode(name string) string {
+	for code, n := range languageNames {
+		if strings.EqualFold(n, name) {
+			return code
+		}
+	}
+	if len(name) == 3 {
+		return strings.ToLower(name)
+	}
+	return "und"
+}
+
+// BookRecord builds a minimal bibliographic record for a catalog book. controlNumber
+// becomes field 001.
+func BookRecord(controlNumber string, book Book) Record {
+	lang := languageCode(book.Language)
+	fixed := []byte(strings.Repeat(" ", 40))
+	copy(fixed[35:], lang)
+	fixed[39] = 'd'
+
+	record := Record{
+		// Language material, monograph, Unicode; lengths are left to the reader.
+		Leader: "00000nam a2200000 i 4500",
+		Fields: []Field{
+			{Tag: "001", Value: controlNumber},
+			{Tag: "008", Value: string(fixed)},
+			{Tag: "020", Ind1: " ", Ind2: " ", Subfields: []Subfield{{Code: "a", Value: book.ISBN}}},
+			{Tag: "041", Ind1: "0", Ind2: " ", Subfields: []Subfield{{Code: "a", Value: lang}}},
+		},
+	}
+	if book.Author != "" {
+		record.Fields = append(record.Fields, Field{Tag: "100", Ind1: "1", Ind2: " ", Subfields: []Subfield{{Code: "a", Value: book.Author}}})
+	}
+	record.Fields = append(record.Fields, Field{Tag: "245", Ind1: "1", Ind2: "0", Subfields: []Subfield{{Code: "a", Value: book.Title}}})
+	if book.Edition != "" {
+		record.Fields = append(record.Fields, Field{Tag: "250", Ind1: " ", Ind2: " ", Subfields: []Subfield{{Code: "a", Value: book.Edition}}})
+	}
+	if book.Publisher != "" {
+		record.Fields = append(record.Fields, Field{Tag: "264", Ind1: " ", Ind2: "1", Subfields: []Subfield{{Code: "b", Value: book.Publisher}}})
+	}
+	return record
+}
@@ -0,0 +1,76 @@
+// /backend/src/marc/record.go
+
+// Package marc reads MARC21 bibliographic records, in binary (ISO 2709) and MARCXML
+// form, writes them as MARCXML and maps them to and from catalog fields.
+package marc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// ErrMalformed is returned for input that is not a well-formed MARC record.
+var ErrMalformed = errors.New("malformed MARC record")
+
+// Record is a MARC record: a 24 character leader and its fields in order.
+type Record struct {
+	Leader string
+	Fields []Field
+}
+
+// Field is a control field (tags 001-009), which has only a Value, or a data field
+// with two indicators and subfields.
+type Field struct {
+	Tag       string
+	Value     string
+	Ind1      string
+	Ind2      string
+	Subfields []Subfield
+}
+
+// Subfield is a coded part of a data field.
+type Subfield struct {
+	Code  string
+	Value string
+}
+
+// IsControl reports whether f is a control field.
+func (f Field) IsControl() bool {
+	return len(f.Tag) == 3 && f.Tag[0] == '0' && f.Tag[1] == '0'
+}
+
+// Subfield returns the value of the first subfield with the given code.
+func (f Field) Subfield(code string) string {
+	for _, sf := range f.Subfields {
+		if sf.Code == code {
+			return sf.Value
+		}
+	}
+	return ""
+}
+
+// FieldsByTag returns the fields of r with the given tag.
+func (r Record) FieldsByTag(tag string) []Field {
+	var fields []Field
+	for _, f := range r.Fields {
+		if f.Tag == tag {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// Parse reads records in either form, telling MARCXML from binary MARC by its
+// leading '<'.
+func Parse(data []byte) ([]Record, error) {
+	trimmed := bytes.TrimLeft(data, " \t\r\n\ufeff")
+	if len(trimmed) > 0 && trimmed[0] == '<' {
+		return ReadXML(bytes.NewReader(trimmed))
+	}
+	return ReadBinary(bytes.NewReader(data))
+}
+
+func malformed(n int, format string, args ...interface{}) error {
+	return fmt.Errorf("%w: record %d: %s", ErrMalformed, n, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,121 @@
+// /backend/src/marc/xml.go
+package marc
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+// Namespace is the MARCXML (MARC21 slim) namespace.
+const Namespace = "http://www.loc.gov/MARC21/slim"
+
+type xmlRecord struct {
+	XMLName       xml.Name          `xml:"record"`
+	Leader        string            `xml:"leader"`
+	ControlFields []xmlControlField `xml:"controlfield"`
+	DataFields    []xmlDataField    `xml:"datafield"`
+}
+
+type xmlControlField struct {
+	Tag   string `xml:"tag,attr"`
+	Value string `xml:",chardata"`
+}
+
+type xmlDataField struct {
+	Tag       string        `xml:"tag,attr"`
+	Ind1      string        `xml:"ind1,attr"`
+	Ind2      string        `xml:"ind2,attr"`
+	Subfields []xmlSubfield `xml:"subfield"`
+}
+
+type xmlSubfield struct {
+	Code  string `xml:"code,attr"`
+	Value string `xml:",chardata"`
+}
+
+// ReadXML reads every record element of a MARCXML document, whether it is a
+// collection or a single record, with or without a namespace prefix.
+func ReadXML(r io.Reader) ([]Record, error) {
+	decoder := xml.NewDecoder(r)
+	var records []Record
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, malformed(len(records)+1, "%v", err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "record" {
+			continue
+		}
+		var x xmlRecord
+		if err := decoder.DecodeElement(&x, &start); err != nil {
+			return nil, malformed(len(records)+1, "%v", err)
+		}
+		record := Record{Leader: x.Leader}
+		for _, cf := range x.ControlFields {
+			record.Fields = append(record.Fields, Field{Tag: cf.Tag, Value: cf.Value})
+		}
+		for _, df := range x.DataFields {
+			field := Field{Tag: df.Tag, Ind1: df.Ind1, Ind2: df.Ind2}
+			for _, sf := range df.Subfields {
+				field.Subfields = append(field.Subfields, Subfield{Code: sf.Code, Value: sf.Value})
+			}
+			record.Fields = append(record.Fields, field)
+		}
+		records = append(records, record)
+	}
+}
+
+// XMLWriter streams records as a MARCXML collection.
+type XMLWriter struct {
+	w       io.Writer
+	encoder *xml.Encoder
+	started bool
+}
+
+// NewXMLWriter returns a writer of a MARCXML collection to w. Close must be called
+// to end the collection.
+func NewXMLWriter(w io.Writer) *XMLWriter {
+	return &XMLWriter{w: w, encoder: xml.NewEncoder(w)}
+}
+
+func (x *XMLWriter) start() error {
+	if x.started {
+		return nil
+	}
+	x.started = true
+	_, err := io.WriteString(x.w, xml.Header+`<collection xmlns="`+Namespace+`">`)
+	return err
+}
+
+// Write adds a record to the collection.
+func (x *XMLWriter) Write(record Record) error {
+	if err := x.start(); err != nil {
+		return err
+	}
+	out := xmlRecord{Leader: record.Leader}
+	for _, f := range record.Fields {
+		if f.IsControl() {
+			out.ControlFields = append(out.ControlFields, xmlControlField{Tag: f.Tag, Value: f.Value})
+			continue
+		}
+		df := xmlDataField{Tag: f.Tag, Ind1: f.Ind1, Ind2: f.Ind2}
+		for _, sf := range f.Subfields {
+			df.Subfields = append(df.Subfields, xmlSubfield{Code: sf.Code, Value: sf.Value})
+		}
+		out.DataFields = append(out.DataFields, df)
+	}
+	return x.encoder.Encode(out)
+}
+
+// Close ends the collection.
+func (x *XMLWriter) Close() error {
+	if err := x.start(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(x.w, "</collection>\n")
+	return err
+}
@@ -0,0 +1,29 @@
+// /backend/src/metadata/metadata.go
+
+// Package metadata looks up the bibliographic details of a book by ISBN in an
+// external catalog.
+package metadata
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNotFound is returned by a Provider that has no record for an ISBN.
+var ErrNotFound = errors.New("no metadata found for this ISBN")
+
+// Metadata is what a provider knows about a book, in the terms of the catalog.
+// Fields the provider has no value for are empty.
+type Metadata struct {
+	ISBN      string `json:"isbn"`
+	Title     string `json:"title"`
+	Author    string `json:"author"`
+	Publisher string `json:"publisher"`
+	Language  string `json:"language"`
+	Version   string `json:"version"`
+}
+
+// Provider looks up books in an external catalog. isbn is a normalized ISBN-13.
+type Provider interface {
+	Lookup(ctx context.Context, isbn string) (Metadata, error)
+}
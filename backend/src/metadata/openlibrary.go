@@ -0,0 +1,132 @@
+// /backend/src/metadata/openlibrary.go
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/swapxs/LibMS/backend/src/marc"
+)
+
+// OpenLibraryURL is the base URL of the public Open Library service.
+const OpenLibraryURL = "https://openlibrary.org"
+
+// DefaultTimeout bounds every request of an OpenLibrary provider.
+const DefaultTimeout = 5 * time.Second
+
+// OpenLibrary is a Provider for the Open Library JSON API, or any service serving
+// the same paths: /isbn/{isbn}.json for editions, and /authors/... and /works/...
+// for the records they link to.
+type OpenLibrary struct {
+	BaseURL string
+	Client  *http.Client
+}
+
+// NewOpenLibrary returns a provider for the service at baseURL.
+func NewOpenLibrary(baseURL string) *OpenLibrary {
+	return &OpenLibrary{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		Client:  &http.Client{Timeout: DefaultTimeout},
+	}
+}
+
+// olKey links one Open Library record to another ("/authors/OL1A").
+type olKey struct {
+	Key string `json:"key"`
+}
+
+type olEdition struct {
+	Title       string   `json:"title"`
+	Subtitle    string   `json:"subtitle"`
+	Publishers  []string `json:"publishers"`
+	EditionName string   `json:"edition_name"`
+	Languages   []olKey  `json:"languages"`
+	Authors     []olKey  `json:"authors"`
+	Works       []olKey  `json:"works"`
+}
+
+type olWork struct {
+	Authors []struct {
+		Author olKey `json:"author"`
+	} `json:"authors"`
+}
+
+type olAuthor struct {
+	Name string `json:"name"`
+}
+
+// get decodes the record at path into out. A missing record is ErrNotFound.
+func (o *OpenLibrary) get(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, o.BaseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := o.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("open library: GET %s: %s", path, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Lookup reads the edition with the given ISBN. Authors are taken from the edition,
+// or from its work when the edition lists none; several are separated by semicolons.
+func (o *OpenLibrary) Lookup(ctx context.Context, isbn string) (Metadata, error) {
+	var edition olEdition
+	if err := o.get(ctx, "/isbn/"+isbn+".json", &edition); err != nil {
+		return Metadata{}, err
+	}
+	md := Metadata{
+		ISBN:    isbn,
+		Title:   strings.TrimSpace(edition.Title),
+		Version: strings.TrimSpace(edition.EditionName),
+	}
+	if subtitle := strings.TrimSpace(edition.Subtitle); subtitle != "" {
+		md.Title += ": " + subtitle
+	}
+	if len(edition.Publishers) > 0 {
+		md.Publisher = strings.TrimSpace(edition.Publishers[0])
+	}
+	// Languages are linked as /languages/{MARC code}.
+	if len(edition.Languages) > 0 {
+		key := edition.Languages[0].Key
+		md.Language = marc.LanguageName(key[strings.LastIndex(key, "/")+1:])
+	}
+
+	authors := edition.Authors
+	if len(authors) == 0 && len(edition.Works) > 0 {
+		var work olWork
+		if err := o.get(ctx, edition.Works[0].Key+".json", &work); err != nil && !errors.Is(err, ErrNotFound) {
+			return md, err
+		}
+		for _, a := range work.Authors {
+			authors = append(authors, a.Author)
+		}
+	}
+	var names []string
+	for _, a := range authors {
+		var author olAuthor
+		if err := o.get(ctx, a.Key+".json", &author); errors.Is(err, ErrNotFound) {
+			continue
+		} else if err != nil {
+			return md, err
+		}
+		if name := strings.TrimSpace(author.Name); name != "" {
+			names = append(names, name)
+		}
+	}
+	md.Author = strings.Join(names, "; ")
+	return md, nil
+}
@@ -2,18 +2,22 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"os"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"gorm.io/gorm"
 )
 
 var jwtSecret = []byte(os.Getenv("JWT_SECRET"))
 
 // JWTAuthMiddleware validates the JWT token and sets the user claims in context.
-func JWTAuthMiddleware() gin.HandlerFunc {
+// Tokens of accounts that were deleted since they were issued are refused.
+func JWTAuthMiddleware(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -46,6 +50,21 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
+		id, ok := claims["id"].(float64)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+			c.Abort()
+			return
+		}
+		if err := db.Select("id").First(&models.User{}, uint(id)).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Account no longer exists"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			c.Abort()
+			return
+		}
 		c.Set("user", claims)
 		c.Next()
 	}
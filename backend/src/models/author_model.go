@@ -0,0 +1,35 @@
+// /backend/src/models/author_model.go
+package models
+
+import "gorm.io/gorm"
+
+// Author is a person credited on books of a library. Name is the preferred form of
+// the name; other forms found on books are kept as Variants. NameKey is the
+// normalized name used to match names, unique within the library.
+type Author struct {
+	gorm.Model
+	LibraryID uint                `gorm:"not null;uniqueIndex:idx_author_name" json:"library_id"`
+	Name      string              `gorm:"not null" json:"name"`
+	NameKey   string              `gorm:"not null;uniqueIndex:idx_author_name" json:"-"`
+	Variants  []AuthorNameVariant `gorm:"constraint:OnDelete:CASCADE" json:"variants,omitempty"`
+}
+
+// AuthorNameVariant is another form of an author's name ("J. Bloch" for "Joshua
+// Bloch"). A variant belongs to a single author of the library.
+type AuthorNameVariant struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	AuthorID  uint   `gorm:"not null;index" json:"author_id"`
+	LibraryID uint   `gorm:"not null;uniqueIndex:idx_author_variant" json:"-"`
+	Name      string `gorm:"not null" json:"name"`
+	NameKey   string `gorm:"not null;uniqueIndex:idx_author_variant" json:"-"`
+}
+
+// BookAuthor credits an author on a book in a role: "author", "editor" or
+// "translator". Position orders the credits of a book.
+type BookAuthor struct {
+	BookInventoryID uint   `gorm:"primaryKey" json:"book_inventory_id"`
+	AuthorID        uint   `gorm:"primaryKey;index" json:"author_id"`
+	Role            string `gorm:"primaryKey" json:"role"`
+	Position        int    `gorm:"not null" json:"position"`
+	Author          Author `gorm:"constraint:OnDelete:CASCADE" json:"author"`
+}
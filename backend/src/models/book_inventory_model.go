@@ -14,4 +14,21 @@ type BookInventory struct {
 	Version         string `gorm:"not null"`
 	TotalCopies     int    `gorm:"not null"`
 	AvailableCopies int    `gorm:"not null"`
+	// Call number and where the title stands; copies may name their own shelf.
+	// CallNumberSortKey orders call numbers as on the shelves.
+	CallNumber        string `gorm:"not null;default:''"`
+	CallNumberScheme  string `gorm:"not null;default:''"` // "DDC" or "LCC"
+	CallNumberSortKey string `gorm:"not null;default:'';index" json:"-"`
+	Floor             string `gorm:"not null;default:''"`
+	Section           string `gorm:"not null;default:''"`
+	Shelf             string `gorm:"not null;default:''"`
+	// CoverContentType is the media type of the uploaded cover, "" when there is none.
+	CoverContentType string `gorm:"not null;default:''"`
+	// WorkID groups the book with the other editions of the same work, if any.
+	WorkID *uint `gorm:"index" json:",omitempty"`
+	// Author is the display form of the credits in Authors, kept for compatibility.
+	Authors []BookAuthor `gorm:"constraint:OnDelete:CASCADE" json:",omitempty"`
+	// Classification, loaded on demand; see GetBookClassification.
+	Subjects []BookSubject `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	Tags     []BookTag     `gorm:"constraint:OnDelete:CASCADE" json:"-"`
 }
@@ -0,0 +1,16 @@
+// /backend/src/models/book_item_model.go
+package models
+
+import "gorm.io/gorm"
+
+// BookItem is a single physical copy of a BookInventory title. The title's
+// TotalCopies and AvailableCopies counters are derived from its items.
+type BookItem struct {
+	gorm.Model
+	BookInventoryID uint   `gorm:"not null;index" json:"book_inventory_id"`
+	LibraryID       uint   `gorm:"not null;uniqueIndex:idx_item_barcode" json:"library_id"`
+	Barcode         string `gorm:"not null;uniqueIndex:idx_item_barcode" json:"barcode"`
+	Condition       string `gorm:"not null;default:Good" json:"condition"` // "New", "Good", "Fair", "Poor", "Damaged"
+	ShelfLocation   string `json:"shelf_location"`
+	Status          string `gorm:"not null;default:Available" json:"status"` // "Available", "Issued", "Damaged", "Lost", "Withdrawn"
+}
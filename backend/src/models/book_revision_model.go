@@ -0,0 +1,24 @@
+// /backend/src/models/book_revision_model.go
+package models
+
+import "time"
+
+// BookRevision is an append-only record of an edit to a book's catalog details.
+type BookRevision struct {
+	ID              uint         `gorm:"primaryKey" json:"id"`
+	BookInventoryID uint         `gorm:"not null;index" json:"book_inventory_id"`
+	EditorID        uint         `gorm:"not null" json:"editor_id"`
+	RevertOf        *uint        `json:"revert_of,omitempty"` // revision undone by this one
+	Changes         []BookChange `gorm:"constraint:OnDelete:CASCADE" json:"changes"`
+	CreatedAt       time.Time    `json:"created_at"`
+}
+
+// BookChange is a field changed by a revision. Credits are recorded under "authors"
+// as JSON.
+type BookChange struct {
+	ID             uint   `gorm:"primaryKey" json:"-"`
+	BookRevisionID uint   `gorm:"not null;index" json:"-"`
+	Field          string `gorm:"not null" json:"field"`
+	OldValue       string `gorm:"not null" json:"old_value"`
+	NewValue       string `gorm:"not null" json:"new_value"`
+}
@@ -0,0 +1,46 @@
+// /backend/src/models/fine_model.go
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// FinePolicy holds a library's overdue fine rules. All amounts are in cents.
+type FinePolicy struct {
+	gorm.Model
+	LibraryID           uint  `gorm:"not null;uniqueIndex" json:"library_id"`
+	DailyRateCents      int64 `gorm:"not null" json:"daily_rate_cents"`
+	GracePeriodDays     int   `gorm:"not null" json:"grace_period_days"`
+	MaxFineCents        int64 `gorm:"not null" json:"max_fine_cents"`        // 0 means uncapped
+	BlockThresholdCents int64 `gorm:"not null" json:"block_threshold_cents"` // readers owing more cannot raise requests
+}
+
+// Fine is the amount owed for a single overdue issue.
+type Fine struct {
+	gorm.Model
+	IssueID         uint  `gorm:"not null;uniqueIndex" json:"issue_id"`
+	ReaderID        uint  `gorm:"not null;index" json:"reader_id"`
+	LibraryID       uint  `gorm:"not null;index" json:"library_id"`
+	AccruedCents    int64 `gorm:"not null" json:"accrued_cents"`
+	AdjustmentCents int64 `gorm:"not null" json:"adjustment_cents"`
+	PaidCents       int64 `gorm:"not null" json:"paid_cents"`
+	WaivedCents     int64 `gorm:"not null" json:"waived_cents"`
+}
+
+// BalanceCents returns what is still owed on the fine.
+func (f Fine) BalanceCents() int64 {
+	return f.AccruedCents + f.AdjustmentCents - f.PaidCents - f.WaivedCents
+}
+
+// FineTransaction records a payment, waiver or adjustment made by an admin.
+type FineTransaction struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	FineID      uint      `gorm:"not null;index" json:"fine_id"`
+	Type        string    `gorm:"not null" json:"type"` // "Payment", "Waiver", "Adjustment"
+	AmountCents int64     `gorm:"not null" json:"amount_cents"`
+	Note        string    `json:"note"`
+	ActorID     uint      `gorm:"not null" json:"actor_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
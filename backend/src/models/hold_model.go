@@ -0,0 +1,19 @@
+// /backend/src/models/hold_model.go
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Hold is a reader's place in the FIFO queue for an unavailable title.
+type Hold struct {
+	gorm.Model
+	ISBN           string     `gorm:"not null;index:idx_hold_queue" json:"isbn"`
+	LibraryID      uint       `gorm:"not null;index:idx_hold_queue" json:"library_id"`
+	ReaderID       uint       `gorm:"not null" json:"reader_id"`
+	Status         string     `gorm:"not null" json:"status"` // "Waiting", "Offered", "Fulfilled", "Cancelled", "Expired"
+	OfferedAt      *time.Time `json:"offered_at"`
+	OfferExpiresAt *time.Time `json:"offer_expires_at"`
+}
@@ -16,5 +16,8 @@ type IssueRegistry struct {
 	ExpectedReturnDate time.Time  `gorm:"not null" json:"expected_return_date"`
 	ReturnDate         *time.Time `json:"return_date"`
 	ReturnApproverID   *uint      `json:"return_approver_id"`
+	RenewalCount       int        `gorm:"not null;default:0" json:"renewal_count"`
 	LibraryID          uint       `gorm:"not null" json:"library_id"`
+	ItemID             *uint      `json:"item_id"`
+	Barcode            string     `json:"barcode"`
 }
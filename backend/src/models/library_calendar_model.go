@@ -0,0 +1,39 @@
+// /backend/src/models/library_calendar_model.go
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LibraryHoliday is a day on which the library is closed. Date is stored at
+// midnight UTC.
+type LibraryHoliday struct {
+	gorm.Model
+	LibraryID uint      `gorm:"not null;uniqueIndex:idx_library_holiday" json:"library_id"`
+	Date      time.Time `gorm:"not null;uniqueIndex:idx_library_holiday" json:"date"`
+	Name      string    `json:"name"`
+}
+
+// LibraryOpeningHours is the opening time of a library on one day of the week. Once
+// a library has any opening hours, weekdays without a row are closed; a library
+// without opening hours is open every day. Times are "HH:MM" in the library's local
+// time.
+type LibraryOpeningHours struct {
+	gorm.Model
+	LibraryID uint   `gorm:"not null;uniqueIndex:idx_library_weekday" json:"library_id"`
+	Weekday   int    `gorm:"not null;uniqueIndex:idx_library_weekday" json:"weekday"` // 0 = Sunday
+	Opens     string `gorm:"not null" json:"opens"`
+	Closes    string `gorm:"not null" json:"closes"`
+}
+
+// LibraryClosure is an exceptional closure, e.g. for repairs, from StartDate to
+// EndDate inclusive. Dates are stored at midnight UTC.
+type LibraryClosure struct {
+	gorm.Model
+	LibraryID uint      `gorm:"not null;index" json:"library_id"`
+	StartDate time.Time `gorm:"not null" json:"start_date"`
+	EndDate   time.Time `gorm:"not null" json:"end_date"`
+	Reason    string    `json:"reason"`
+}
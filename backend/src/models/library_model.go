@@ -5,7 +5,8 @@ import "gorm.io/gorm"
 
 type Library struct {
 	gorm.Model
-	Name  string          `gorm:"unique;not null"`
-	Users []User          `gorm:"not null"`
-	Books []BookInventory `gorm:"not null"`
+	Name        string          `gorm:"unique;not null"`
+	MaxRenewals int             `gorm:"not null;default:2"` // renewals allowed per issue
+	Users       []User          `gorm:"not null"`
+	Books       []BookInventory `gorm:"not null"`
 }
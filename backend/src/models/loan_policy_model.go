@@ -0,0 +1,21 @@
+// /backend/src/models/loan_policy_model.go
+package models
+
+import "gorm.io/gorm"
+
+// LoanPolicy is a circulation rule of a library. A rule applies to users with the
+// given Role and PatronCategory; an empty value matches everyone. Nil limits are not
+// overridden by the rule and fall back to less specific rules or the library defaults.
+type LoanPolicy struct {
+	gorm.Model
+	LibraryID           uint   `gorm:"not null;uniqueIndex:idx_loan_policy_scope" json:"library_id"`
+	Role                string `gorm:"not null;default:'';uniqueIndex:idx_loan_policy_scope" json:"role"`
+	PatronCategory      string `gorm:"not null;default:'';uniqueIndex:idx_loan_policy_scope" json:"patron_category"`
+	MaxActiveRequests   *int   `json:"max_active_requests,omitempty"`
+	LoanPeriodDays      *int   `json:"loan_period_days,omitempty"`
+	MaxRenewals         *int   `json:"max_renewals,omitempty"`
+	DailyRateCents      *int64 `json:"daily_rate_cents,omitempty"`
+	GracePeriodDays     *int   `json:"grace_period_days,omitempty"`
+	MaxFineCents        *int64 `json:"max_fine_cents,omitempty"`
+	BlockThresholdCents *int64 `json:"block_threshold_cents,omitempty"`
+}
@@ -3,6 +3,8 @@ package models
 
 import "time"
 
+// RequestEvent is a reader's request. RequestType is the kind of request ("Issue",
+// "Return" or "Renew"); Status is where it stands in its lifecycle.
 type RequestEvent struct {
 	ReqID        uint       `gorm:"primaryKey;autoIncrement" json:"req_id"`
 	BookID       string     `gorm:"not null" json:"book_id"`
@@ -11,4 +13,8 @@ type RequestEvent struct {
 	ApprovalDate *time.Time `json:"approval_date,omitempty"`
 	ApproverID   *uint      `json:"approver_id,omitempty"`
 	RequestType  string     `gorm:"not null" json:"request_type" binding:"required"`
+	Status       string     `gorm:"not null;default:Requested;index" json:"status"` // "Requested", "Approved", "Issued", "Returned", "Rejected", "Cancelled", "Expired"
+	IssueID      *uint      `json:"issue_id,omitempty"` // issue created for an issue request; the issue returned or renewed otherwise
+	ItemID       *uint      `json:"item_id,omitempty"`  // copy set aside when an issue request is approved
+	WorkID       *uint      `json:"work_id,omitempty"`  // work requested in any edition; BookID is the edition picked
 }
@@ -0,0 +1,16 @@
+// /backend/src/models/request_transition_model.go
+package models
+
+import "time"
+
+// RequestTransition is an append-only record of a request changing status.
+// FromStatus is empty for the transition that created the request.
+type RequestTransition struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	RequestID  uint      `gorm:"not null;index" json:"request_id"`
+	FromStatus string    `gorm:"not null" json:"from_status"`
+	ToStatus   string    `gorm:"not null" json:"to_status"`
+	ActorID    *uint     `json:"actor_id"` // nil for system changes
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `json:"created_at"`
+}
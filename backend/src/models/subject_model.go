@@ -0,0 +1,28 @@
+// /backend/src/models/subject_model.go
+package models
+
+import "gorm.io/gorm"
+
+// Subject is a node of a library's subject hierarchy ("Fiction" > "Science
+// fiction"). Path lists the IDs from the root down to the subject ("/1/5/"), so
+// that a subject and everything under it can be selected by prefix.
+type Subject struct {
+	gorm.Model
+	LibraryID uint   `gorm:"not null;index" json:"library_id"`
+	ParentID  *uint  `gorm:"index" json:"parent_id"`
+	Name      string `gorm:"not null" json:"name"`
+	Path      string `gorm:"not null;index" json:"path"`
+}
+
+// BookSubject files a book under a subject.
+type BookSubject struct {
+	BookInventoryID uint    `gorm:"primaryKey" json:"book_inventory_id"`
+	SubjectID       uint    `gorm:"primaryKey;index" json:"subject_id"`
+	Subject         Subject `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// BookTag is a free-form label on a book, stored in lower case.
+type BookTag struct {
+	BookInventoryID uint   `gorm:"primaryKey" json:"book_inventory_id"`
+	Tag             string `gorm:"primaryKey;index" json:"tag"`
+}
@@ -5,10 +5,11 @@ import "gorm.io/gorm"
 
 type User struct {
 	gorm.Model
-	Name          string `gorm:"not null"`
-	Email         string `gorm:"unique;not null"`
-	Password      string `gorm:"not null"` // stored as bcrypt hash
-	ContactNumber string `gorm:"not null"`
-	Role          string `gorm:"not null"` // "Owner", "LibraryAdmin", "Reader"
-	LibraryID     uint   `gorm:"not null"`
+	Name           string `gorm:"not null"`
+	Email          string `gorm:"unique;not null"`
+	Password       string `gorm:"not null"` // stored as bcrypt hash
+	ContactNumber  string `gorm:"not null"`
+	Role           string `gorm:"not null"` // "Owner", "LibraryAdmin", "Reader"
+	LibraryID      uint   `gorm:"not null"`
+	PatronCategory string // e.g. "Student" or "Faculty"; selects loan policy rules
 }
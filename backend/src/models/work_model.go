@@ -0,0 +1,14 @@
+// /backend/src/models/work_model.go
+package models
+
+import "gorm.io/gorm"
+
+// Work groups the editions of the same work in a library: printings, versions and
+// translations, each a BookInventory with its own ISBN. Readers may request any
+// edition of a work.
+type Work struct {
+	gorm.Model
+	LibraryID uint   `gorm:"not null;index" json:"library_id"`
+	Title     string `gorm:"not null" json:"title"`
+	Author    string `gorm:"not null" json:"author"`
+}
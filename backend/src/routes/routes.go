@@ -7,12 +7,15 @@ import (
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/swapxs/LibMS/backend/src/handlers"
+	"github.com/swapxs/LibMS/backend/src/metadata"
 	"github.com/swapxs/LibMS/backend/src/middleware"
+	"github.com/swapxs/LibMS/backend/src/storage"
 	"gorm.io/gorm"
 )
 
-// SetupRouter configures all routes and applies CORS.
-func SetupRouter(db *gorm.DB) *gin.Engine {
+// SetupRouter configures all routes and applies CORS. provider, which may be nil,
+// supplies book metadata by ISBN; store, which may be nil, keeps cover images.
+func SetupRouter(db *gorm.DB, provider metadata.Provider, store storage.Store) *gin.Engine {
 	r := gin.Default()
 
 	// Custom CORS configuration.
@@ -29,25 +32,83 @@ func SetupRouter(db *gorm.DB) *gin.Engine {
 	{
 		// Public endpoints.
 		api.GET("/libraries", handlers.GetLibraries(db))
+		api.GET("/libraries/:id/calendar", handlers.GetLibraryCalendar(db))
 		api.POST("/owner/registration", handlers.RegisterLibraryOwner(db))
 		api.POST("/auth/login", handlers.Login(db))
 		api.POST("/auth/register", handlers.RegisterUser(db))
 
 		// Protected endpoints.
 		protected := api.Group("/")
-		protected.Use(middleware.JWTAuthMiddleware())
+		protected.Use(middleware.JWTAuthMiddleware(db))
 		{
 			protected.POST("/library", handlers.CreateLibrary(db))
+			protected.PUT("/library/renewal-limit", handlers.UpdateRenewalLimit(db))
+			protected.GET("/library/holidays", handlers.GetHolidays(db))
+			protected.POST("/library/holidays", handlers.AddHoliday(db))
+			protected.DELETE("/library/holidays/:id", handlers.DeleteHoliday(db))
+			protected.PUT("/library/hours", handlers.UpdateOpeningHours(db))
+			protected.GET("/library/closures", handlers.GetClosures(db))
+			protected.POST("/library/closures", handlers.AddClosure(db))
+			protected.DELETE("/library/closures/:id", handlers.DeleteClosure(db))
 			protected.GET("/users", handlers.GetUsers(db))
+			protected.PUT("/users/:id/patron-category", handlers.UpdatePatronCategory(db))
+			protected.DELETE("/users/:id", handlers.DeleteUser(db))
 			protected.GET("/auth/userIssueInfo", handlers.GetUserIssueInfo(db))
+			// Author endpoints.
+			protected.GET("/authors", handlers.GetAuthors(db))
+			protected.GET("/authors/:id", handlers.GetAuthor(db))
+			protected.PUT("/authors/:id", handlers.UpdateAuthor(db))
+			protected.POST("/authors/:id/merge", handlers.MergeAuthors(db))
+			// Subject and tag endpoints.
+			protected.GET("/subjects", handlers.GetSubjects(db))
+			protected.POST("/subjects", handlers.CreateSubject(db))
+			protected.PUT("/subjects/:id", handlers.UpdateSubject(db))
+			protected.DELETE("/subjects/:id", handlers.DeleteSubject(db))
+			protected.GET("/tags", handlers.GetTags(db))
+			// Work endpoints.
+			works := protected.Group("/works")
+			{
+				works.GET("", handlers.GetWorks(db))
+				works.POST("", handlers.CreateWork(db))
+				works.GET("/:id", handlers.GetWork(db))
+				works.PUT("/:id", handlers.UpdateWork(db))
+				works.DELETE("/:id", handlers.DeleteWork(db))
+			}
 			// Book endpoints.
 			books := protected.Group("/books")
 			{
-				books.POST("", handlers.AddOrIncrementBook(db))
+				books.POST("", handlers.AddOrIncrementBook(db, provider))
+				books.POST("/import", handlers.ImportBooks(db))
+				books.POST("/import/marc", handlers.ImportMARC(db))
+				books.GET("/export", handlers.ExportBooks(db))
+				books.GET("/export/marc", handlers.ExportMARC(db))
 				books.GET("", handlers.GetBooks(db))
+				books.GET("/search", handlers.SearchBooks(db))
+				books.GET("/suggest", handlers.SuggestBooks(db))
+				books.GET("/shelflist", handlers.GetShelfList(db))
+				books.GET("/metadata/:isbn", handlers.LookupBookMetadata(provider))
 				books.POST("/remove", handlers.RemoveBook(db))
 				books.PUT("/:isbn", handlers.UpdateBook(db))
+				books.GET("/:isbn/revisions", handlers.GetBookRevisions(db))
+				books.POST("/:isbn/revisions/:id/revert", handlers.RevertBookRevision(db))
+				books.GET("/:isbn/items", handlers.GetBookItems(db))
+				books.GET("/:isbn/classification", handlers.GetBookClassification(db))
+				books.PUT("/:isbn/classification", handlers.SetBookClassification(db))
+				books.GET("/:isbn/cover", handlers.GetBookCover(db, store))
+				books.PUT("/:isbn/cover", handlers.UploadBookCover(db, store))
+				books.DELETE("/:isbn/cover", handlers.DeleteBookCover(db, store))
 			}
+			// Trash endpoints.
+			trash := protected.Group("/trash")
+			{
+				trash.GET("", handlers.GetTrash(db))
+				trash.POST("/books/:isbn/restore", handlers.RestoreBook(db))
+				trash.DELETE("/books/:isbn", handlers.PurgeBook(db, store))
+				trash.POST("/users/:id/restore", handlers.RestoreUser(db))
+				trash.DELETE("/users/:id", handlers.PurgeUser(db))
+			}
+			// Copy endpoints.
+			protected.PUT("/items/:barcode", handlers.UpdateItem(db))
 			// Owner endpoints.
 			owner := protected.Group("/owner")
 			{
@@ -56,6 +117,8 @@ func SetupRouter(db *gorm.DB) *gin.Engine {
 			}
 			// Request events.
 			protected.POST("/requestEvents", handlers.RaiseRequest(db))
+			protected.GET("/requestEvents/:id/history", handlers.GetRequestHistory(db))
+			protected.POST("/requestEvents/:id/cancel", handlers.CancelRequest(db))
 			// Issue Request endpoints.
 			issue := protected.Group("/issueRequests")
 			{
@@ -63,8 +126,40 @@ func SetupRouter(db *gorm.DB) *gin.Engine {
 				issue.GET("", handlers.GetIssueRequests(db))
 				issue.PUT("/:id", handlers.UpdateIssueRequestStatus(db))
 			}
-			// Issue Registry endpoint.
+			// Hold endpoints.
+			holds := protected.Group("/holds")
+			{
+				holds.POST("", handlers.PlaceHold(db))
+				holds.GET("", handlers.GetHolds(db))
+				holds.DELETE("/:id", handlers.CancelHold(db))
+				holds.POST("/expire", handlers.ExpireHoldOffers(db))
+			}
+			// Fine endpoints.
+			fines := protected.Group("/fines")
+			{
+				fines.GET("", handlers.GetFines(db))
+				fines.GET("/balances", handlers.GetFineBalances(db))
+				fines.GET("/policy", handlers.GetFinePolicy(db))
+				fines.PUT("/policy", handlers.UpdateFinePolicy(db))
+				fines.POST("/:id/pay", handlers.PayFine(db))
+				fines.POST("/:id/waive", handlers.WaiveFine(db))
+				fines.POST("/:id/adjust", handlers.AdjustFine(db))
+			}
+			// Loan policy endpoints.
+			loanPolicies := protected.Group("/loanPolicies")
+			{
+				loanPolicies.GET("", handlers.GetLoanPolicies(db))
+				loanPolicies.POST("", handlers.CreateLoanPolicy(db))
+				loanPolicies.GET("/effective", handlers.GetEffectiveLoanPolicy(db))
+				loanPolicies.PUT("/:id", handlers.UpdateLoanPolicy(db))
+				loanPolicies.DELETE("/:id", handlers.DeleteLoanPolicy(db))
+			}
+			// Issue Registry endpoints.
 			protected.POST("/issueRegistry", handlers.IssueBook(db))
+			protected.POST("/issueRegistry/return", handlers.RaiseReturnRequest(db))
+			protected.POST("/issueRegistry/:id/renew", handlers.RenewIssue(db))
+			// Return Request endpoints.
+			protected.PUT("/returnRequests/:id", handlers.UpdateReturnRequestStatus(db))
 		}
 	}
 
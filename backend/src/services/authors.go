@@ -0,0 +1,316 @@
+// /backend/src/services/authors.go
+package services
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/swapxs/LibMS/backend/src/models"
+	"gorm.io/gorm"
+)
+
+// Roles an author can be credited in.
+const (
+	RoleAuthor     = "author"
+	RoleEditor     = "editor"
+	RoleTranslator = "translator"
+)
+
+var (
+	ErrInvalidCredit   = errors.New("each author needs a name and a role of author, editor or translator")
+	ErrAuthorNameTaken = errors.New("name is already used by another author")
+)
+
+// AuthorCredit names an author of a book and the role they had. An empty Role
+// means RoleAuthor.
+type AuthorCredit struct {
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+// authorSeparators split a free-text author field into names. Commas are not
+// separators: they are part of inverted names ("Bloch, Joshua").
+var authorSeparators = regexp.MustCompile(`(?i)\s*(?:;|\s&\s|\sand\s)\s*`)
+
+// SplitAuthorNames splits a free-text author field ("Kernighan & Ritchie") into the
+// names it credits.
+func SplitAuthorNames(s string) []string {
+	var names []string
+	for _, name := range authorSeparators.Split(s, -1) {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// AuthorKey normalizes a name for matching: case, spacing and trailing
+// punctuation are ignored.
+func AuthorKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(strings.TrimRight(strings.TrimSpace(name), ".,;")), " "))
+}
+
+// CreditsFromAuthorField turns a free-text author field into credits in the author
+// role.
+func CreditsFromAuthorField(s string) []AuthorCredit {
+	var credits []AuthorCredit
+	for _, name := range SplitAuthorNames(s) {
+		credits = append(credits, AuthorCredit{Name: name, Role: RoleAuthor})
+	}
+	return credits
+}
+
+// NormalizeCredits checks credits and fills in the default role.
+func NormalizeCredits(credits []AuthorCredit) ([]AuthorCredit, error) {
+	out := make([]AuthorCredit, 0, len(credits))
+	for _, credit := range credits {
+		credit.Name = strings.TrimSpace(credit.Name)
+		credit.Role = strings.ToLower(strings.TrimSpace(credit.Role))
+		if credit.Role == "" {
+			credit.Role = RoleAuthor
+		}
+		if AuthorKey(credit.Name) == "" || (credit.Role != RoleAuthor && credit.Role != RoleEditor && credit.Role != RoleTranslator) {
+			return nil, ErrInvalidCredit
+		}
+		out = append(out, credit)
+	}
+	return out, nil
+}
+
+// AuthorDisplay is the author field of a book with the given credits: the names in
+// the author role, or every name when nobody has it (an edited volume), separated
+// by semicolons.
+func AuthorDisplay(credits []AuthorCredit) string {
+	var authors, all []string
+	for _, credit := range credits {
+		if credit.Role == RoleAuthor {
+			authors = append(authors, credit.Name)
+		}
+		all = append(all, credit.Name)
+	}
+	if len(authors) == 0 {
+		authors = all
+	}
+	return strings.Join(authors, "; ")
+}
+
+// findAuthor returns the author of the library whose name or variant has the given
+// key, or gorm.ErrRecordNotFound.
+func findAuthor(tx *gorm.DB, libraryID uint, key string) (models.Author, error) {
+	var author models.Author
+	err := tx.Where("library_id = ? AND name_key = ?", libraryID, key).First(&author).Error
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return author, err
+	}
+	var variant models.AuthorNameVariant
+	if err := tx.Where("library_id = ? AND name_key = ?", libraryID, key).First(&variant).Error; err != nil {
+		return author, err
+	}
+	return author, tx.First(&author, variant.AuthorID).Error
+}
+
+// ResolveAuthor returns the author of the library known by name, as their name or
+// one of its variants, creating them if there is none.
+func ResolveAuthor(tx *gorm.DB, libraryID uint, name string) (models.Author, error) {
+	key := AuthorKey(name)
+	author, err := findAuthor(tx, libraryID, key)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		author = models.Author{LibraryID: libraryID, Name: strings.TrimSpace(name), NameKey: key}
+		err = tx.Create(&author).Error
+	}
+	return author, err
+}
+
+// LinkBookAuthors replaces the credits of a book. The book's author field is left
+// as it is; see SetBookAuthors.
+func LinkBookAuthors(tx *gorm.DB, book *models.BookInventory, credits []AuthorCredit) error {
+	credits, err := NormalizeCredits(credits)
+	if err != nil {
+		return err
+	}
+	if err := tx.Where("book_inventory_id = ?", book.ID).Delete(&models.BookAuthor{}).Error; err != nil {
+		return err
+	}
+	type credited struct {
+		authorID uint
+		role     string
+	}
+	seen := map[credited]bool{}
+	for _, credit := range credits {
+		author, err := ResolveAuthor(tx, book.LibraryID, credit.Name)
+		if err != nil {
+			return err
+		}
+		// The same person may be named twice, under variants of their name.
+		if seen[credited{author.ID, credit.Role}] {
+			continue
+		}
+		seen[credited{author.ID, credit.Role}] = true
+		link := models.BookAuthor{BookInventoryID: book.ID, AuthorID: author.ID, Role: credit.Role, Position: len(seen)}
+		if err := tx.Create(&link).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetBookAuthors replaces the credits of a book and sets its author field to match.
+func SetBookAuthors(tx *gorm.DB, book *models.BookInventory, credits []AuthorCredit) error {
+	credits, err := NormalizeCredits(credits)
+	if err != nil {
+		return err
+	}
+	if err := LinkBookAuthors(tx, book, credits); err != nil {
+		return err
+	}
+	book.Author = AuthorDisplay(credits)
+	return tx.Model(&models.BookInventory{}).Where("id = ?", book.ID).Update("author", book.Author).Error
+}
+
+// BookCredits returns the credits of a book in order, with their authors.
+func BookCredits(tx *gorm.DB, bookID uint) ([]models.BookAuthor, error) {
+	var links []models.BookAuthor
+	err := tx.Preload("Author").Where("book_inventory_id = ?", bookID).Order("position ASC").Find(&links).Error
+	return links, err
+}
+
+// refreshAuthorFields rewrites the author field of every book crediting one of the
+// given authors, after their names changed.
+func refreshAuthorFields(tx *gorm.DB, authorIDs ...uint) error {
+	var bookIDs []uint
+	if err := tx.Model(&models.BookAuthor{}).Where("author_id IN ?", authorIDs).Distinct().Pluck("book_inventory_id", &bookIDs).Error; err != nil {
+		return err
+	}
+	for _, bookID := range bookIDs {
+		links, err := BookCredits(tx, bookID)
+		if err != nil {
+			return err
+		}
+		credits := make([]AuthorCredit, len(links))
+		for i, link := range links {
+			credits[i] = AuthorCredit{Name: link.Author.Name, Role: link.Role}
+		}
+		if err := tx.Model(&models.BookInventory{}).Where("id = ?", bookID).Update("author", AuthorDisplay(credits)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nameTaken reports whether key is the name or a variant of an author of the
+// library other than authorID.
+func nameTaken(tx *gorm.DB, libraryID, authorID uint, key string) (bool, error) {
+	author, err := findAuthor(tx, libraryID, key)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	return err == nil && author.ID != authorID, err
+}
+
+// UpdateAuthor renames an author and replaces their name variants. Names already
+// used by another author of the library give ErrAuthorNameTaken. The author field
+// of their books follows the new name.
+func UpdateAuthor(tx *gorm.DB, author *models.Author, name string, variants []string) error {
+	key := AuthorKey(name)
+	if key == "" {
+		return ErrInvalidCredit
+	}
+	if taken, err := nameTaken(tx, author.LibraryID, author.ID, key); err != nil || taken {
+		if taken {
+			err = ErrAuthorNameTaken
+		}
+		return err
+	}
+	if err := tx.Where("author_id = ?", author.ID).Delete(&models.AuthorNameVariant{}).Error; err != nil {
+		return err
+	}
+	author.Name, author.NameKey = strings.TrimSpace(name), key
+	if err := tx.Model(author).Updates(map[string]interface{}{"name": author.Name, "name_key": key}).Error; err != nil {
+		return err
+	}
+
+	author.Variants = nil
+	seen := map[string]bool{key: true}
+	for _, variant := range variants {
+		vkey := AuthorKey(variant)
+		if vkey == "" || seen[vkey] {
+			continue
+		}
+		seen[vkey] = true
+		if taken, err := nameTaken(tx, author.LibraryID, author.ID, vkey); err != nil || taken {
+			if taken {
+				err = ErrAuthorNameTaken
+			}
+			return err
+		}
+		v := models.AuthorNameVariant{AuthorID: author.ID, LibraryID: author.LibraryID, Name: strings.TrimSpace(variant), NameKey: vkey}
+		if err := tx.Create(&v).Error; err != nil {
+			return err
+		}
+		author.Variants = append(author.Variants, v)
+	}
+	return refreshAuthorFields(tx, author.ID)
+}
+
+// MergeAuthors folds from into into: the books crediting from credit into instead,
+// and the name and variants of from become variants of into. from is deleted.
+func MergeAuthors(tx *gorm.DB, into *models.Author, from models.Author) error {
+	var links []models.BookAuthor
+	if err := tx.Where("author_id = ?", from.ID).Find(&links).Error; err != nil {
+		return err
+	}
+	for _, link := range links {
+		// A book crediting both in the same role keeps the credit it had for into.
+		var count int64
+		if err := tx.Model(&models.BookAuthor{}).Where("book_inventory_id = ? AND author_id = ? AND role = ?", link.BookInventoryID, into.ID, link.Role).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			if err := tx.Create(&models.BookAuthor{BookInventoryID: link.BookInventoryID, AuthorID: into.ID, Role: link.Role, Position: link.Position}).Error; err != nil {
+				return err
+			}
+		}
+	}
+	if err := tx.Where("author_id = ?", from.ID).Delete(&models.BookAuthor{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.AuthorNameVariant{}).Where("author_id = ?", from.ID).Update("author_id", into.ID).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Delete(&models.Author{}, from.ID).Error; err != nil {
+		return err
+	}
+	if err := tx.Create(&models.AuthorNameVariant{AuthorID: into.ID, LibraryID: into.LibraryID, Name: from.Name, NameKey: from.NameKey}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("author_id = ?", into.ID).Order("name ASC").Find(&into.Variants).Error; err != nil {
+		return err
+	}
+	return refreshAuthorFields(tx, into.ID)
+}
+
+// BackfillBookAuthors links the books that have no credits yet to authors parsed
+// from their author field, leaving the field itself unchanged. It returns the
+// number of books linked.
+func BackfillBookAuthors(db *gorm.DB) (int, error) {
+	var books []models.BookInventory
+	err := db.Where("author <> '' AND id NOT IN (?)", db.Model(&models.BookAuthor{}).Select("book_inventory_id")).
+		Find(&books).Error
+	if err != nil {
+		return 0, err
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for i := range books {
+			if err := LinkBookAuthors(tx, &books[i], CreditsFromAuthorField(books[i].Author)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(books), nil
+}
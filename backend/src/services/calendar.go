@@ -0,0 +1,116 @@
+// /backend/src/services/calendar.go
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/swapxs/LibMS/backend/src/models"
+	"gorm.io/gorm"
+)
+
+// maxClosedDays bounds the search for the next open day.
+const maxClosedDays = 366
+
+// ErrLibraryAlwaysClosed is returned when no open day can be found within a year.
+var ErrLibraryAlwaysClosed = errors.New("library has no open day within a year")
+
+// CalendarDate returns the calendar day of t as midnight UTC, the form in which
+// holidays and closures are stored.
+func CalendarDate(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// Calendar tells which days a library is open within the range it was loaded for.
+type Calendar struct {
+	openWeekdays map[time.Weekday]bool // nil when the library has no opening hours
+	holidays     map[time.Time]bool
+	closures     []models.LibraryClosure
+}
+
+// LoadCalendar loads the opening hours of a library and its holidays and closures
+// between from and to.
+func LoadCalendar(db *gorm.DB, libraryID uint, from, to time.Time) (*Calendar, error) {
+	cal := &Calendar{holidays: map[time.Time]bool{}}
+
+	var hours []models.LibraryOpeningHours
+	if err := db.Where("library_id = ?", libraryID).Find(&hours).Error; err != nil {
+		return nil, err
+	}
+	if len(hours) > 0 {
+		cal.openWeekdays = make(map[time.Weekday]bool, len(hours))
+		for _, h := range hours {
+			cal.openWeekdays[time.Weekday(h.Weekday)] = true
+		}
+	}
+
+	var holidays []models.LibraryHoliday
+	if err := db.Where("library_id = ? AND date >= ? AND date <= ?", libraryID, CalendarDate(from), CalendarDate(to)).
+		Find(&holidays).Error; err != nil {
+		return nil, err
+	}
+	for _, h := range holidays {
+		cal.holidays[CalendarDate(h.Date.UTC())] = true
+	}
+
+	if err := db.Where("library_id = ? AND start_date <= ? AND end_date >= ?", libraryID, CalendarDate(to), CalendarDate(from)).
+		Find(&cal.closures).Error; err != nil {
+		return nil, err
+	}
+	return cal, nil
+}
+
+// IsOpen reports whether the library is open on the calendar day of t. A nil
+// calendar is open every day.
+func (c *Calendar) IsOpen(t time.Time) bool {
+	if c == nil {
+		return true
+	}
+	day := CalendarDate(t)
+	if c.openWeekdays != nil && !c.openWeekdays[day.Weekday()] {
+		return false
+	}
+	if c.holidays[day] {
+		return false
+	}
+	for _, closure := range c.closures {
+		if !day.Before(CalendarDate(closure.StartDate.UTC())) && !day.After(CalendarDate(closure.EndDate.UTC())) {
+			return false
+		}
+	}
+	return true
+}
+
+// OpenDaysAfter counts the open days among the n days following start.
+func (c *Calendar) OpenDaysAfter(start time.Time, n int) int {
+	open := 0
+	for i := 1; i <= n; i++ {
+		if c.IsOpen(start.AddDate(0, 0, i)) {
+			open++
+		}
+	}
+	return open
+}
+
+// NextOpenDay moves t forward, a day at a time, until it falls on a day the library
+// is open. The time of day is kept.
+func NextOpenDay(db *gorm.DB, libraryID uint, t time.Time) (time.Time, error) {
+	cal, err := LoadCalendar(db, libraryID, t, t.AddDate(0, 0, maxClosedDays))
+	if err != nil {
+		return t, err
+	}
+	for i := 0; i <= maxClosedDays; i++ {
+		if cal.IsOpen(t) {
+			return t, nil
+		}
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, ErrLibraryAlwaysClosed
+}
+
+// DueDate returns the due date of a loan starting at from: one loan period later,
+// moved to the next day the library is open.
+func DueDate(db *gorm.DB, libraryID uint, from time.Time, period time.Duration) (time.Time, error) {
+	return NextOpenDay(db, libraryID, from.Add(period))
+}
@@ -0,0 +1,347 @@
+// /backend/src/services/catalog.go
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/swapxs/LibMS/backend/src/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Catalog search limits.
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
+var (
+	// ErrInvalidCursor is returned when a search cursor cannot be decoded or does not
+	// belong to the requested sort.
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrInvalidSort is returned for an unknown sort field or order.
+	ErrInvalidSort = errors.New("invalid sort")
+)
+
+// searchSortColumns maps the sort fields of a catalog search to their columns.
+// "relevance" ranks by the keyword match and "newest" by insertion order.
+var searchSortColumns = map[string]string{
+	"title":       "title",
+	"author":      "author",
+	"publisher":   "publisher",
+	"available":   "available_copies",
+	"newest":      "id",
+	"call_number": "call_number_sort_key",
+}
+
+// BookSearch is a catalog search within one library.
+type BookSearch struct {
+	LibraryID uint
+	Query     string // keywords matched against title, author and publisher
+	Language  string
+	Publisher string
+	AuthorID  uint   // only books crediting this author, in any role
+	SubjectID uint   // only books filed under this subject or a subject below it
+	Tag       string // only books with this tag
+	WorkID    uint   // only editions of this work
+	Available *bool  // only books with (true) or without (false) available copies
+	Sort      string // "relevance" (default with a query), "title" (default otherwise), "author", "publisher", "available", "newest", "call_number"
+	Order     string // "asc" or "desc"; "newest" and "relevance" default to "desc"
+	Limit     int
+	Cursor    string // NextCursor of the previous page
+}
+
+// BookSearchResult is one page of a catalog search.
+type BookSearchResult struct {
+	Books      []models.BookInventory `json:"books"`
+	Total      int64                  `json:"total"`
+	NextCursor string                 `json:"next_cursor,omitempty"`
+}
+
+// searchCursor marks where the previous page ended: pages resume after the last
+// row's sort value, its rank for relevance sorts, and ID.
+type searchCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v,omitempty"`
+	ID    uint   `json:"id,omitempty"`
+}
+
+func encodeCursor(c searchCursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(s string) (searchCursor, error) {
+	var c searchCursor
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// searchTerms splits a keyword query into lower-case words of letters and digits.
+func searchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+func isPostgres(db *gorm.DB) bool {
+	return db.Dialector.Name() == "postgres"
+}
+
+// bookSearchVector is the weighted document searched on Postgres. It must match the
+// generated search_vector column created by EnsureCatalogSearch.
+const bookSearchVector = `setweight(to_tsvector('simple', coalesce(title, '')), 'A') ||
+	setweight(to_tsvector('simple', coalesce(author, '')), 'B') ||
+	setweight(to_tsvector('simple', coalesce(publisher, '')), 'C')`
+
+// EnsureCatalogSearch adds the full-text search column and its GIN index on Postgres.
+// Other databases search with LIKE and need nothing.
+func EnsureCatalogSearch(db *gorm.DB) error {
+	if !isPostgres(db) {
+		return nil
+	}
+	if err := db.Exec(`ALTER TABLE book_inventories ADD COLUMN IF NOT EXISTS search_vector tsvector
+		GENERATED ALWAYS AS (` + bookSearchVector + `) STORED`).Error; err != nil {
+		return err
+	}
+	return db.Exec(`CREATE INDEX IF NOT EXISTS idx_book_inventories_search
+		ON book_inventories USING GIN (search_vector)`).Error
+}
+
+// matchKeywords restricts a query to books matching every search term and returns
+// the expression ranking the matches, best first.
+func matchKeywords(db *gorm.DB, query *gorm.DB, terms []string) (*gorm.DB, string, []interface{}) {
+	if isPostgres(db) {
+		// Every term must match, as a prefix so that partial words still find books.
+		prefixes := make([]string, len(terms))
+		for i, term := range terms {
+			prefixes[i] = term + ":*"
+		}
+		tsquery := strings.Join(prefixes, " & ")
+		query = query.Where("search_vector @@ to_tsquery('simple', ?)", tsquery)
+		return query, "ts_rank(search_vector, to_tsquery('simple', ?))", []interface{}{tsquery}
+	}
+
+	// SQLite: substring matches, weighted like the Postgres document.
+	var rank []string
+	var args []interface{}
+	for _, term := range terms {
+		pattern := "%" + term + "%"
+		query = query.Where("(LOWER(title) LIKE ? OR LOWER(author) LIKE ? OR LOWER(publisher) LIKE ?)", pattern, pattern, pattern)
+		rank = append(rank, "(CASE WHEN LOWER(title) LIKE ? THEN 3 ELSE 0 END + CASE WHEN LOWER(author) LIKE ? THEN 2 ELSE 0 END + CASE WHEN LOWER(publisher) LIKE ? THEN 1 ELSE 0 END)")
+		args = append(args, pattern, pattern, pattern)
+	}
+	return query, strings.Join(rank, " + "), args
+}
+
+// catalogQuery restricts the catalog to the books matching the filters and keywords
+// of a search. With keywords it also returns the expression ranking the matches.
+func catalogQuery(db *gorm.DB, s BookSearch, terms []string) (*gorm.DB, string, []interface{}) {
+	query := db.Model(&models.BookInventory{}).Where("library_id = ?", s.LibraryID)
+	if s.Language != "" {
+		query = query.Where("LOWER(language) = ?", strings.ToLower(s.Language))
+	}
+	if s.Publisher != "" {
+		query = query.Where("LOWER(publisher) = ?", strings.ToLower(s.Publisher))
+	}
+	if s.AuthorID != 0 {
+		query = query.Where("id IN (?)", db.Model(&models.BookAuthor{}).Select("book_inventory_id").Where("author_id = ?", s.AuthorID))
+	}
+	if s.SubjectID != 0 {
+		subjects := db.Model(&models.Subject{}).Select("id").
+			Where("path LIKE (?)", db.Model(&models.Subject{}).Select("path || '%'").Where("id = ?", s.SubjectID))
+		query = query.Where("id IN (?)", db.Model(&models.BookSubject{}).Select("book_inventory_id").Where("subject_id IN (?)", subjects))
+	}
+	if s.Tag != "" {
+		tag, _ := NormalizeTag(s.Tag)
+		query = query.Where("id IN (?)", db.Model(&models.BookTag{}).Select("book_inventory_id").Where("tag = ?", tag))
+	}
+	if s.WorkID != 0 {
+		query = query.Where("work_id = ?", s.WorkID)
+	}
+	if s.Available != nil {
+		if *s.Available {
+			query = query.Where("available_copies > 0")
+		} else {
+			query = query.Where("available_copies = 0")
+		}
+	}
+	if len(terms) == 0 {
+		return query, "", nil
+	}
+	return matchKeywords(db, query, terms)
+}
+
+// ListBooks returns every book matching the filters and keywords of a search, in
+// insertion order. Sort, Order, Limit and Cursor are ignored.
+func ListBooks(db *gorm.DB, s BookSearch) ([]models.BookInventory, error) {
+	query, _, _ := catalogQuery(db, s, searchTerms(s.Query))
+	var books []models.BookInventory
+	err := query.Order("id ASC").Find(&books).Error
+	return books, err
+}
+
+// EachBook calls fn for every book matching the filters and keywords of a search, in
+// insertion order. Books are read batchSize at a time, so the catalog is never held
+// in memory as a whole. Sort, Order, Limit and Cursor are ignored.
+func EachBook(db *gorm.DB, s BookSearch, batchSize int, fn func(models.BookInventory) error) error {
+	query, _, _ := catalogQuery(db, s, searchTerms(s.Query))
+	var books []models.BookInventory
+	return query.FindInBatches(&books, batchSize, func(tx *gorm.DB, batch int) error {
+		for _, book := range books {
+			if err := fn(book); err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+}
+
+// SearchBooks runs a catalog search and returns one page of results together with
+// the total number of matches.
+func SearchBooks(db *gorm.DB, s BookSearch) (BookSearchResult, error) {
+	result := BookSearchResult{Books: []models.BookInventory{}}
+	terms := searchTerms(s.Query)
+
+	sort := s.Sort
+	if sort == "" {
+		sort = "title"
+		if len(terms) > 0 {
+			sort = "relevance"
+		}
+	}
+	if sort == "relevance" && len(terms) == 0 {
+		sort = "title"
+	}
+	column, ok := searchSortColumns[sort]
+	if !ok && sort != "relevance" {
+		return result, fmt.Errorf("%w: unknown sort field %q", ErrInvalidSort, s.Sort)
+	}
+	order := strings.ToLower(s.Order)
+	switch order {
+	case "":
+		order = "asc"
+		if sort == "newest" || sort == "relevance" {
+			order = "desc"
+		}
+	case "asc", "desc":
+	default:
+		return result, fmt.Errorf("%w: order must be asc or desc", ErrInvalidSort)
+	}
+	limit := s.Limit
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	if limit > MaxSearchLimit {
+		limit = MaxSearchLimit
+	}
+
+	query, rank, rankArgs := catalogQuery(db, s, terms)
+
+	if err := query.Session(&gorm.Session{}).Count(&result.Total).Error; err != nil {
+		return result, err
+	}
+
+	var cursor searchCursor
+	if s.Cursor != "" {
+		var err error
+		if cursor, err = decodeCursor(s.Cursor); err != nil {
+			return result, err
+		}
+		if cursor.Sort != sort+" "+order {
+			return result, ErrInvalidCursor
+		}
+	}
+
+	page := query.Session(&gorm.Session{}).Limit(limit + 1)
+	if sort == "relevance" {
+		if s.Cursor != "" {
+			value, err := strconv.ParseFloat(cursor.Value, 64)
+			if err != nil {
+				return result, ErrInvalidCursor
+			}
+			cmp := "<"
+			if order == "asc" {
+				cmp = ">"
+			}
+			// Ties in rank are ordered by ID ascending in either direction.
+			args := append(append(append([]interface{}{}, rankArgs...), value), rankArgs...)
+			page = page.Where(fmt.Sprintf("((%s %s ?) OR (%s = ? AND id > ?))", rank, cmp, rank),
+				append(args, value, cursor.ID)...)
+		}
+		page = page.Order(clause.OrderBy{Expression: clause.Expr{
+			SQL: rank + " " + strings.ToUpper(order) + ", id ASC", Vars: rankArgs, WithoutParentheses: true,
+		}})
+	} else {
+		cmp := ">"
+		if order == "desc" {
+			cmp = "<"
+		}
+		expr := column
+		if column == "call_number_sort_key" {
+			expr = callNumberOrder(db)
+		}
+		if s.Cursor != "" {
+			var value interface{} = cursor.Value
+			if column == "available_copies" || column == "id" {
+				var n int64
+				if _, err := fmt.Sscan(cursor.Value, &n); err != nil {
+					return result, ErrInvalidCursor
+				}
+				value = n
+			}
+			page = page.Where(fmt.Sprintf("((%s %s ?) OR (%s = ? AND id %s ?))", expr, cmp, expr, cmp),
+				value, value, cursor.ID)
+		}
+		page = page.Order(expr + " " + order)
+		if column != "id" {
+			page = page.Order("id " + order)
+		}
+	}
+	if err := page.Find(&result.Books).Error; err != nil {
+		return result, err
+	}
+
+	if len(result.Books) > limit {
+		result.Books = result.Books[:limit]
+		last := result.Books[limit-1]
+		next := searchCursor{Sort: sort + " " + order, ID: last.ID}
+		switch column {
+		case "title":
+			next.Value = last.Title
+		case "author":
+			next.Value = last.Author
+		case "publisher":
+			next.Value = last.Publisher
+		case "available_copies":
+			next.Value = fmt.Sprint(last.AvailableCopies)
+		case "call_number_sort_key":
+			next.Value = last.CallNumberSortKey
+		case "id":
+			next.Value = fmt.Sprint(last.ID)
+		}
+		if sort == "relevance" {
+			// The rank is not a column; read it back for the last row.
+			var value float64
+			if err := db.Model(&models.BookInventory{}).Select(rank, rankArgs...).
+				Where("id = ?", last.ID).Scan(&value).Error; err != nil {
+				return result, err
+			}
+			next.Value = strconv.FormatFloat(value, 'g', -1, 64)
+		}
+		result.NextCursor = encodeCursor(next)
+	}
+	return result, nil
+}
@@ -0,0 +1,498 @@
+// /backend/src/services/circulation.go
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/swapxs/LibMS/backend/src/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrRequestNotFound is returned when a request does not exist or is of the wrong kind.
+	ErrRequestNotFound = errors.New("request not found")
+	// ErrRequestNotPending is returned when a request was already approved or rejected.
+	ErrRequestNotPending = errors.New("request has already been processed")
+	// ErrBookNotFound is returned when the requested title is not in the library.
+	ErrBookNotFound = errors.New("book not found")
+	// ErrIssueNotFound is returned when a return request points at a missing issue.
+	ErrIssueNotFound = errors.New("issue record not found")
+	// ErrAlreadyReturned is returned when the issue was closed already.
+	ErrAlreadyReturned = errors.New("book already returned")
+	// ErrItemOnLoan is returned when a copy is already tied to another open issue.
+	ErrItemOnLoan = errors.New("copy is already issued to another reader")
+	// ErrInvalidDueDate is returned when a requested due date is in the past or
+	// beyond the reader's loan period.
+	ErrInvalidDueDate = errors.New("due date must be in the future and within the loan period")
+)
+
+// How long issue requests wait before they expire: pending requests not decided in
+// time, and approved requests whose copy is not collected.
+const (
+	RequestPendingWindow = 14 * 24 * time.Hour
+	RequestPickupWindow  = 3 * 24 * time.Hour
+)
+
+// lockForUpdate adds SELECT ... FOR UPDATE to a query. On Postgres this locks the
+// selected rows until the transaction ends; the SQLite driver drops the clause, as
+// SQLite already serializes write transactions.
+func lockForUpdate(db *gorm.DB) *gorm.DB {
+	return db.Clauses(clause.Locking{Strength: "UPDATE"})
+}
+
+// libraryReaders selects the IDs of a library's users, to keep requests, which carry
+// no library of their own, to the library of their reader.
+func libraryReaders(tx *gorm.DB, libraryID uint) *gorm.DB {
+	return tx.Model(&models.User{}).Select("id").Where("library_id = ?", libraryID)
+}
+
+// IssueDecision is an admin's decision on a pending issue request.
+type IssueDecision struct {
+	RequestID          uint
+	ApproverID         uint
+	LibraryID          uint
+	Approve            bool
+	Barcode            string     // copy to issue; any available copy when empty
+	ExpectedReturnDate *time.Time // due date; computed from the loan policy when nil
+	Reason             string     // recorded in the request's history
+}
+
+// DecideIssueRequest approves or rejects a pending issue request in one transaction.
+// Approval issues a copy to the reader right away: the issue registry row is created
+// with a due date one loan period ahead, moved past days the library is closed, and
+// the request goes through Approved to Issued. If anything fails no copy is taken and
+// the request stays pending. A request can only be decided once, even by concurrent
+// admins.
+func DecideIssueRequest(db *gorm.DB, d IssueDecision) (models.RequestEvent, error) {
+	var req models.RequestEvent
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := lockForUpdate(tx).Where("req_id = ?", d.RequestID).
+			Where("reader_id IN (?)", libraryReaders(tx, d.LibraryID)).First(&req).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRequestNotFound
+			}
+			return err
+		}
+		if req.RequestType != KindIssue {
+			return ErrRequestNotFound
+		}
+		if req.Status != StatusRequested {
+			return ErrRequestNotPending
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{
+			"approval_date": now,
+			"approver_id":   d.ApproverID,
+		}
+		if !d.Approve {
+			return TransitionRequest(tx, &req, StatusRejected, &d.ApproverID, d.Reason, updates)
+		}
+
+		due, err := approvalDueDate(tx, req, d, now)
+		if err != nil {
+			return err
+		}
+		var book models.BookInventory
+		if err := lockForUpdate(tx).Where("isbn = ? AND library_id = ?", req.BookID, d.LibraryID).
+			First(&book).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrBookNotFound
+			}
+			return err
+		}
+		item, err := CheckoutItem(tx, &book, d.Barcode)
+		if err != nil {
+			return err
+		}
+		updates["item_id"] = item.ID
+
+		// The conditional status update is the final guard against a concurrent decision.
+		if err := TransitionRequest(tx, &req, StatusApproved, &d.ApproverID, d.Reason, updates); err != nil {
+			return err
+		}
+		issue := models.IssueRegistry{
+			ISBN:               book.ISBN,
+			ReaderID:           req.ReaderID,
+			IssueApproverID:    d.ApproverID,
+			IssueStatus:        "Issued",
+			IssueDate:          now,
+			ExpectedReturnDate: due,
+			LibraryID:          d.LibraryID,
+			ItemID:             &item.ID,
+			Barcode:            item.Barcode,
+		}
+		if err := tx.Create(&issue).Error; err != nil {
+			return err
+		}
+		return TransitionRequest(tx, &req, StatusIssued, &d.ApproverID, "Book issued",
+			map[string]interface{}{"issue_id": issue.ID})
+	})
+	return req, err
+}
+
+// approvalDueDate returns the due date for an approved request: the admin's date if
+// given, otherwise one loan period from now, moved to a day the library is open.
+func approvalDueDate(tx *gorm.DB, req models.RequestEvent, d IssueDecision, now time.Time) (time.Time, error) {
+	policy, err := ResolveLoanPolicy(tx, d.LibraryID, req.ReaderID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if d.ExpectedReturnDate == nil {
+		return DueDate(tx, d.LibraryID, now, policy.LoanPeriod())
+	}
+	if !d.ExpectedReturnDate.After(now) || d.ExpectedReturnDate.After(now.Add(policy.LoanPeriod())) {
+		return time.Time{}, ErrInvalidDueDate
+	}
+	return NextOpenDay(tx, d.LibraryID, *d.ExpectedReturnDate)
+}
+
+// IssueCopy records a book issuance and moves the reader's approved request for the
+// book to Issued. The copy is the one the issue names by barcode, which must be a
+// copy of the book on the shelf, or else the one set aside for that request; it must
+// not already be tied to another open issue. A named copy is taken off the shelf and
+// a different copy set aside for the request goes back.
+func IssueCopy(db *gorm.DB, issue *models.IssueRegistry) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var approved models.RequestEvent
+		err := lockForUpdate(tx).
+			Where("reader_id = ? AND book_id = ? AND request_type = ? AND status = ?",
+				issue.ReaderID, issue.ISBN, KindIssue, StatusApproved).
+			Order("req_id ASC").First(&approved).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		found := err == nil
+		var setAside *uint
+		if found {
+			setAside = approved.ItemID
+		}
+
+		named := issue.Barcode != "" || issue.ItemID != nil
+		if !named {
+			issue.ItemID = setAside
+		}
+		if issue.ItemID != nil || issue.Barcode != "" {
+			book, item, err := findIssueItem(tx, issue)
+			if err != nil {
+				return err
+			}
+			var open int64
+			if err := tx.Model(&models.IssueRegistry{}).
+				Where("item_id = ? AND return_date IS NULL", item.ID).
+				Count(&open).Error; err != nil {
+				return err
+			}
+			if open > 0 {
+				return ErrItemOnLoan
+			}
+			if named && (setAside == nil || *setAside != item.ID) {
+				if err := claimIssueItem(tx, &book, item, setAside); err != nil {
+					return err
+				}
+			}
+			issue.ItemID = &item.ID
+			issue.Barcode = item.Barcode
+		}
+		if err := tx.Create(issue).Error; err != nil {
+			return err
+		}
+		if !found {
+			return nil
+		}
+		return TransitionRequest(tx, &approved, StatusIssued, &issue.IssueApproverID, "Book issued",
+			map[string]interface{}{"issue_id": issue.ID, "item_id": issue.ItemID})
+	})
+}
+
+// findIssueItem loads the copy an issue names, by barcode or item ID, together with
+// its book. A copy of another title is refused with ErrItemNotAvailable.
+func findIssueItem(tx *gorm.DB, issue *models.IssueRegistry) (models.BookInventory, models.BookItem, error) {
+	var book models.BookInventory
+	var item models.BookItem
+	if err := lockForUpdate(tx).Where("isbn = ? AND library_id = ?", issue.ISBN, issue.LibraryID).
+		First(&book).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return book, item, ErrItemNotAvailable
+		}
+		return book, item, err
+	}
+	query := lockForUpdate(tx).Where("book_inventory_id = ?", book.ID)
+	if issue.Barcode != "" {
+		query = query.Where("barcode = ?", issue.Barcode)
+	} else {
+		query = query.Where("id = ?", *issue.ItemID)
+	}
+	if err := query.First(&item).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return book, item, ErrItemNotAvailable
+		}
+		return book, item, err
+	}
+	return book, item, nil
+}
+
+// claimIssueItem takes a named copy off the shelf with the same conditional update
+// as CheckoutItem, and puts the copy set aside for the request, if any, back.
+func claimIssueItem(tx *gorm.DB, book *models.BookInventory, item models.BookItem, setAside *uint) error {
+	res := tx.Model(&models.BookItem{}).
+		Where("id = ? AND status = ?", item.ID, "Available").
+		UpdateColumn("status", "Issued")
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrItemNotAvailable
+	}
+	if setAside != nil {
+		return releaseItem(tx, book, setAside)
+	}
+	return SyncBookCounters(tx, book)
+}
+
+// ReturnDecision is an admin's decision on a pending return request.
+type ReturnDecision struct {
+	RequestID  uint
+	ApproverID uint
+	LibraryID  uint
+	Approve    bool
+	Condition  string // state the copy came back in
+	Reason     string // recorded in the request's history
+}
+
+// DecideReturnRequest approves or rejects a pending return request in one transaction.
+// Approval closes the issue, settles its fine, puts the copy back on the shelf, offers
+// it to the hold queue and frees the reader's slot in the active request limit.
+func DecideReturnRequest(db *gorm.DB, d ReturnDecision) (models.RequestEvent, models.IssueRegistry, error) {
+	var req models.RequestEvent
+	var issue models.IssueRegistry
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := lockForUpdate(tx).Where("req_id = ? AND request_type = ?", d.RequestID, KindReturn).
+			Where("reader_id IN (?)", libraryReaders(tx, d.LibraryID)).First(&req).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRequestNotFound
+			}
+			return err
+		}
+		if req.Status != StatusRequested {
+			return ErrRequestNotPending
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{
+			"approval_date": now,
+			"approver_id":   d.ApproverID,
+		}
+		to := StatusRejected
+		if d.Approve {
+			if req.IssueID == nil {
+				return ErrIssueNotFound
+			}
+			if err := closeIssue(tx, &issue, *req.IssueID, d, now); err != nil {
+				return err
+			}
+			to = StatusApproved
+		}
+		return TransitionRequest(tx, &req, to, &d.ApproverID, d.Reason, updates)
+	})
+	return req, issue, err
+}
+
+// closeIssue performs the approval side of a return inside the caller's transaction.
+func closeIssue(tx *gorm.DB, issue *models.IssueRegistry, issueID uint, d ReturnDecision, now time.Time) error {
+	if err := tx.Where("id = ? AND library_id = ?", issueID, d.LibraryID).First(issue).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrIssueNotFound
+		}
+		return err
+	}
+
+	// Close the issue registry row unless it was closed already.
+	res := tx.Model(&models.IssueRegistry{}).
+		Where("id = ? AND return_date IS NULL", issue.ID).
+		Updates(map[string]interface{}{
+			"return_date":        now,
+			"return_approver_id": d.ApproverID,
+			"issue_status":       "Returned",
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrAlreadyReturned
+	}
+	issue.ReturnDate = &now
+	issue.ReturnApproverID = &d.ApproverID
+	issue.IssueStatus = "Returned"
+
+	// Settle the fine for any days the book was kept past its due date.
+	policy, err := ResolveLoanPolicy(tx, issue.LibraryID, issue.ReaderID)
+	if err != nil {
+		return err
+	}
+	if err := AccrueFine(tx, *issue, policy.Fine, now); err != nil {
+		return err
+	}
+
+	// Put the copy back on the shelf; waiting readers get it first.
+	if err := CheckinItem(tx, *issue, d.Condition); err != nil {
+		return err
+	}
+	if err := OfferHolds(tx, issue.ISBN, issue.LibraryID); err != nil {
+		return err
+	}
+
+	// Free the reader's slot by closing the request that led to this issue or, for
+	// issues recorded by hand, the oldest open issue request for this book.
+	var issueReq models.RequestEvent
+	err = lockForUpdate(tx).
+		Where("reader_id = ? AND book_id = ? AND request_type = ? AND status IN ?",
+			issue.ReaderID, issue.ISBN, KindIssue, []string{StatusIssued, StatusApproved}).
+		Where("issue_id = ? OR issue_id IS NULL", issue.ID).
+		Order("issue_id IS NULL, req_id ASC").First(&issueReq).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return TransitionRequest(tx, &issueReq, StatusReturned, &d.ApproverID, "Book returned", nil)
+}
+
+// CancelRequest withdraws a pending issue or return request. An issue request that was
+// approved but not yet handed out gives its copy back to the shelf and to the hold
+// queue. When readerID is non-zero only that reader's requests can be cancelled.
+func CancelRequest(db *gorm.DB, requestID, actorID, libraryID, readerID uint, reason string) (models.RequestEvent, error) {
+	var req models.RequestEvent
+	err := db.Transaction(func(tx *gorm.DB) error {
+		query := lockForUpdate(tx).
+			Where("req_id = ? AND request_type IN ?", requestID, []string{KindIssue, KindReturn}).
+			Where("reader_id IN (?)", libraryReaders(tx, libraryID))
+		if readerID != 0 {
+			query = query.Where("reader_id = ?", readerID)
+		}
+		if err := query.First(&req).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRequestNotFound
+			}
+			return err
+		}
+		if !CanTransition(req.RequestType, req.Status, StatusCancelled) {
+			return ErrRequestNotPending
+		}
+		return withdrawRequest(tx, &req, StatusCancelled, &actorID, libraryID, reason)
+	})
+	return req, err
+}
+
+// withdrawRequest ends a request that was never handed out, as cancelled or expired.
+// An approved issue request gives its copy back to the shelf and to the hold queue.
+func withdrawRequest(tx *gorm.DB, req *models.RequestEvent, to string, actorID *uint, libraryID uint, reason string) error {
+	if req.Status != StatusApproved {
+		return TransitionRequest(tx, req, to, actorID, reason, nil)
+	}
+
+	// An approved request may predate status tracking and already be on loan.
+	open := tx.Model(&models.IssueRegistry{}).
+		Where("reader_id = ? AND isbn = ? AND library_id = ? AND return_date IS NULL", req.ReaderID, req.BookID, libraryID)
+	if req.ItemID != nil {
+		open = open.Where("item_id = ? OR item_id IS NULL", *req.ItemID)
+	}
+	var onLoan int64
+	if err := open.Count(&onLoan).Error; err != nil {
+		return err
+	}
+	if onLoan > 0 {
+		return ErrItemOnLoan
+	}
+
+	var book models.BookInventory
+	if err := lockForUpdate(tx).Where("isbn = ? AND library_id = ?", req.BookID, libraryID).
+		First(&book).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return TransitionRequest(tx, req, to, actorID, reason, nil)
+		}
+		return err
+	}
+	if err := TransitionRequest(tx, req, to, actorID, reason, nil); err != nil {
+		return err
+	}
+	return releaseItem(tx, &book, req.ItemID)
+}
+
+// ExpireRequests expires issue requests left pending longer than RequestPendingWindow
+// and approved requests not collected within RequestPickupWindow, each through its own
+// transition so that it shows in the request's history. Copies set aside for expired
+// requests go back on the shelf. Approved requests already on loan are left alone.
+// It returns the number of expired requests.
+func ExpireRequests(db *gorm.DB, now time.Time) (int64, error) {
+	var stale []struct {
+		ReqID     uint
+		LibraryID uint
+	}
+	if err := db.Table("request_events re").Select("re.req_id, u.library_id").
+		Joins("JOIN users u ON u.id = re.reader_id").
+		Where("re.request_type = ?", KindIssue).
+		Where("(re.status = ? AND re.request_date < ?) OR (re.status = ? AND re.approval_date < ?)",
+			StatusRequested, now.Add(-RequestPendingWindow), StatusApproved, now.Add(-RequestPickupWindow)).
+		Order("re.req_id ASC").Scan(&stale).Error; err != nil {
+		return 0, err
+	}
+
+	var expired int64
+	for _, s := range stale {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var req models.RequestEvent
+			if err := lockForUpdate(tx).First(&req, s.ReqID).Error; err != nil {
+				return err
+			}
+			if !CanTransition(req.RequestType, req.Status, StatusExpired) {
+				return ErrRequestNotPending
+			}
+			return withdrawRequest(tx, &req, StatusExpired, nil, s.LibraryID, "Request expired")
+		})
+		switch {
+		case err == nil:
+			expired++
+		case errors.Is(err, ErrRequestNotPending), errors.Is(err, ErrItemOnLoan):
+			// Decided in the meantime, or handed out before status tracking.
+		default:
+			return expired, err
+		}
+	}
+	return expired, nil
+}
+
+// releaseItem puts a copy that was set aside but never handed out back on the shelf.
+// Without an item ID, any issued copy of the book not tied to an open issue is used.
+func releaseItem(tx *gorm.DB, book *models.BookInventory, itemID *uint) error {
+	if err := EnsureBookItems(tx, book); err != nil {
+		return err
+	}
+	query := tx.Model(&models.BookItem{}).Where("book_inventory_id = ? AND status = ?", book.ID, "Issued")
+	if itemID != nil {
+		query = query.Where("id = ?", *itemID)
+	} else {
+		linked := tx.Model(&models.IssueRegistry{}).Select("item_id").
+			Where("item_id IS NOT NULL AND return_date IS NULL")
+		var item models.BookItem
+		err := tx.Where("book_inventory_id = ? AND status = ? AND id NOT IN (?)", book.ID, "Issued", linked).
+			Order("id ASC").First(&item).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		query = query.Where("id = ?", item.ID)
+	}
+	if err := query.UpdateColumn("status", "Available").Error; err != nil {
+		return err
+	}
+	if err := SyncBookCounters(tx, book); err != nil {
+		return err
+	}
+	return OfferHolds(tx, book.ISBN, book.LibraryID)
+}
@@ -0,0 +1,118 @@
+// /backend/src/services/covers.go
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif" // registers the GIF decoder
+	"image/jpeg"
+	_ "image/png" // registers the PNG decoder
+	"net/http"
+
+	"github.com/swapxs/LibMS/backend/src/models"
+)
+
+// Cover image limits.
+const (
+	MaxCoverSize      = 5 << 20 // bytes
+	MaxCoverDimension = 6000    // pixels, either side
+	ThumbnailWidth    = 200
+	ThumbnailHeight   = 300
+)
+
+// CoverTypes are the media types accepted for cover images.
+var CoverTypes = []string{"image/jpeg", "image/png", "image/gif"}
+
+var (
+	ErrUnsupportedCover = fmt.Errorf("cover must be one of %v", CoverTypes)
+	ErrInvalidCover     = errors.New("cover is not a readable image")
+	ErrCoverTooLarge    = fmt.Errorf("cover must be at most %d bytes and %d pixels a side", MaxCoverSize, MaxCoverDimension)
+)
+
+// CoverKey is the storage key of a book's cover, and of its thumbnail when thumb is
+// set. Keys follow the book's ID, which unlike its ISBN never changes.
+func CoverKey(book models.BookInventory, thumb bool) string {
+	key := fmt.Sprintf("covers/%d/%d", book.LibraryID, book.ID)
+	if thumb {
+		key += "-thumb.jpg"
+	}
+	return key
+}
+
+// ProcessCover checks an uploaded cover and renders its thumbnail. The media type is
+// sniffed from the content, whatever the upload claims, and the dimensions are
+// checked before the image is decoded in full.
+func ProcessCover(data []byte) (contentType string, thumbnail []byte, err error) {
+	if len(data) > MaxCoverSize {
+		return "", nil, ErrCoverTooLarge
+	}
+	contentType = http.DetectContentType(data)
+	supported := false
+	for _, t := range CoverTypes {
+		supported = supported || t == contentType
+	}
+	if !supported {
+		return "", nil, ErrUnsupportedCover
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", nil, ErrInvalidCover
+	}
+	if cfg.Width > MaxCoverDimension || cfg.Height > MaxCoverDimension {
+		return "", nil, ErrCoverTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", nil, ErrInvalidCover
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, thumbnailOf(img, ThumbnailWidth, ThumbnailHeight), &jpeg.Options{Quality: 85}); err != nil {
+		return "", nil, err
+	}
+	return contentType, buf.Bytes(), nil
+}
+
+// thumbnailOf scales img down to fit within width x height, keeping its proportions,
+// on a white background for images with transparency. Each thumbnail pixel averages
+// the source pixels it covers. Images already small enough keep their size.
+func thumbnailOf(img image.Image, width, height int) *image.RGBA {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > width || h > height {
+		// Scale by the tighter of the two limits.
+		if w*height > h*width {
+			w, h = width, max(1, h*width/b.Dx())
+		} else {
+			w, h = max(1, w*height/b.Dy()), height
+		}
+	}
+
+	src := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(src, src.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Over)
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0, y1 := y*b.Dy()/h, max((y+1)*b.Dy()/h, y*b.Dy()/h+1)
+		for x := 0; x < w; x++ {
+			x0, x1 := x*b.Dx()/w, max((x+1)*b.Dx()/w, x*b.Dx()/w+1)
+			var r, g, bl, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					i := src.PixOffset(sx, sy)
+					r += uint64(src.Pix[i])
+					g += uint64(src.Pix[i+1])
+					bl += uint64(src.Pix[i+2])
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{uint8(r / n), uint8(g / n), uint8(bl / n), 255})
+		}
+	}
+	return dst
+}
@@ -0,0 +1,127 @@
+// /backend/src/services/fines.go
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/swapxs/LibMS/backend/src/models"
+	"gorm.io/gorm"
+)
+
+// DefaultFinePolicy applies to libraries that have not configured one: overdue
+// issues are tracked but no fines accrue until a daily rate is set.
+func DefaultFinePolicy(libraryID uint) models.FinePolicy {
+	return models.FinePolicy{LibraryID: libraryID}
+}
+
+// LoadFinePolicy returns the library's fine policy, or the default when none is stored.
+func LoadFinePolicy(db *gorm.DB, libraryID uint) (models.FinePolicy, error) {
+	var policy models.FinePolicy
+	err := db.Where("library_id = ?", libraryID).First(&policy).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return DefaultFinePolicy(libraryID), nil
+	}
+	return policy, err
+}
+
+// FineAmount computes the fine for an issue due at due and closed (or evaluated) at
+// end. Only days on which the library is open are charged; a nil calendar charges
+// every day.
+func FineAmount(policy models.FinePolicy, cal *Calendar, due, end time.Time) int64 {
+	if !end.After(due) {
+		return 0
+	}
+	days := cal.OpenDaysAfter(due, int(end.Sub(due).Hours()/24))
+	chargeable := days - policy.GracePeriodDays
+	if chargeable <= 0 {
+		return 0
+	}
+	amount := int64(chargeable) * policy.DailyRateCents
+	if policy.MaxFineCents > 0 && amount > policy.MaxFineCents {
+		amount = policy.MaxFineCents
+	}
+	return amount
+}
+
+// AccrueFine brings the fine of an overdue issue up to date. Accrual stops at the
+// return date once the book is back. Fines are only created once they are non-zero.
+func AccrueFine(db *gorm.DB, issue models.IssueRegistry, policy models.FinePolicy, now time.Time) error {
+	end := now
+	if issue.ReturnDate != nil {
+		end = *issue.ReturnDate
+	}
+	cal, err := LoadCalendar(db, issue.LibraryID, issue.ExpectedReturnDate, end)
+	if err != nil {
+		return err
+	}
+	amount := FineAmount(policy, cal, issue.ExpectedReturnDate, end)
+
+	var fine models.Fine
+	err = db.Where("issue_id = ?", issue.ID).First(&fine).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if amount == 0 {
+			return nil
+		}
+		fine = models.Fine{
+			IssueID:      issue.ID,
+			ReaderID:     issue.ReaderID,
+			LibraryID:    issue.LibraryID,
+			AccruedCents: amount,
+		}
+		return db.Create(&fine).Error
+	}
+	if err != nil {
+		return err
+	}
+	// Never lower an accrued fine, e.g. after a policy change; admins adjust instead.
+	if amount <= fine.AccruedCents {
+		return nil
+	}
+	return db.Model(&fine).UpdateColumn("accrued_cents", amount).Error
+}
+
+// SweepOverdue marks open issues past their expected return date as "Overdue" and
+// accrues fines on every open overdue issue. It returns the number of newly marked issues.
+func SweepOverdue(db *gorm.DB, now time.Time) (int64, error) {
+	res := db.Model(&models.IssueRegistry{}).
+		Where("return_date IS NULL AND expected_return_date < ? AND issue_status <> ?", now, "Overdue").
+		UpdateColumn("issue_status", "Overdue")
+	if res.Error != nil {
+		return 0, res.Error
+	}
+
+	var overdue []models.IssueRegistry
+	if err := db.Where("return_date IS NULL AND issue_status = ?", "Overdue").Find(&overdue).Error; err != nil {
+		return res.RowsAffected, err
+	}
+
+	// Fine rates can differ per reader, so resolve each reader's policy once.
+	policies := map[[2]uint]models.FinePolicy{}
+	for _, issue := range overdue {
+		key := [2]uint{issue.LibraryID, issue.ReaderID}
+		policy, ok := policies[key]
+		if !ok {
+			resolved, err := ResolveLoanPolicy(db, issue.LibraryID, issue.ReaderID)
+			if err != nil {
+				return res.RowsAffected, err
+			}
+			policy = resolved.Fine
+			policies[key] = policy
+		}
+		if err := AccrueFine(db, issue, policy, now); err != nil {
+			return res.RowsAffected, err
+		}
+	}
+	return res.RowsAffected, nil
+}
+
+// OutstandingBalance returns the total a reader still owes in a library.
+func OutstandingBalance(db *gorm.DB, readerID, libraryID uint) (int64, error) {
+	var balance int64
+	err := db.Model(&models.Fine{}).
+		Where("reader_id = ? AND library_id = ?", readerID, libraryID).
+		Select("COALESCE(SUM(accrued_cents + adjustment_cents - paid_cents - waived_cents), 0)").
+		Scan(&balance).Error
+	return balance, err
+}
@@ -0,0 +1,94 @@
+// /backend/src/services/holds.go
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/swapxs/LibMS/backend/src/models"
+	"gorm.io/gorm"
+)
+
+// HoldPickupWindow is how long an offered copy is kept for the reader at the head of the queue.
+const HoldPickupWindow = 3 * 24 * time.Hour
+
+// CountOfferedHolds returns the number of copies currently set aside for holds,
+// optionally ignoring the offers made to one reader.
+func CountOfferedHolds(db *gorm.DB, isbn string, libraryID uint, exceptReaderID uint) (int64, error) {
+	var offered int64
+	err := db.Model(&models.Hold{}).
+		Where("isbn = ? AND library_id = ? AND status = ? AND reader_id <> ?", isbn, libraryID, "Offered", exceptReaderID).
+		Count(&offered).Error
+	return offered, err
+}
+
+// OfferHolds offers free copies of a title to the readers at the head of its hold queue.
+// It should be called whenever copies become available.
+func OfferHolds(db *gorm.DB, isbn string, libraryID uint) error {
+	var book models.BookInventory
+	if err := db.Where("isbn = ? AND library_id = ?", isbn, libraryID).First(&book).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	offered, err := CountOfferedHolds(db, isbn, libraryID, 0)
+	if err != nil {
+		return err
+	}
+
+	free := int64(book.AvailableCopies) - offered
+	for ; free > 0; free-- {
+		var next models.Hold
+		err := db.Where("isbn = ? AND library_id = ? AND status = ?", isbn, libraryID, "Waiting").
+			Order("id ASC").First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		expires := now.Add(HoldPickupWindow)
+		next.Status = "Offered"
+		next.OfferedAt = &now
+		next.OfferExpiresAt = &expires
+		if err := db.Save(&next).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExpireHoldOffers marks unclaimed offers past their pickup deadline as expired and
+// passes the copies on to the next readers in line. It returns the number of expired holds.
+func ExpireHoldOffers(db *gorm.DB, libraryID uint, now time.Time) (int64, error) {
+	var expired []models.Hold
+	if err := db.Where("library_id = ? AND status = ? AND offer_expires_at < ?", libraryID, "Offered", now).
+		Find(&expired).Error; err != nil {
+		return 0, err
+	}
+	if len(expired) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]uint, 0, len(expired))
+	for _, h := range expired {
+		ids = append(ids, h.ID)
+	}
+	if err := db.Model(&models.Hold{}).Where("id IN ?", ids).Update("status", "Expired").Error; err != nil {
+		return 0, err
+	}
+
+	seen := map[string]bool{}
+	for _, h := range expired {
+		if seen[h.ISBN] {
+			continue
+		}
+		seen[h.ISBN] = true
+		if err := OfferHolds(db, h.ISBN, libraryID); err != nil {
+			return 0, err
+		}
+	}
+	return int64(len(expired)), nil
+}
@@ -0,0 +1,148 @@
+// /backend/src/services/isbn.go
+package services
+
+import (
+	"sort"
+
+	"github.com/swapxs/LibMS/backend/src/isbn"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"gorm.io/gorm"
+)
+
+// ISBNMigrationReport summarizes what NormalizeStoredISBNs changed.
+type ISBNMigrationReport struct {
+	Normalized int      // books whose ISBN was rewritten
+	Merged     int      // duplicate books folded into another record
+	Invalid    []string // stored ISBNs that are not valid ISBNs, left as they are
+}
+
+// NormalizeStoredISBNs rewrites every stored ISBN into its normalized ISBN-13 form.
+// Books of a library that turn out to share an ISBN are merged into one record: the
+// copies, credits, subjects, tags and revisions of the others move to it and the
+// others are deleted. Holds, issues and
+// requests follow the new ISBN. Normalized rows are left alone, so running it again
+// is a no-op.
+func NormalizeStoredISBNs(db *gorm.DB) (ISBNMigrationReport, error) {
+	var report ISBNMigrationReport
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var books []models.BookInventory
+		if err := tx.Unscoped().Order("id ASC").Find(&books).Error; err != nil {
+			return err
+		}
+
+		type key struct {
+			libraryID uint
+			isbn      string
+		}
+		groups := map[key][]models.BookInventory{}
+		renamed := map[string]string{}
+		for _, book := range books {
+			normalized, err := isbn.Normalize(book.ISBN)
+			if err != nil {
+				report.Invalid = append(report.Invalid, book.ISBN)
+				continue
+			}
+			if normalized != book.ISBN {
+				renamed[book.ISBN] = normalized
+			}
+			k := key{book.LibraryID, normalized}
+			groups[k] = append(groups[k], book)
+		}
+
+		for k, group := range groups {
+			if len(group) == 1 && group[0].ISBN == k.isbn {
+				continue
+			}
+			// Keep the oldest live record; deleted ones only when nothing else is left.
+			sort.SliceStable(group, func(i, j int) bool {
+				return !group[i].DeletedAt.Valid && group[j].DeletedAt.Valid
+			})
+			keep := group[0]
+			if !keep.DeletedAt.Valid && len(group) > 1 {
+				// Books from before copy tracking get their items first, so that
+				// their copies are not lost in the merge.
+				if err := EnsureBookItems(tx, &keep); err != nil {
+					return err
+				}
+			}
+			for _, book := range group {
+				if book.ISBN != k.isbn {
+					report.Normalized++
+				}
+			}
+			for _, dup := range group[1:] {
+				if !dup.DeletedAt.Valid {
+					if err := EnsureBookItems(tx, &dup); err != nil {
+						return err
+					}
+				}
+				if err := mergeBookRecords(tx, keep.ID, dup.ID); err != nil {
+					return err
+				}
+				if err := tx.Unscoped().Delete(&models.BookInventory{}, dup.ID).Error; err != nil {
+					return err
+				}
+				report.Merged++
+			}
+			if keep.ISBN != k.isbn {
+				if err := tx.Unscoped().Model(&models.BookInventory{}).Where("id = ?", keep.ID).
+					Update("isbn", k.isbn).Error; err != nil {
+					return err
+				}
+			}
+			if !keep.DeletedAt.Valid && len(group) > 1 {
+				if err := SyncBookCounters(tx, &keep); err != nil {
+					return err
+				}
+			}
+		}
+
+		// ISBNs are the same in every library, so references can be renamed as a whole.
+		for old, normalized := range renamed {
+			if err := tx.Unscoped().Model(&models.Hold{}).Where("isbn = ?", old).Update("isbn", normalized).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Model(&models.IssueRegistry{}).Where("isbn = ?", old).Update("isbn", normalized).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Model(&models.RequestEvent{}).Where("book_id = ?", old).Update("book_id", normalized).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return report, err
+}
+
+// mergeBookRecords moves the copies, credits, subjects, tags and revisions of the
+// book from onto the book into. Credits, subjects and tags into already has are
+// dropped; the remaining credits are listed after those of into.
+func mergeBookRecords(tx *gorm.DB, into, from uint) error {
+	var credits int64
+	if err := tx.Model(&models.BookAuthor{}).Where("book_inventory_id = ?", into).Count(&credits).Error; err != nil {
+		return err
+	}
+	duplicates := []struct {
+		rows any
+		kept string
+	}{
+		{&models.BookAuthor{}, "SELECT 1 FROM book_authors kept WHERE kept.book_inventory_id = ? AND kept.author_id = book_authors.author_id AND kept.role = book_authors.role"},
+		{&models.BookSubject{}, "SELECT 1 FROM book_subjects kept WHERE kept.book_inventory_id = ? AND kept.subject_id = book_subjects.subject_id"},
+		{&models.BookTag{}, "SELECT 1 FROM book_tags kept WHERE kept.book_inventory_id = ? AND kept.tag = book_tags.tag"},
+	}
+	for _, d := range duplicates {
+		if err := tx.Where("book_inventory_id = ? AND EXISTS ("+d.kept+")", from, into).Delete(d.rows).Error; err != nil {
+			return err
+		}
+	}
+	if err := tx.Model(&models.BookAuthor{}).Where("book_inventory_id = ?", from).
+		Updates(map[string]interface{}{"book_inventory_id": into, "position": gorm.Expr("position + ?", credits)}).Error; err != nil {
+		return err
+	}
+	for _, rows := range []any{&models.BookItem{}, &models.BookSubject{}, &models.BookTag{}, &models.BookRevision{}} {
+		if err := tx.Model(rows).Where("book_inventory_id = ?", from).Update("book_inventory_id", into).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,245 @@
+// /backend/src/services/items.go
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/swapxs/LibMS/backend/src/models"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrNoAvailableItem is returned when a title has no copy on the shelf.
+	ErrNoAvailableItem = errors.New("no available copies left to issue")
+	// ErrItemNotAvailable is returned when the requested barcode cannot be issued.
+	ErrItemNotAvailable = errors.New("copy with this barcode is not available")
+	// ErrBarcodeInUse is returned when a new copy reuses an existing barcode.
+	ErrBarcodeInUse = errors.New("barcode already in use")
+)
+
+// generateBarcode builds a library-unique barcode for the seq-th copy of a title.
+func generateBarcode(book models.BookInventory, seq int64) string {
+	return fmt.Sprintf("LIB%d-%d-%04d", book.LibraryID, book.ID, seq)
+}
+
+// nextItemSeq returns the sequence number for the next copy of a title.
+func nextItemSeq(db *gorm.DB, bookID uint) (int64, error) {
+	var count int64
+	err := db.Unscoped().Model(&models.BookItem{}).Where("book_inventory_id = ?", bookID).Count(&count).Error
+	return count + 1, err
+}
+
+// EnsureBookItems creates items for a title that predates copy-level tracking, so that
+// its counters can be derived from items from now on. Copies already out on loan are
+// created as "Issued".
+func EnsureBookItems(db *gorm.DB, book *models.BookInventory) error {
+	var count int64
+	if err := db.Unscoped().Model(&models.BookItem{}).Where("book_inventory_id = ?", book.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 || book.TotalCopies <= 0 {
+		return nil
+	}
+
+	issued := book.TotalCopies - book.AvailableCopies
+	items := make([]models.BookItem, 0, book.TotalCopies)
+	for i := 0; i < book.TotalCopies; i++ {
+		status := "Available"
+		if i < issued {
+			status = "Issued"
+		}
+		items = append(items, models.BookItem{
+			BookInventoryID: book.ID,
+			LibraryID:       book.LibraryID,
+			Barcode:         generateBarcode(*book, int64(i+1)),
+			Condition:       "Good",
+			Status:          status,
+		})
+	}
+	return db.Create(&items).Error
+}
+
+// AddBookItems adds copies to a title. Barcodes are generated unless supplied;
+// when supplied there must be exactly one per copy.
+func AddBookItems(db *gorm.DB, book *models.BookInventory, copies int, barcodes []string, condition, shelfLocation string) error {
+	if len(barcodes) > 0 {
+		var taken int64
+		if err := db.Model(&models.BookItem{}).
+			Where("library_id = ? AND barcode IN ?", book.LibraryID, barcodes).
+			Count(&taken).Error; err != nil {
+			return err
+		}
+		if taken > 0 {
+			return ErrBarcodeInUse
+		}
+	}
+	if condition == "" {
+		condition = "Good"
+	}
+
+	seq, err := nextItemSeq(db, book.ID)
+	if err != nil {
+		return err
+	}
+	items := make([]models.BookItem, 0, copies)
+	for i := 0; i < copies; i++ {
+		barcode := generateBarcode(*book, seq+int64(i))
+		if len(barcodes) > 0 {
+			barcode = barcodes[i]
+		}
+		items = append(items, models.BookItem{
+			BookInventoryID: book.ID,
+			LibraryID:       book.LibraryID,
+			Barcode:         barcode,
+			Condition:       condition,
+			ShelfLocation:   shelfLocation,
+			Status:          "Available",
+		})
+	}
+	if err := db.Create(&items).Error; err != nil {
+		return err
+	}
+	return SyncBookCounters(db, book)
+}
+
+// SyncBookCounters recomputes a title's TotalCopies and AvailableCopies from its items.
+// Withdrawn copies no longer count towards the total. The book row is locked first so
+// that concurrent transactions recount one after the other.
+func SyncBookCounters(db *gorm.DB, book *models.BookInventory) error {
+	if err := lockForUpdate(db).Select("id").First(&models.BookInventory{}, book.ID).Error; err != nil {
+		return err
+	}
+	var total, available int64
+	if err := db.Model(&models.BookItem{}).
+		Where("book_inventory_id = ? AND status <> ?", book.ID, "Withdrawn").
+		Count(&total).Error; err != nil {
+		return err
+	}
+	if err := db.Model(&models.BookItem{}).
+		Where("book_inventory_id = ? AND status = ?", book.ID, "Available").
+		Count(&available).Error; err != nil {
+		return err
+	}
+	book.TotalCopies = int(total)
+	book.AvailableCopies = int(available)
+	return db.Model(book).UpdateColumns(map[string]interface{}{
+		"total_copies":     book.TotalCopies,
+		"available_copies": book.AvailableCopies,
+	}).Error
+}
+
+// CheckoutItem marks a copy of the title as issued. With an empty barcode the
+// lowest-numbered available copy is used. The copy is claimed with a conditional
+// update, so two transactions can never issue the same copy.
+func CheckoutItem(db *gorm.DB, book *models.BookInventory, barcode string) (models.BookItem, error) {
+	var item models.BookItem
+	if err := EnsureBookItems(db, book); err != nil {
+		return item, err
+	}
+
+	for {
+		query := db.Where("book_inventory_id = ? AND status = ?", book.ID, "Available")
+		if barcode != "" {
+			query = query.Where("barcode = ?", barcode)
+		}
+		err := query.Order("id ASC").First(&item).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if barcode != "" {
+				return item, ErrItemNotAvailable
+			}
+			return item, ErrNoAvailableItem
+		}
+		if err != nil {
+			return item, err
+		}
+
+		res := db.Model(&models.BookItem{}).
+			Where("id = ? AND status = ?", item.ID, "Available").
+			UpdateColumn("status", "Issued")
+		if res.Error != nil {
+			return item, res.Error
+		}
+		if res.RowsAffected == 1 {
+			break
+		}
+		// Another transaction claimed this copy first; try the next one.
+	}
+	item.Status = "Issued"
+	return item, SyncBookCounters(db, book)
+}
+
+// CheckinItem puts the copy held by an issue back on the shelf. Issues recorded
+// before copy-level tracking fall back to any issued copy not tied to an open issue.
+// condition, when non-empty, records the state the copy came back in.
+func CheckinItem(db *gorm.DB, issue models.IssueRegistry, condition string) error {
+	var book models.BookInventory
+	if err := db.Where("isbn = ? AND library_id = ?", issue.ISBN, issue.LibraryID).First(&book).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if err := EnsureBookItems(db, &book); err != nil {
+		return err
+	}
+
+	var item models.BookItem
+	var err error
+	if issue.ItemID != nil {
+		err = db.First(&item, *issue.ItemID).Error
+	} else {
+		linked := db.Model(&models.IssueRegistry{}).Select("item_id").
+			Where("item_id IS NOT NULL AND return_date IS NULL AND id <> ?", issue.ID)
+		err = db.Where("book_inventory_id = ? AND status = ? AND id NOT IN (?)", book.ID, "Issued", linked).
+			Order("id ASC").First(&item).Error
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return SyncBookCounters(db, &book)
+	}
+	if err != nil {
+		return err
+	}
+
+	updates := map[string]interface{}{"status": "Available"}
+	if condition != "" {
+		updates["condition"] = condition
+		if condition == "Damaged" {
+			updates["status"] = "Damaged"
+		}
+	}
+	if err := db.Model(&item).UpdateColumns(updates).Error; err != nil {
+		return err
+	}
+	return SyncBookCounters(db, &book)
+}
+
+// WithdrawItems retires copies of a title. Named barcodes must be on the shelf;
+// otherwise the most recently added available copies are withdrawn.
+func WithdrawItems(db *gorm.DB, book *models.BookInventory, copies int, barcodes []string) error {
+	if err := EnsureBookItems(db, book); err != nil {
+		return err
+	}
+
+	query := db.Where("book_inventory_id = ? AND status IN ?", book.ID, []string{"Available", "Damaged", "Lost"})
+	if len(barcodes) > 0 {
+		query = query.Where("barcode IN ?", barcodes)
+		copies = len(barcodes)
+	}
+	var items []models.BookItem
+	if err := query.Order("id DESC").Limit(copies).Find(&items).Error; err != nil {
+		return err
+	}
+	if len(items) < copies {
+		return ErrItemNotAvailable
+	}
+
+	ids := make([]uint, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	if err := db.Model(&models.BookItem{}).Where("id IN ?", ids).UpdateColumn("status", "Withdrawn").Error; err != nil {
+		return err
+	}
+	return SyncBookCounters(db, book)
+}
@@ -0,0 +1,123 @@
+// /backend/src/services/loan_policy.go
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/swapxs/LibMS/backend/src/models"
+	"gorm.io/gorm"
+)
+
+// Library-wide defaults used when no rule sets a limit.
+const (
+	DefaultMaxActiveRequests = 4
+	DefaultLoanPeriodDays    = 14
+	DefaultMaxRenewals       = 2
+)
+
+// EffectiveLoanPolicy is the set of circulation limits that apply to one user.
+type EffectiveLoanPolicy struct {
+	LibraryID         uint              `json:"library_id"`
+	Role              string            `json:"role"`
+	PatronCategory    string            `json:"patron_category"`
+	MaxActiveRequests int               `json:"max_active_requests"`
+	LoanPeriodDays    int               `json:"loan_period_days"`
+	MaxRenewals       int               `json:"max_renewals"`
+	Fine              models.FinePolicy `json:"fine"`
+	RuleIDs           []uint            `json:"rule_ids"` // matching rules, least specific first
+}
+
+// LoanPeriod returns the loan period as a duration.
+func (p EffectiveLoanPolicy) LoanPeriod() time.Duration {
+	return time.Duration(p.LoanPeriodDays) * 24 * time.Hour
+}
+
+// ruleSpecificity orders rules so that more specific ones are applied last: a
+// library-wide rule, then role, then patron category, then both.
+func ruleSpecificity(rule models.LoanPolicy) int {
+	n := 0
+	if rule.Role != "" {
+		n++
+	}
+	if rule.PatronCategory != "" {
+		n += 2
+	}
+	return n
+}
+
+// ResolveLoanPolicyFor combines the library defaults, the library's fine policy and
+// renewal limit, and every rule matching the role and patron category.
+func ResolveLoanPolicyFor(db *gorm.DB, libraryID uint, role, category string) (EffectiveLoanPolicy, error) {
+	policy := EffectiveLoanPolicy{
+		LibraryID:         libraryID,
+		Role:              role,
+		PatronCategory:    category,
+		MaxActiveRequests: DefaultMaxActiveRequests,
+		LoanPeriodDays:    DefaultLoanPeriodDays,
+		MaxRenewals:       DefaultMaxRenewals,
+		RuleIDs:           []uint{},
+	}
+	var library models.Library
+	err := db.First(&library, libraryID).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return policy, err
+	}
+	if err == nil {
+		policy.MaxRenewals = library.MaxRenewals
+	}
+	if policy.Fine, err = LoadFinePolicy(db, libraryID); err != nil {
+		return policy, err
+	}
+
+	var rules []models.LoanPolicy
+	if err := db.Where("library_id = ? AND role IN ? AND patron_category IN ?",
+		libraryID, []string{"", role}, []string{"", category}).
+		Find(&rules).Error; err != nil {
+		return policy, err
+	}
+	for level := 0; level <= 3; level++ {
+		for _, rule := range rules {
+			if ruleSpecificity(rule) == level {
+				applyLoanRule(&policy, rule)
+			}
+		}
+	}
+	return policy, nil
+}
+
+// ResolveLoanPolicy returns the limits that apply to a user of the library. Unknown
+// users get the rules that match everyone.
+func ResolveLoanPolicy(db *gorm.DB, libraryID, userID uint) (EffectiveLoanPolicy, error) {
+	var user models.User
+	err := db.Where("id = ? AND library_id = ?", userID, libraryID).First(&user).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return EffectiveLoanPolicy{}, err
+	}
+	return ResolveLoanPolicyFor(db, libraryID, user.Role, user.PatronCategory)
+}
+
+func applyLoanRule(policy *EffectiveLoanPolicy, rule models.LoanPolicy) {
+	policy.RuleIDs = append(policy.RuleIDs, rule.ID)
+	if rule.MaxActiveRequests != nil {
+		policy.MaxActiveRequests = *rule.MaxActiveRequests
+	}
+	if rule.LoanPeriodDays != nil {
+		policy.LoanPeriodDays = *rule.LoanPeriodDays
+	}
+	if rule.MaxRenewals != nil {
+		policy.MaxRenewals = *rule.MaxRenewals
+	}
+	if rule.DailyRateCents != nil {
+		policy.Fine.DailyRateCents = *rule.DailyRateCents
+	}
+	if rule.GracePeriodDays != nil {
+		policy.Fine.GracePeriodDays = *rule.GracePeriodDays
+	}
+	if rule.MaxFineCents != nil {
+		policy.Fine.MaxFineCents = *rule.MaxFineCents
+	}
+	if rule.BlockThresholdCents != nil {
+		policy.Fine.BlockThresholdCents = *rule.BlockThresholdCents
+	}
+}
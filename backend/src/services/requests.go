@@ -0,0 +1,152 @@
+// /backend/src/services/requests.go
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/swapxs/LibMS/backend/src/models"
+	"gorm.io/gorm"
+)
+
+// Request kinds, stored in RequestEvent.RequestType.
+const (
+	KindIssue  = "Issue"
+	KindReturn = "Return"
+	KindRenew  = "Renew"
+)
+
+// Request statuses, stored in RequestEvent.Status.
+const (
+	StatusRequested = "Requested"
+	StatusApproved  = "Approved"
+	StatusIssued    = "Issued"
+	StatusReturned  = "Returned"
+	StatusRejected  = "Rejected"
+	StatusCancelled = "Cancelled"
+	StatusExpired   = "Expired"
+)
+
+// ActiveIssueStatuses are the statuses in which an issue request counts towards a
+// reader's request limit.
+var ActiveIssueStatuses = []string{StatusRequested, StatusApproved, StatusIssued}
+
+// requestTransitions lists, per request kind, the statuses each status may move to.
+var requestTransitions = map[string]map[string][]string{
+	KindIssue: {
+		StatusRequested: {StatusApproved, StatusRejected, StatusCancelled, StatusExpired},
+		StatusApproved:  {StatusIssued, StatusReturned, StatusCancelled, StatusExpired},
+		StatusIssued:    {StatusReturned},
+	},
+	KindReturn: {
+		StatusRequested: {StatusApproved, StatusRejected, StatusCancelled},
+	},
+}
+
+// initialStatus is the status a new request of each kind starts in. Renewals are
+// granted on the spot.
+var initialStatus = map[string]string{
+	KindIssue:  StatusRequested,
+	KindReturn: StatusRequested,
+	KindRenew:  StatusApproved,
+}
+
+// ErrInvalidTransition is returned when a request cannot move to the requested status.
+var ErrInvalidTransition = errors.New("invalid request status transition")
+
+// CanTransition reports whether a request of the given kind may move between statuses.
+func CanTransition(kind, from, to string) bool {
+	for _, allowed := range requestTransitions[kind][from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateRequest stores a new request in the initial status for its kind and records
+// the creating transition.
+func CreateRequest(db *gorm.DB, req *models.RequestEvent, actorID *uint, reason string) error {
+	status, ok := initialStatus[req.RequestType]
+	if !ok {
+		return fmt.Errorf("unknown request kind %q", req.RequestType)
+	}
+	if req.Status == "" {
+		req.Status = status
+	}
+	if err := db.Create(req).Error; err != nil {
+		return err
+	}
+	return db.Create(&models.RequestTransition{
+		RequestID: req.ReqID,
+		ToStatus:  req.Status,
+		ActorID:   actorID,
+		Reason:    reason,
+	}).Error
+}
+
+// TransitionRequest moves a request to a new status, applying any extra column
+// updates, and appends the change to its history. The update only succeeds while the
+// request is still in the status it was read in, so concurrent changes cannot both
+// win; the loser gets ErrRequestNotPending.
+func TransitionRequest(db *gorm.DB, req *models.RequestEvent, to string, actorID *uint, reason string, updates map[string]interface{}) error {
+	from := req.Status
+	if !CanTransition(req.RequestType, from, to) {
+		return fmt.Errorf("%w: %s request cannot go from %s to %s", ErrInvalidTransition, req.RequestType, from, to)
+	}
+
+	columns := map[string]interface{}{"status": to}
+	for k, v := range updates {
+		columns[k] = v
+	}
+	res := db.Model(&models.RequestEvent{}).
+		Where("req_id = ? AND status = ?", req.ReqID, from).
+		Updates(columns)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrRequestNotPending
+	}
+	if err := db.Create(&models.RequestTransition{
+		RequestID:  req.ReqID,
+		FromStatus: from,
+		ToStatus:   to,
+		ActorID:    actorID,
+		Reason:     reason,
+	}).Error; err != nil {
+		return err
+	}
+	return db.First(req, req.ReqID).Error
+}
+
+// legacyRequestTypes maps the request_type values used before requests had a status
+// column to their kind and status.
+var legacyRequestTypes = map[string][2]string{
+	"Approve":        {KindIssue, StatusApproved},
+	"Reject":         {KindIssue, StatusRejected},
+	"Returned":       {KindIssue, StatusReturned},
+	"ReturnApproved": {KindReturn, StatusApproved},
+	"ReturnRejected": {KindReturn, StatusRejected},
+	"Renew":          {KindRenew, StatusApproved},
+}
+
+// BackfillRequestStatuses converts requests stored with a legacy request_type into a
+// kind and status, and gives every request without a history a starting entry.
+// Legacy rows still carry the column default "Requested"; converted rows no longer
+// match, so running it again is a no-op.
+func BackfillRequestStatuses(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for legacy, target := range legacyRequestTypes {
+			if err := tx.Model(&models.RequestEvent{}).
+				Where("request_type = ? AND status = ?", legacy, StatusRequested).
+				Updates(map[string]interface{}{"request_type": target[0], "status": target[1]}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Exec(`INSERT INTO request_transitions (request_id, from_status, to_status, reason, created_at)
+			SELECT req_id, '', status, ?, CURRENT_TIMESTAMP FROM request_events
+			WHERE NOT EXISTS (SELECT 1 FROM request_transitions rt WHERE rt.request_id = request_events.req_id)`,
+			"Backfilled from legacy request").Error
+	})
+}
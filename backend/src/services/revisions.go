@@ -0,0 +1,210 @@
+// /backend/src/services/revisions.go
+package services
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/swapxs/LibMS/backend/src/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrISBNInUse        = errors.New("another book in the library has this ISBN")
+	ErrRevisionNotFound = errors.New("revision not found")
+	ErrRevisionConflict = errors.New("book has changed since this revision; revert the later revisions first")
+)
+
+// EditableBookFields are the catalog details of a book that can be edited, in the
+// order their changes are recorded. "authors" holds the credits as JSON.
+var EditableBookFields = []string{
+	"isbn", "title", "author", "authors", "publisher", "language", "version",
+	"call_number", "call_number_scheme", "floor", "section", "shelf",
+}
+
+// BookFieldValues returns the current values of a book's editable fields.
+func BookFieldValues(tx *gorm.DB, book models.BookInventory) (map[string]string, error) {
+	links, err := BookCredits(tx, book.ID)
+	if err != nil {
+		return nil, err
+	}
+	credits := make([]AuthorCredit, len(links))
+	for i, link := range links {
+		credits[i] = AuthorCredit{Name: link.Author.Name, Role: link.Role}
+	}
+	return map[string]string{
+		"isbn":               book.ISBN,
+		"title":              book.Title,
+		"author":             book.Author,
+		"authors":            EncodeCredits(credits),
+		"publisher":          book.Publisher,
+		"language":           book.Language,
+		"version":            book.Version,
+		"call_number":        book.CallNumber,
+		"call_number_scheme": book.CallNumberScheme,
+		"floor":              book.Floor,
+		"section":            book.Section,
+		"shelf":              book.Shelf,
+	}, nil
+}
+
+// EncodeCredits is the value credits are recorded with in a revision.
+func EncodeCredits(credits []AuthorCredit) string {
+	encoded, _ := json.Marshal(credits)
+	return string(encoded)
+}
+
+// EditBook sets editable fields of a book to the given values, which the caller has
+// validated: call numbers parsed, credits normalized and the author field matching
+// them. The fields that changed are recorded as a revision by editorID; the
+// revision is nil when nothing changed.
+func EditBook(tx *gorm.DB, book *models.BookInventory, editorID uint, values map[string]string, revertOf *uint) (*models.BookRevision, error) {
+	before, err := BookFieldValues(tx, *book)
+	if err != nil {
+		return nil, err
+	}
+	changed := map[string]string{}
+	for _, field := range EditableBookFields {
+		if value, ok := values[field]; ok && value != before[field] {
+			changed[field] = value
+		}
+	}
+	if len(changed) == 0 {
+		return nil, nil
+	}
+	if err := applyBookChanges(tx, book, changed); err != nil {
+		return nil, err
+	}
+
+	// Record the values as stored, which may differ from those given: author names
+	// resolve to the author's main name.
+	after, err := BookFieldValues(tx, *book)
+	if err != nil {
+		return nil, err
+	}
+	revision := models.BookRevision{BookInventoryID: book.ID, EditorID: editorID, RevertOf: revertOf}
+	for _, field := range EditableBookFields {
+		if before[field] != after[field] {
+			revision.Changes = append(revision.Changes, models.BookChange{Field: field, OldValue: before[field], NewValue: after[field]})
+		}
+	}
+	if len(revision.Changes) == 0 {
+		return nil, nil
+	}
+	if err := tx.Create(&revision).Error; err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// applyBookChanges writes changed fields to a book.
+func applyBookChanges(tx *gorm.DB, book *models.BookInventory, changed map[string]string) error {
+	updates := map[string]interface{}{}
+	for field, value := range changed {
+		if field != "authors" {
+			updates[field] = value
+		}
+	}
+	if isbn, ok := changed["isbn"]; ok {
+		if err := renameBook(tx, *book, isbn); err != nil {
+			return err
+		}
+	}
+	_, cnChanged := changed["call_number"]
+	_, schemeChanged := changed["call_number_scheme"]
+	if cnChanged || schemeChanged {
+		parsed := *book
+		raw, scheme := book.CallNumber, book.CallNumberScheme
+		if cnChanged {
+			raw = changed["call_number"]
+		}
+		if schemeChanged {
+			scheme = changed["call_number_scheme"]
+		}
+		if err := SetCallNumber(&parsed, scheme, raw); err != nil {
+			return err
+		}
+		updates["call_number"], updates["call_number_scheme"], updates["call_number_sort_key"] =
+			parsed.CallNumber, parsed.CallNumberScheme, parsed.CallNumberSortKey
+	}
+	if len(updates) > 0 {
+		if err := tx.Model(book).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+	if encoded, ok := changed["authors"]; ok {
+		var credits []AuthorCredit
+		if err := json.Unmarshal([]byte(encoded), &credits); err != nil {
+			return err
+		}
+		return LinkBookAuthors(tx, book, credits)
+	}
+	return nil
+}
+
+// renameBook checks that a new ISBN is free in the book's library and carries the
+// holds, requests and issues of the book over to it.
+func renameBook(tx *gorm.DB, book models.BookInventory, isbn string) error {
+	var taken int64
+	if err := tx.Unscoped().Model(&models.BookInventory{}).
+		Where("isbn = ? AND library_id = ? AND id <> ?", isbn, book.LibraryID, book.ID).Count(&taken).Error; err != nil {
+		return err
+	}
+	if taken > 0 {
+		return ErrISBNInUse
+	}
+	if err := tx.Unscoped().Model(&models.Hold{}).Where("isbn = ? AND library_id = ?", book.ISBN, book.LibraryID).
+		Update("isbn", isbn).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Model(&models.IssueRegistry{}).Where("isbn = ? AND library_id = ?", book.ISBN, book.LibraryID).
+		Update("isbn", isbn).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.RequestEvent{}).Where("book_id = ?", book.ISBN).
+		Where("reader_id IN (?)", tx.Unscoped().Model(&models.User{}).Select("id").Where("library_id = ?", book.LibraryID)).
+		Update("book_id", isbn).Error
+}
+
+// BookRevisions returns the revisions of a book, latest first.
+func BookRevisions(db *gorm.DB, bookID uint) ([]models.BookRevision, error) {
+	revisions := []models.BookRevision{}
+	err := db.Preload("Changes", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Where("book_inventory_id = ?", bookID).Order("id DESC").Find(&revisions).Error
+	return revisions, err
+}
+
+// RevertRevision sets the fields a revision changed back to their old values,
+// recorded as a new revision. It fails with ErrRevisionConflict when one of the
+// fields has changed again since.
+func RevertRevision(tx *gorm.DB, book *models.BookInventory, revisionID, editorID uint) (*models.BookRevision, error) {
+	var revision models.BookRevision
+	err := tx.Preload("Changes").Where("id = ? AND book_inventory_id = ?", revisionID, book.ID).First(&revision).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRevisionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	current, err := BookFieldValues(tx, *book)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]string{}
+	for _, change := range revision.Changes {
+		if current[change.Field] != change.NewValue {
+			return nil, ErrRevisionConflict
+		}
+		values[change.Field] = change.OldValue
+	}
+	return EditBook(tx, book, editorID, values, &revision.ID)
+}
+
+// deleteBookRevisions removes the history of a book.
+func deleteBookRevisions(tx *gorm.DB, bookID uint) error {
+	revisions := tx.Model(&models.BookRevision{}).Select("id").Where("book_inventory_id = ?", bookID)
+	if err := tx.Where("book_revision_id IN (?)", revisions).Delete(&models.BookChange{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("book_inventory_id = ?", bookID).Delete(&models.BookRevision{}).Error
+}
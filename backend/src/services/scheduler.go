@@ -0,0 +1,46 @@
+// /backend/src/services/scheduler.go
+package services
+
+import (
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StartOverdueScheduler runs SweepOverdue and ExpireRequests once immediately and then
+// every interval in the background. Calling the returned function stops the scheduler.
+func StartOverdueScheduler(db *gorm.DB, interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	ticker := time.NewTicker(interval)
+
+	sweep := func() {
+		marked, err := SweepOverdue(db, time.Now())
+		if err != nil {
+			log.Printf("Overdue sweep failed: %v", err)
+		} else if marked > 0 {
+			log.Printf("Overdue sweep marked %d issue(s) as overdue", marked)
+		}
+		expired, err := ExpireRequests(db, time.Now())
+		if err != nil {
+			log.Printf("Request expiry failed: %v", err)
+		} else if expired > 0 {
+			log.Printf("Request expiry expired %d request(s)", expired)
+		}
+	}
+
+	go func() {
+		defer ticker.Stop()
+		sweep()
+		for {
+			select {
+			case <-ticker.C:
+				sweep()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
@@ -0,0 +1,113 @@
+// /backend/src/services/shelving.go
+package services
+
+import (
+	"strings"
+
+	"github.com/swapxs/LibMS/backend/src/callnumber"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"gorm.io/gorm"
+)
+
+// Location tells a reader where to find a book: its call number and where the title
+// stands, and the shelf of the copy set aside for them, if any.
+type Location struct {
+	CallNumber    string `json:"call_number"`
+	Floor         string `json:"floor"`
+	Section       string `json:"section"`
+	Shelf         string `json:"shelf"`
+	ShelfLocation string `json:"shelf_location,omitempty"`
+}
+
+// BookLocation returns where the copies of a book stand.
+func BookLocation(book models.BookInventory) Location {
+	return Location{CallNumber: book.CallNumber, Floor: book.Floor, Section: book.Section, Shelf: book.Shelf}
+}
+
+// RequestLocation returns where to find the book of a request, down to the shelf of
+// the copy set aside for it once approved.
+func RequestLocation(db *gorm.DB, req models.RequestEvent, libraryID uint) (Location, error) {
+	var book models.BookInventory
+	if err := db.Where("isbn = ? AND library_id = ?", req.BookID, libraryID).First(&book).Error; err != nil {
+		return Location{}, err
+	}
+	location := BookLocation(book)
+	if req.ItemID != nil {
+		var item models.BookItem
+		if err := db.First(&item, *req.ItemID).Error; err != nil {
+			return location, err
+		}
+		location.ShelfLocation = item.ShelfLocation
+	}
+	return location, nil
+}
+
+// SetCallNumber parses a call number of the given scheme (detected when empty) into
+// a book. An empty call number clears it.
+func SetCallNumber(book *models.BookInventory, scheme, raw string) error {
+	if strings.TrimSpace(raw) == "" {
+		book.CallNumber, book.CallNumberScheme, book.CallNumberSortKey = "", "", ""
+		return nil
+	}
+	cn, err := callnumber.Parse(scheme, raw)
+	if err != nil {
+		return err
+	}
+	book.CallNumber, book.CallNumberScheme, book.CallNumberSortKey = cn.Display, cn.Scheme, cn.SortKey
+	return nil
+}
+
+// ShelfListQuery selects part of a library's shelves. Empty fields match anything.
+type ShelfListQuery struct {
+	LibraryID uint
+	Floor     string
+	Section   string
+	Scheme    string // "DDC" or "LCC"
+	From      string // first call number, inclusive
+	To        string // last call number, inclusive of call numbers extending it
+}
+
+// ShelfList returns the books with a call number, in the order they stand on the
+// shelves.
+func ShelfList(db *gorm.DB, q ShelfListQuery) ([]models.BookInventory, error) {
+	query := db.Where("library_id = ? AND call_number_sort_key <> ''", q.LibraryID)
+	if q.Floor != "" {
+		query = query.Where("LOWER(floor) = ?", strings.ToLower(q.Floor))
+	}
+	if q.Section != "" {
+		query = query.Where("LOWER(section) = ?", strings.ToLower(q.Section))
+	}
+	if q.Scheme != "" {
+		query = query.Where("call_number_scheme = ?", strings.ToUpper(q.Scheme))
+	}
+	for _, bound := range []struct {
+		raw, cmp string
+	}{{q.From, ">="}, {q.To, "<="}} {
+		if bound.raw == "" {
+			continue
+		}
+		cn, err := callnumber.Parse(q.Scheme, bound.raw)
+		if err != nil {
+			return nil, err
+		}
+		key := cn.SortKey
+		if bound.cmp == "<=" {
+			// "QA76" also takes in "QA76.73.G63": every key it is a prefix of. Keys
+			// hold spaces, digits and capitals only, all below '~'.
+			key += "~"
+		}
+		query = query.Where(callNumberOrder(db)+" "+bound.cmp+" ?", key)
+	}
+	books := []models.BookInventory{}
+	err := query.Order(callNumberOrder(db) + " ASC, id ASC").Find(&books).Error
+	return books, err
+}
+
+// callNumberOrder is the call number sort key compared byte by byte, which is how
+// keys are built to compare; Postgres would otherwise use the database collation.
+func callNumberOrder(db *gorm.DB) string {
+	if isPostgres(db) {
+		return `call_number_sort_key COLLATE "C"`
+	}
+	return "call_number_sort_key"
+}
@@ -0,0 +1,167 @@
+// /backend/src/services/suggest.go
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/swapxs/LibMS/backend/src/models"
+	"gorm.io/gorm"
+)
+
+// Suggestion limits and the minimum trigram score of a suggestion.
+const (
+	DefaultSuggestLimit = 10
+	MaxSuggestLimit     = 25
+	SuggestThreshold    = 0.3
+)
+
+// Suggestion is a title or author offered while the reader types.
+type Suggestion struct {
+	Text  string  `json:"text"`
+	Field string  `json:"field"` // "title" or "author"
+	Score float64 `json:"score"`
+}
+
+// suggestFields are the catalog columns suggestions are drawn from.
+var suggestFields = []string{"title", "author"}
+
+// trigrams returns the set of trigrams of s the way pg_trgm builds them: each word
+// is lower-cased and padded with two spaces in front and one behind.
+func trigrams(s string) map[string]bool {
+	set := map[string]bool{}
+	for _, word := range searchTerms(s) {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = true
+		}
+	}
+	return set
+}
+
+// WordSimilarity approximates pg_trgm's word_similarity: the share of the query's
+// trigrams that also occur in text. A query that is a prefix of, or a typo away
+// from, a word of text scores high even when text is much longer.
+func WordSimilarity(query, text string) float64 {
+	q := trigrams(query)
+	if len(q) == 0 {
+		return 0
+	}
+	t := trigrams(text)
+	shared := 0
+	for tri := range q {
+		if t[tri] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(q))
+}
+
+// EnsureCatalogSuggest enables pg_trgm and indexes titles and authors for similarity
+// lookups on Postgres. Other databases score suggestions in Go and need nothing.
+func EnsureCatalogSuggest(db *gorm.DB) error {
+	if !isPostgres(db) {
+		return nil
+	}
+	if err := db.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error; err != nil {
+		return err
+	}
+	for _, field := range suggestFields {
+		if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_book_inventories_" + field + "_trgm" +
+			" ON book_inventories USING GIN (LOWER(" + field + ") gin_trgm_ops)").Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SuggestBooks returns the titles and authors of a library's catalog that best match
+// a partly typed and possibly misspelt query, best first.
+func SuggestBooks(db *gorm.DB, libraryID uint, query string, limit int) ([]Suggestion, error) {
+	suggestions := []Suggestion{}
+	query = strings.ToLower(strings.TrimSpace(query))
+	if len(trigrams(query)) == 0 {
+		return suggestions, nil
+	}
+	if limit <= 0 {
+		limit = DefaultSuggestLimit
+	}
+	if limit > MaxSuggestLimit {
+		limit = MaxSuggestLimit
+	}
+
+	for _, field := range suggestFields {
+		var found []Suggestion
+		var err error
+		if isPostgres(db) {
+			found, err = suggestPostgres(db, libraryID, field, query, limit)
+		} else {
+			found, err = suggestFallback(db, libraryID, field, query)
+		}
+		if err != nil {
+			return nil, err
+		}
+		suggestions = append(suggestions, found...)
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		return strings.ToLower(suggestions[i].Text) < strings.ToLower(suggestions[j].Text)
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions, nil
+}
+
+// suggestPostgres ranks the distinct values of field by word_similarity, using the
+// trigram index through the <% operator. field is one of suggestFields; column names
+// cannot be bound, so only those are ever put into the SQL.
+func suggestPostgres(db *gorm.DB, libraryID uint, field, query string, limit int) ([]Suggestion, error) {
+	lowered := "LOWER(" + field + ")"
+	var found []Suggestion
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// SET takes no bind parameters.
+		if err := tx.Exec(fmt.Sprintf("SET LOCAL pg_trgm.word_similarity_threshold = %g", SuggestThreshold)).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.BookInventory{}).
+			Select("MIN("+field+") AS text, MAX(word_similarity(?, "+lowered+")) AS score", query).
+			Where("library_id = ? AND ? <% "+lowered, libraryID, query).
+			Group(lowered).
+			Order("score DESC").
+			Limit(limit).
+			Scan(&found).Error
+	})
+	for i := range found {
+		found[i].Field = field
+	}
+	return found, err
+}
+
+// suggestFallback scores the distinct values of field in Go, for databases without
+// pg_trgm.
+func suggestFallback(db *gorm.DB, libraryID uint, field, query string) ([]Suggestion, error) {
+	var values []string
+	if err := db.Model(&models.BookInventory{}).
+		Where("library_id = ?", libraryID).
+		Distinct().Pluck(field, &values).Error; err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var found []Suggestion
+	for _, value := range values {
+		key := strings.ToLower(value)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if score := WordSimilarity(query, value); score >= SuggestThreshold {
+			found = append(found, Suggestion{Text: value, Field: field, Score: score})
+		}
+	}
+	return found, nil
+}
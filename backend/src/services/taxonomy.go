@@ -0,0 +1,276 @@
+// /backend/src/services/taxonomy.go
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/swapxs/LibMS/backend/src/models"
+	"gorm.io/gorm"
+)
+
+// MaxTagLength is the longest tag a book can carry, in characters.
+const MaxTagLength = 50
+
+var (
+	ErrSubjectNameTaken   = errors.New("a subject with this name already exists under the same parent")
+	ErrSubjectNotFound    = errors.New("subject not found")
+	ErrSubjectCycle       = errors.New("a subject cannot be moved under itself or its descendants")
+	ErrSubjectHasChildren = errors.New("subject has subjects under it; move or delete them first")
+	ErrInvalidTag         = fmt.Errorf("tags must have between 1 and %d characters", MaxTagLength)
+)
+
+// SubjectNode is a subject with the subjects under it. BookCount counts the books
+// filed under the subject or any subject below it.
+type SubjectNode struct {
+	models.Subject
+	BookCount int           `json:"book_count"`
+	Children  []SubjectNode `json:"children"`
+}
+
+// TagCount is a tag with the number of books carrying it.
+type TagCount struct {
+	Tag       string `json:"tag"`
+	BookCount int64  `json:"book_count"`
+}
+
+// NormalizeTag brings a tag into its stored form: lower case, single spaces and
+// no leading '#'.
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.Join(strings.Fields(strings.TrimPrefix(strings.TrimSpace(tag), "#")), " "))
+	if tag == "" || len([]rune(tag)) > MaxTagLength {
+		return "", ErrInvalidTag
+	}
+	return tag, nil
+}
+
+// findSubject loads a subject of the library, or ErrSubjectNotFound.
+func findSubject(tx *gorm.DB, libraryID, id uint) (models.Subject, error) {
+	var subject models.Subject
+	err := tx.Where("id = ? AND library_id = ?", id, libraryID).First(&subject).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = ErrSubjectNotFound
+	}
+	return subject, err
+}
+
+// siblingNameTaken reports whether another subject with the same parent has name,
+// ignoring case.
+func siblingNameTaken(tx *gorm.DB, libraryID uint, parentID *uint, name string, exceptID uint) (bool, error) {
+	query := tx.Model(&models.Subject{}).Where("library_id = ? AND LOWER(name) = ? AND id <> ?", libraryID, strings.ToLower(name), exceptID)
+	if parentID == nil {
+		query = query.Where("parent_id IS NULL")
+	} else {
+		query = query.Where("parent_id = ?", *parentID)
+	}
+	var count int64
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+// parentPath returns the path of the parent a subject is placed under, "/" for the
+// root.
+func parentPath(tx *gorm.DB, libraryID uint, parentID *uint) (string, error) {
+	if parentID == nil {
+		return "/", nil
+	}
+	parent, err := findSubject(tx, libraryID, *parentID)
+	return parent.Path, err
+}
+
+// CreateSubject adds a subject to the library, at the root when parentID is nil.
+func CreateSubject(tx *gorm.DB, libraryID uint, name string, parentID *uint) (models.Subject, error) {
+	subject := models.Subject{LibraryID: libraryID, ParentID: parentID, Name: strings.TrimSpace(name)}
+	base, err := parentPath(tx, libraryID, parentID)
+	if err != nil {
+		return subject, err
+	}
+	if taken, err := siblingNameTaken(tx, libraryID, parentID, subject.Name, 0); err != nil || taken {
+		if taken {
+			err = ErrSubjectNameTaken
+		}
+		return subject, err
+	}
+	// The path holds the subject's own ID, known only once it is saved.
+	subject.Path = base
+	if err := tx.Create(&subject).Error; err != nil {
+		return subject, err
+	}
+	subject.Path = fmt.Sprintf("%s%d/", base, subject.ID)
+	return subject, tx.Model(&subject).Update("path", subject.Path).Error
+}
+
+// UpdateSubject renames a subject and moves it, with everything under it, to a new
+// parent (the root when parentID is nil).
+func UpdateSubject(tx *gorm.DB, subject *models.Subject, name string, parentID *uint) error {
+	name = strings.TrimSpace(name)
+	base, err := parentPath(tx, subject.LibraryID, parentID)
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(base, subject.Path) {
+		return ErrSubjectCycle
+	}
+	if taken, err := siblingNameTaken(tx, subject.LibraryID, parentID, name, subject.ID); err != nil || taken {
+		if taken {
+			err = ErrSubjectNameTaken
+		}
+		return err
+	}
+
+	oldPath := subject.Path
+	newPath := fmt.Sprintf("%s%d/", base, subject.ID)
+	if newPath != oldPath {
+		// Rewrite the prefix of the subject and its descendants.
+		if err := tx.Model(&models.Subject{}).Where("library_id = ? AND path LIKE ?", subject.LibraryID, oldPath+"%").
+			Update("path", gorm.Expr("? || SUBSTR(path, ?)", newPath, len(oldPath)+1)).Error; err != nil {
+			return err
+		}
+	}
+	subject.Name, subject.ParentID, subject.Path = name, parentID, newPath
+	return tx.Model(subject).Select("name", "parent_id").Updates(subject).Error
+}
+
+// DeleteSubject removes a subject without subjects under it, and unfiles its books.
+func DeleteSubject(tx *gorm.DB, subject models.Subject) error {
+	var children int64
+	if err := tx.Model(&models.Subject{}).Where("parent_id = ?", subject.ID).Count(&children).Error; err != nil {
+		return err
+	}
+	if children > 0 {
+		return ErrSubjectHasChildren
+	}
+	if err := tx.Where("subject_id = ?", subject.ID).Delete(&models.BookSubject{}).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Delete(&subject).Error
+}
+
+// SetBookSubjects files a book under exactly the given subjects of its library.
+func SetBookSubjects(tx *gorm.DB, book models.BookInventory, subjectIDs []uint) error {
+	for _, id := range subjectIDs {
+		if _, err := findSubject(tx, book.LibraryID, id); err != nil {
+			return err
+		}
+	}
+	if err := tx.Where("book_inventory_id = ?", book.ID).Delete(&models.BookSubject{}).Error; err != nil {
+		return err
+	}
+	seen := map[uint]bool{}
+	for _, id := range subjectIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if err := tx.Create(&models.BookSubject{BookInventoryID: book.ID, SubjectID: id}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetBookTags replaces the tags of a book.
+func SetBookTags(tx *gorm.DB, book models.BookInventory, tags []string) error {
+	normalized := map[string]bool{}
+	for _, tag := range tags {
+		tag, err := NormalizeTag(tag)
+		if err != nil {
+			return err
+		}
+		normalized[tag] = true
+	}
+	if err := tx.Where("book_inventory_id = ?", book.ID).Delete(&models.BookTag{}).Error; err != nil {
+		return err
+	}
+	for tag := range normalized {
+		if err := tx.Create(&models.BookTag{BookInventoryID: book.ID, Tag: tag}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BookClassification returns the subjects, by path, and the tags, sorted, of a book.
+func BookClassification(db *gorm.DB, bookID uint) ([]models.Subject, []string, error) {
+	subjects := []models.Subject{}
+	err := db.Where("id IN (?)", db.Model(&models.BookSubject{}).Select("subject_id").Where("book_inventory_id = ?", bookID)).
+		Order("path ASC").Find(&subjects).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	tags := []string{}
+	err = db.Model(&models.BookTag{}).Where("book_inventory_id = ?", bookID).Order("tag ASC").Pluck("tag", &tags).Error
+	return subjects, tags, err
+}
+
+// SubjectTree returns the subject hierarchy of a library, siblings sorted by name.
+func SubjectTree(db *gorm.DB, libraryID uint) ([]SubjectNode, error) {
+	var subjects []models.Subject
+	if err := db.Where("library_id = ?", libraryID).Order("name ASC").Find(&subjects).Error; err != nil {
+		return nil, err
+	}
+	var links []models.BookSubject
+	if err := db.Model(&models.BookSubject{}).
+		Joins("JOIN book_inventories ON book_inventories.id = book_subjects.book_inventory_id AND book_inventories.deleted_at IS NULL").
+		Where("book_inventories.library_id = ?", libraryID).Find(&links).Error; err != nil {
+		return nil, err
+	}
+
+	// A book counts once for each subject on the paths of the subjects it is filed under.
+	byID := map[uint]models.Subject{}
+	for _, s := range subjects {
+		byID[s.ID] = s
+	}
+	books := map[uint]map[uint]bool{}
+	for _, link := range links {
+		for _, part := range strings.Split(strings.Trim(byID[link.SubjectID].Path, "/"), "/") {
+			n, err := strconv.ParseUint(part, 10, 64)
+			if err != nil {
+				continue
+			}
+			id := uint(n)
+			if books[id] == nil {
+				books[id] = map[uint]bool{}
+			}
+			books[id][link.BookInventoryID] = true
+		}
+	}
+
+	children := map[uint][]models.Subject{}
+	var roots []models.Subject
+	for _, s := range subjects {
+		if s.ParentID == nil {
+			roots = append(roots, s)
+		} else {
+			children[*s.ParentID] = append(children[*s.ParentID], s)
+		}
+	}
+	var build func([]models.Subject) []SubjectNode
+	build = func(level []models.Subject) []SubjectNode {
+		nodes := make([]SubjectNode, 0, len(level))
+		for _, s := range level {
+			nodes = append(nodes, SubjectNode{Subject: s, BookCount: len(books[s.ID]), Children: build(children[s.ID])})
+		}
+		return nodes
+	}
+	return build(roots), nil
+}
+
+// LibraryTags lists the tags used in a library with the number of books carrying
+// each, most used first.
+func LibraryTags(db *gorm.DB, libraryID uint) ([]TagCount, error) {
+	tags := []TagCount{}
+	err := db.Model(&models.BookTag{}).Select("book_tags.tag, COUNT(*) AS book_count").
+		Joins("JOIN book_inventories ON book_inventories.id = book_tags.book_inventory_id AND book_inventories.deleted_at IS NULL").
+		Where("book_inventories.library_id = ?", libraryID).Group("book_tags.tag").Scan(&tags).Error
+	sort.SliceStable(tags, func(i, j int) bool {
+		if tags[i].BookCount != tags[j].BookCount {
+			return tags[i].BookCount > tags[j].BookCount
+		}
+		return tags[i].Tag < tags[j].Tag
+	})
+	return tags, err
+}
@@ -0,0 +1,157 @@
+// /backend/src/services/trash.go
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/swapxs/LibMS/backend/src/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNotInTrash = errors.New("not in the trash")
+	ErrBookInUse  = errors.New("book has pending requests or holds")
+	ErrUserInUse  = errors.New("user has books out, pending requests, holds or fines outstanding")
+)
+
+// TrashedUser is a deleted account as listed in the trash.
+type TrashedUser struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// TrashedBooks returns the deleted books of a library, most recently deleted first.
+func TrashedBooks(db *gorm.DB, libraryID uint) ([]models.BookInventory, error) {
+	books := []models.BookInventory{}
+	err := db.Unscoped().Where("library_id = ? AND deleted_at IS NOT NULL", libraryID).
+		Order("deleted_at DESC, id DESC").Find(&books).Error
+	return books, err
+}
+
+// TrashedUsers returns the deleted accounts of a library, most recently deleted first.
+func TrashedUsers(db *gorm.DB, libraryID uint) ([]TrashedUser, error) {
+	users := []TrashedUser{}
+	err := db.Unscoped().Model(&models.User{}).Select("id, name, email, role, deleted_at").
+		Where("library_id = ? AND deleted_at IS NOT NULL", libraryID).
+		Order("deleted_at DESC, id DESC").Scan(&users).Error
+	return users, err
+}
+
+// FindTrashedBook returns the deleted book of a library with the given ISBN.
+func FindTrashedBook(db *gorm.DB, libraryID uint, isbn string) (models.BookInventory, error) {
+	var book models.BookInventory
+	err := db.Unscoped().Where("isbn = ? AND library_id = ? AND deleted_at IS NOT NULL", isbn, libraryID).First(&book).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = ErrNotInTrash
+	}
+	return book, err
+}
+
+// FindTrashedUser returns the deleted account of a library with the given ID.
+func FindTrashedUser(db *gorm.DB, libraryID, id uint) (models.User, error) {
+	var user models.User
+	err := db.Unscoped().Where("id = ? AND library_id = ? AND deleted_at IS NOT NULL", id, libraryID).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = ErrNotInTrash
+	}
+	return user, err
+}
+
+// RestoreBook takes a book out of the trash with its details, credits,
+// classification and cover. Its copies stay withdrawn until new ones are added.
+func RestoreBook(tx *gorm.DB, book *models.BookInventory) error {
+	if err := tx.Unscoped().Model(book).Update("deleted_at", nil).Error; err != nil {
+		return err
+	}
+	book.DeletedAt = gorm.DeletedAt{}
+	return nil
+}
+
+// RestoreUser takes an account out of the trash.
+func RestoreUser(tx *gorm.DB, user *models.User) error {
+	if err := tx.Unscoped().Model(user).Update("deleted_at", nil).Error; err != nil {
+		return err
+	}
+	user.DeletedAt = gorm.DeletedAt{}
+	return nil
+}
+
+// CheckBookPurgeable refuses to purge a book readers are still waiting for.
+func CheckBookPurgeable(db *gorm.DB, book models.BookInventory) error {
+	var pending int64
+	if err := db.Model(&models.RequestEvent{}).
+		Where("book_id = ? AND status IN ?", book.ISBN, []string{"Requested", "Approved"}).
+		Where("reader_id IN (?)", db.Model(&models.User{}).Select("id").Where("library_id = ?", book.LibraryID)).
+		Count(&pending).Error; err != nil {
+		return err
+	}
+	var holds int64
+	if err := db.Model(&models.Hold{}).
+		Where("isbn = ? AND library_id = ? AND status IN ?", book.ISBN, book.LibraryID, []string{"Waiting", "Offered"}).
+		Count(&holds).Error; err != nil {
+		return err
+	}
+	if pending > 0 || holds > 0 {
+		return ErrBookInUse
+	}
+	return nil
+}
+
+// PurgeBook deletes a trashed book for good, with its copies, credits and
+// classification, freeing its barcodes, and its revisions. Issue and request history
+// keep the ISBN. Covers are kept in a store and are the caller's to delete.
+func PurgeBook(tx *gorm.DB, book models.BookInventory) error {
+	if err := CheckBookPurgeable(tx, book); err != nil {
+		return err
+	}
+	if err := deleteBookRevisions(tx, book.ID); err != nil {
+		return err
+	}
+	for _, rows := range []any{&models.BookItem{}, &models.BookAuthor{}, &models.BookSubject{}, &models.BookTag{}} {
+		if err := tx.Unscoped().Where("book_inventory_id = ?", book.ID).Delete(rows).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Unscoped().Delete(&models.BookInventory{}, book.ID).Error
+}
+
+// CheckUserRemovable refuses to remove a reader who still has business with the
+// library: books out, requests or holds pending, or fines owed.
+func CheckUserRemovable(db *gorm.DB, user models.User) error {
+	var open int64
+	if err := db.Model(&models.IssueRegistry{}).
+		Where("reader_id = ? AND return_date IS NULL", user.ID).Count(&open).Error; err != nil {
+		return err
+	}
+	var pending int64
+	if err := db.Model(&models.RequestEvent{}).
+		Where("reader_id = ? AND status IN ?", user.ID, []string{"Requested", "Approved"}).Count(&pending).Error; err != nil {
+		return err
+	}
+	var holds int64
+	if err := db.Model(&models.Hold{}).
+		Where("reader_id = ? AND status IN ?", user.ID, []string{"Waiting", "Offered"}).Count(&holds).Error; err != nil {
+		return err
+	}
+	balance, err := OutstandingBalance(db, user.ID, user.LibraryID)
+	if err != nil {
+		return err
+	}
+	if open > 0 || pending > 0 || holds > 0 || balance > 0 {
+		return ErrUserInUse
+	}
+	return nil
+}
+
+// PurgeUser deletes a trashed account for good. Issue, request and fine history
+// keep the reader's ID.
+func PurgeUser(tx *gorm.DB, user models.User) error {
+	if err := CheckUserRemovable(tx, user); err != nil {
+		return err
+	}
+	return tx.Unscoped().Delete(&models.User{}, user.ID).Error
+}
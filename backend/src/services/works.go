@@ -0,0 +1,89 @@
+// /backend/src/services/works.go
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/swapxs/LibMS/backend/src/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrWorkNotFound       = errors.New("work not found")
+	ErrEditionNotFound    = errors.New("book not found in your library")
+	ErrNoEditionAvailable = errors.New("no edition of the work is available for issue")
+)
+
+// FindWork loads a work of the library, or ErrWorkNotFound.
+func FindWork(db *gorm.DB, libraryID, id uint) (models.Work, error) {
+	var work models.Work
+	err := db.Where("id = ? AND library_id = ?", id, libraryID).First(&work).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = ErrWorkNotFound
+	}
+	return work, err
+}
+
+// WorkEditions returns the editions of a work, by language, version and ISBN.
+func WorkEditions(db *gorm.DB, workID uint) ([]models.BookInventory, error) {
+	editions := []models.BookInventory{}
+	err := db.Where("work_id = ?", workID).Order("language ASC, version ASC, isbn ASC").Find(&editions).Error
+	return editions, err
+}
+
+// SetWorkEditions makes the books with the given ISBNs, in stored form, exactly the
+// editions of a work. Books taken from another work leave it.
+func SetWorkEditions(tx *gorm.DB, work models.Work, isbns []string) error {
+	var ids []uint
+	for _, isbn := range isbns {
+		var book models.BookInventory
+		if err := tx.Where("isbn = ? AND library_id = ?", isbn, work.LibraryID).First(&book).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: %s", ErrEditionNotFound, isbn)
+			}
+			return err
+		}
+		ids = append(ids, book.ID)
+	}
+	if err := tx.Model(&models.BookInventory{}).Where("work_id = ?", work.ID).Update("work_id", nil).Error; err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return tx.Model(&models.BookInventory{}).Where("id IN ?", ids).Update("work_id", work.ID).Error
+}
+
+// DeleteWork removes a work; its editions remain in the catalog on their own.
+func DeleteWork(tx *gorm.DB, work models.Work) error {
+	if err := tx.Model(&models.BookInventory{}).Where("work_id = ?", work.ID).Update("work_id", nil).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Delete(&work).Error
+}
+
+// PickEdition chooses the edition of a work to issue to a reader: the one with the
+// most copies free, not counting copies offered to other readers from the hold
+// queue. language, when not empty, restricts the choice to editions in it.
+func PickEdition(db *gorm.DB, work models.Work, readerID uint, language string) (models.BookInventory, error) {
+	query := db.Where("work_id = ? AND library_id = ? AND available_copies > 0", work.ID, work.LibraryID)
+	if language != "" {
+		query = query.Where("LOWER(language) = ?", strings.ToLower(language))
+	}
+	var editions []models.BookInventory
+	if err := query.Order("available_copies DESC, id ASC").Find(&editions).Error; err != nil {
+		return models.BookInventory{}, err
+	}
+	for _, edition := range editions {
+		offered, err := CountOfferedHolds(db, edition.ISBN, work.LibraryID, readerID)
+		if err != nil {
+			return edition, err
+		}
+		if int64(edition.AvailableCopies)-offered >= 1 {
+			return edition, nil
+		}
+	}
+	return models.BookInventory{}, ErrNoEditionAvailable
+}
@@ -0,0 +1,50 @@
+// /backend/src/storage/storage.go
+
+// Package storage keeps binary objects, such as cover images, under string keys:
+// on the local file system or in an S3-compatible object store.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+var (
+	// ErrNotFound is returned by Get for a key with no object.
+	ErrNotFound = errors.New("object not found")
+	// ErrInvalidKey is returned for a key that is empty, absolute or climbs out of
+	// the store with "..".
+	ErrInvalidKey = errors.New("invalid object key")
+)
+
+// Object is an object read from a store. The caller must close it.
+type Object struct {
+	io.ReadCloser
+	ContentType string
+	Size        int64
+}
+
+// Store keeps objects under slash-separated keys ("covers/1/42").
+type Store interface {
+	// Put stores body under key, replacing any object already there.
+	Put(ctx context.Context, key string, body []byte, contentType string) error
+	// Get opens the object under key, or returns ErrNotFound.
+	Get(ctx context.Context, key string) (*Object, error)
+	// Delete removes the object under key. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// checkKey rejects keys that could address something outside the store.
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return ErrInvalidKey
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
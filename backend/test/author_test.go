@@ -0,0 +1,241 @@
+// /backend/test/author_test.go
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/swapxs/LibMS/backend/src/handlers"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"github.com/swapxs/LibMS/backend/src/services"
+	"gorm.io/gorm"
+)
+
+func setupAuthorRouter(db *gorm.DB, claims jwt.MapClaims) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user", claims)
+		c.Next()
+	})
+	r.POST("/books", handlers.AddOrIncrementBook(db, nil))
+	r.PUT("/books/:isbn", handlers.UpdateBook(db))
+	r.GET("/books/search", handlers.SearchBooks(db))
+	r.GET("/authors", handlers.GetAuthors(db))
+	r.GET("/authors/:id", handlers.GetAuthor(db))
+	r.PUT("/authors/:id", handlers.UpdateAuthor(db))
+	r.POST("/authors/:id/merge", handlers.MergeAuthors(db))
+	return r
+}
+
+func addBookBy(t *testing.T, r *gin.Engine, n int, body map[string]interface{}) models.BookInventory {
+	body["isbn"], body["title"], body["language"], body["copies"] = testISBN(n), fmt.Sprintf("Book %d", n), "English", 1
+	w := postJSON(r, "/books", body)
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var resp struct{ Book models.BookInventory }
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return resp.Book
+}
+
+type authorResponse struct {
+	Author models.Author         `json:"author"`
+	Works  []handlers.AuthorWork `json:"works"`
+}
+
+func getAuthor(r *gin.Engine, id uint) (int, authorResponse) {
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/authors/%d", id), nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var resp authorResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return w.Code, resp
+}
+
+func authorByName(t *testing.T, db *gorm.DB, name string) models.Author {
+	var author models.Author
+	assert.NoError(t, db.Where("name = ?", name).First(&author).Error)
+	return author
+}
+
+// TestSplitAuthorNames splits on semicolons, ampersands and "and", never on commas.
+func TestSplitAuthorNames(t *testing.T) {
+	assert.Equal(t, []string{"Kernighan", "Ritchie"}, services.SplitAuthorNames("Kernighan & Ritchie"))
+	assert.Equal(t, []string{"Bloch, Joshua", "Gafter, Neal"}, services.SplitAuthorNames("Bloch, Joshua; Gafter, Neal"))
+	assert.Equal(t, []string{"Abelson", "Sussman"}, services.SplitAuthorNames("Abelson AND Sussman"))
+	assert.Equal(t, []string{"Sandy Anderson"}, services.SplitAuthorNames("Sandy Anderson"))
+	assert.Equal(t, "joshua bloch", services.AuthorKey("  Joshua   BLOCH. "))
+}
+
+// TestAddBook_LinksAuthors credits authors with roles, reuses them across books and
+// keeps the author field.
+func TestAddBook_LinksAuthors(t *testing.T) {
+	db := setupTestDB(t)
+	r := setupAuthorRouter(db, adminClaims())
+
+	book := addBookBy(t, r, 1, map[string]interface{}{"authors": []map[string]string{
+		{"name": "Joshua Bloch"}, {"name": "Jane Roe", "role": "Translator"},
+	}})
+	assert.Equal(t, "Joshua Bloch", book.Author)
+	credits, err := services.BookCredits(db, book.ID)
+	assert.NoError(t, err)
+	assert.Len(t, credits, 2)
+	assert.Equal(t, "translator", credits[1].Role)
+	assert.Equal(t, "Jane Roe", credits[1].Author.Name)
+
+	book = addBookBy(t, r, 2, map[string]interface{}{"author": "joshua bloch & Neal Gafter"})
+	assert.Equal(t, "joshua bloch & Neal Gafter", book.Author)
+	var count int64
+	db.Model(&models.Author{}).Count(&count)
+	assert.Equal(t, int64(3), count)
+
+	// Edited volumes show their editors.
+	book = addBookBy(t, r, 3, map[string]interface{}{"authors": []map[string]string{{"name": "Ed Itor", "role": "editor"}}})
+	assert.Equal(t, "Ed Itor", book.Author)
+
+	w := postJSON(r, "/books", map[string]interface{}{"isbn": testISBN(4), "title": "T", "language": "English", "copies": 1,
+		"authors": []map[string]string{{"name": "X", "role": "illustrator"}}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// TestGetAuthor_ListsWorks lists an author's books with roles and filters the
+// catalog by author, leaving out deleted books.
+func TestGetAuthor_ListsWorks(t *testing.T) {
+	db := setupTestDB(t)
+	r := setupAuthorRouter(db, adminClaims())
+	addBookBy(t, r, 1, map[string]interface{}{"author": "Joshua Bloch"})
+	addBookBy(t, r, 2, map[string]interface{}{"authors": []map[string]string{
+		{"name": "Neal Gafter"}, {"name": "Joshua Bloch"}, {"name": "Joshua Bloch", "role": "editor"},
+	}})
+	gone := addBookBy(t, r, 3, map[string]interface{}{"author": "Joshua Bloch"})
+	db.Delete(&gone)
+	bloch := authorByName(t, db, "Joshua Bloch")
+
+	reader := setupAuthorRouter(db, readerClaims(1))
+	code, resp := getAuthor(reader, bloch.ID)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, resp.Works, 2)
+	assert.Equal(t, testISBN(1), resp.Works[0].Book.ISBN)
+	assert.Equal(t, []string{"author"}, resp.Works[0].Roles)
+	assert.Equal(t, []string{"author", "editor"}, resp.Works[1].Roles)
+
+	req, _ := http.NewRequest("GET", "/authors?q=bloch", nil)
+	w := httptest.NewRecorder()
+	reader.ServeHTTP(w, req)
+	var list struct {
+		Authors []handlers.AuthorSummary `json:"authors"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &list)
+	assert.Len(t, list.Authors, 1)
+	assert.Equal(t, int64(2), list.Authors[0].BookCount)
+
+	code, result := search(t, reader, url.Values{"author_id": {fmt.Sprint(bloch.ID)}})
+	assert.Equal(t, http.StatusOK, code)
+	assert.ElementsMatch(t, []string{testISBN(1), testISBN(2)}, isbns(result.Books))
+
+	code, _ = getAuthor(reader, 999)
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+// TestMergeAuthors folds a name variant into its author and keeps it for later books.
+func TestMergeAuthors(t *testing.T) {
+	db := setupTestDB(t)
+	r := setupAuthorRouter(db, adminClaims())
+	addBookBy(t, r, 1, map[string]interface{}{"author": "Joshua Bloch"})
+	addBookBy(t, r, 2, map[string]interface{}{"author": "J. Bloch; Neal Gafter"})
+	bloch, short := authorByName(t, db, "Joshua Bloch"), authorByName(t, db, "J. Bloch")
+
+	w := postJSON(setupAuthorRouter(db, readerClaims(1)), fmt.Sprintf("/authors/%d/merge", bloch.ID), map[string]uint{"author_id": short.ID})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = postJSON(r, fmt.Sprintf("/authors/%d/merge", bloch.ID), map[string]uint{"author_id": bloch.ID})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = postJSON(r, fmt.Sprintf("/authors/%d/merge", bloch.ID), map[string]uint{"author_id": short.ID})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	_, resp := getAuthor(r, bloch.ID)
+	assert.Len(t, resp.Works, 2)
+	assert.Len(t, resp.Author.Variants, 1)
+	assert.Equal(t, "J. Bloch", resp.Author.Variants[0].Name)
+	var book models.BookInventory
+	db.First(&book, "isbn = ?", testISBN(2))
+	assert.Equal(t, "Joshua Bloch; Neal Gafter", book.Author)
+
+	// Later books naming the variant credit the merged author.
+	book = addBookBy(t, r, 3, map[string]interface{}{"author": "j. bloch"})
+	credits, _ := services.BookCredits(db, book.ID)
+	assert.Equal(t, bloch.ID, credits[0].AuthorID)
+}
+
+// TestUpdateAuthor renames an author, replaces variants and refuses names of others.
+func TestUpdateAuthor(t *testing.T) {
+	db := setupTestDB(t)
+	r := setupAuthorRouter(db, adminClaims())
+	addBookBy(t, r, 1, map[string]interface{}{"author": "Bloch, Joshua & Neal Gafter"})
+	bloch := authorByName(t, db, "Bloch, Joshua")
+
+	w := putJSON(r, fmt.Sprintf("/authors/%d", bloch.ID), map[string]interface{}{"name": "Joshua Bloch", "variants": []string{"Bloch, Joshua", "J. Bloch"}})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var book models.BookInventory
+	db.First(&book, "isbn = ?", testISBN(1))
+	assert.Equal(t, "Joshua Bloch; Neal Gafter", book.Author)
+	_, resp := getAuthor(r, bloch.ID)
+	assert.Len(t, resp.Author.Variants, 2)
+
+	w = putJSON(r, fmt.Sprintf("/authors/%d", bloch.ID), map[string]interface{}{"name": "neal gafter"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	gafter := authorByName(t, db, "Neal Gafter")
+	w = putJSON(r, fmt.Sprintf("/authors/%d", gafter.ID), map[string]interface{}{"name": "Neal Gafter", "variants": []string{"J. Bloch"}})
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+// TestUpdateBook_ReplacesCredits relinks a book when its authors or author field
+// change.
+func TestUpdateBook_ReplacesCredits(t *testing.T) {
+	db := setupTestDB(t)
+	r := setupAuthorRouter(db, adminClaims())
+	book := addBookBy(t, r, 1, map[string]interface{}{"author": "Someone"})
+
+	w := putJSON(r, "/books/"+testISBN(1), map[string]interface{}{"authors": []map[string]string{{"name": "Ann"}, {"name": "Bob", "role": "translator"}}})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	db.First(&book, book.ID)
+	assert.Equal(t, "Ann", book.Author)
+	credits, _ := services.BookCredits(db, book.ID)
+	assert.Len(t, credits, 2)
+
+	w = putJSON(r, "/books/"+testISBN(1), map[string]interface{}{"author": "Cy and Di"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	db.First(&book, book.ID)
+	assert.Equal(t, "Cy and Di", book.Author)
+	credits, _ = services.BookCredits(db, book.ID)
+	assert.Equal(t, "Cy", credits[0].Author.Name)
+	assert.Equal(t, "Di", credits[1].Author.Name)
+
+	w = putJSON(r, "/books/"+testISBN(1), map[string]interface{}{"authors": []map[string]string{{"role": "author"}}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// TestBackfillBookAuthors links books from before author records once, keeping
+// their author field.
+func TestBackfillBookAuthors(t *testing.T) {
+	db := setupTestDB(t)
+	book := models.BookInventory{ISBN: testISBN(1), LibraryID: 1, Title: "C", Author: "Kernighan & Ritchie", Language: "English"}
+	assert.NoError(t, db.Create(&book).Error)
+
+	linked, err := services.BackfillBookAuthors(db)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, linked)
+	credits, _ := services.BookCredits(db, book.ID)
+	assert.Len(t, credits, 2)
+	db.First(&book, book.ID)
+	assert.Equal(t, "Kernighan & Ritchie", book.Author)
+
+	linked, err = services.BackfillBookAuthors(db)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, linked)
+}
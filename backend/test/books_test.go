@@ -19,7 +19,7 @@ func TestAddOrIncrementBook(t *testing.T) {
 	db := setupTestDB(t)
 	
 	book := models.BookInventory{
-		ISBN:            "12345",
+		ISBN:            testISBN(1),
 		LibraryID:       1,
 		Title:           "Golang Book",
 		Author:          "John Doe",
@@ -44,10 +44,10 @@ func TestAddOrIncrementBook(t *testing.T) {
 		c.Set("user", claims)
 		c.Next()
 	})
-	r.POST("/books", handlers.AddOrIncrementBook(db))
+	r.POST("/books", handlers.AddOrIncrementBook(db, nil))
 
 	payload, _ := json.Marshal(map[string]any{
-		"isbn":     "12345",
+		"isbn":     testISBN(1),
 		"title":    "Golang Book",
 		"author":   "John Doe",
 		"copies":   5,
@@ -62,7 +62,7 @@ func TestAddOrIncrementBook(t *testing.T) {
 	assert.Equal(t, http.StatusCreated, w.Code)
 
 	var updatedBook models.BookInventory
-	err := db.First(&updatedBook, "isbn = ? AND library_id = ?", "12345", 1).Error
+	err := db.First(&updatedBook, "isbn = ? AND library_id = ?", testISBN(1), 1).Error
 	assert.NoError(t, err)
 	assert.Equal(t, 15, updatedBook.TotalCopies)
 	assert.Equal(t, 15, updatedBook.AvailableCopies)
@@ -74,7 +74,7 @@ func TestGetBookByISBN_Success(t *testing.T) {
 
     // Seed a test book
     book := models.BookInventory{
-        ISBN:            "get-isbn",
+        ISBN:            testISBN(123),
         LibraryID:       1,
         Title:           "Fetch Me",
         Author:          "Test Author",
@@ -103,7 +103,7 @@ func TestGetBookByISBN_Success(t *testing.T) {
 
     r.GET("/books/:isbn", handlers.GetBooks(db))
 
-    req, _ := http.NewRequest("GET", "/books/get-isbn", nil)
+    req, _ := http.NewRequest("GET", "/books/"+testISBN(123), nil)
     w := httptest.NewRecorder()
     r.ServeHTTP(w, req)
 
@@ -181,7 +181,7 @@ func TestRemoveBook_WithActiveIssues_Fails(t *testing.T) {
 	db := setupTestDB(t)
 
 	book := models.BookInventory{
-		ISBN:            "issued-isbn",
+		ISBN:            testISBN(105),
 		LibraryID:       1,
 		Title:           "Cannot Delete",
 		TotalCopies:     2,
@@ -204,7 +204,7 @@ func TestRemoveBook_WithActiveIssues_Fails(t *testing.T) {
 	r.POST("/books/remove", handlers.RemoveBook(db))
 
 	payload, _ := json.Marshal(map[string]any{
-		"isbn":   "issued-isbn",
+		"isbn":   testISBN(105),
 		"copies": 2,
 	})
 	req, _ := http.NewRequest("POST", "/books/remove", bytes.NewBuffer(payload))
@@ -244,6 +244,14 @@ func TestUpdateBook_InvalidISBN_Fails(t *testing.T) {
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
 
+	// A malformed ISBN is refused before the lookup.
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// A valid ISBN that is not in the library is not found.
+	req, _ = http.NewRequest("PUT", "/books/"+testISBN(999), bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
 	assert.Equal(t, http.StatusNotFound, w.Code)
 }
 
@@ -263,10 +271,10 @@ func TestAddBook_MissingFields_Fails(t *testing.T) {
 		c.Next()
 	})
 
-	r.POST("/books", handlers.AddOrIncrementBook(db))
+	r.POST("/books", handlers.AddOrIncrementBook(db, nil))
 
 	payload, _ := json.Marshal(map[string]any{
-		"isbn":    "no-title-book",
+		"isbn":    testISBN(106),
 		"copies":  3,
 	})
 	req, _ := http.NewRequest("POST", "/books", bytes.NewBuffer(payload))
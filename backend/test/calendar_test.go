@@ -0,0 +1,176 @@
+// /backend/test/calendar_test.go
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/swapxs/LibMS/backend/src/handlers"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"github.com/swapxs/LibMS/backend/src/services"
+	"gorm.io/gorm"
+)
+
+func setupCalendarRouter(db *gorm.DB, claims jwt.MapClaims) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/libraries/:id/calendar", handlers.GetLibraryCalendar(db))
+	r.Use(func(c *gin.Context) {
+		c.Set("user", claims)
+		c.Next()
+	})
+	r.POST("/library/holidays", handlers.AddHoliday(db))
+	r.PUT("/library/hours", handlers.UpdateOpeningHours(db))
+	r.GET("/library/closures", handlers.GetClosures(db))
+	r.POST("/library/closures", handlers.AddClosure(db))
+	r.DELETE("/library/closures/:id", handlers.DeleteClosure(db))
+	return r
+}
+
+// weekdayHours opens the library from Monday to Friday.
+func weekdayHours() map[string]any {
+	var hours []map[string]any
+	for day := 1; day <= 5; day++ {
+		hours = append(hours, map[string]any{"weekday": day, "opens": "09:00", "closes": "17:00"})
+	}
+	return map[string]any{"hours": hours}
+}
+
+// TestCalendar_HoursAndClosures skips weekends and closures when looking for the
+// next open day.
+func TestCalendar_HoursAndClosures(t *testing.T) {
+	db := setupTestDB(t)
+	admin := setupCalendarRouter(db, adminClaims())
+	saturday := time.Date(2030, 6, 1, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Saturday, saturday.Weekday())
+
+	w := putJSON(admin, "/library/hours", weekdayHours())
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	next, err := services.NextOpenDay(db, 1, saturday)
+	assert.NoError(t, err)
+	assert.Equal(t, saturday.AddDate(0, 0, 2), next)
+
+	w = postJSON(admin, "/library/closures", map[string]any{"start_date": "2030-06-03", "end_date": "2030-06-05", "reason": "Repairs"})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	next, err = services.NextOpenDay(db, 1, saturday)
+	assert.NoError(t, err)
+	assert.Equal(t, saturday.AddDate(0, 0, 5), next)
+
+	// Clearing the weekly hours opens the library every day again.
+	assert.Equal(t, http.StatusOK, putJSON(admin, "/library/hours", map[string]any{"hours": []any{}}).Code)
+	next, err = services.NextOpenDay(db, 1, saturday)
+	assert.NoError(t, err)
+	assert.Equal(t, saturday, next)
+
+	var closure models.LibraryClosure
+	assert.NoError(t, db.First(&closure).Error)
+	req, _ := http.NewRequest("DELETE", "/library/closures/"+strconv.Itoa(int(closure.ID)), nil)
+	w = httptest.NewRecorder()
+	admin.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var count int64
+	db.Model(&models.LibraryClosure{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+// TestCalendar_Validation refuses malformed schedules and closures.
+func TestCalendar_Validation(t *testing.T) {
+	db := setupTestDB(t)
+	admin := setupCalendarRouter(db, adminClaims())
+	reader := setupCalendarRouter(db, readerClaims(1))
+
+	assert.Equal(t, http.StatusUnauthorized, putJSON(reader, "/library/hours", weekdayHours()).Code)
+	assert.Equal(t, http.StatusUnauthorized, postJSON(reader, "/library/closures", map[string]any{"start_date": "2030-06-03", "end_date": "2030-06-05"}).Code)
+
+	bad := []map[string]any{
+		{"weekday": 7, "opens": "09:00", "closes": "17:00"},
+		{"weekday": 1, "opens": "9am", "closes": "17:00"},
+		{"weekday": 1, "opens": "17:00", "closes": "09:00"},
+	}
+	for _, h := range bad {
+		assert.Equal(t, http.StatusBadRequest, putJSON(admin, "/library/hours", map[string]any{"hours": []any{h}}).Code, h)
+	}
+	dup := []any{
+		map[string]any{"weekday": 1, "opens": "09:00", "closes": "12:00"},
+		map[string]any{"weekday": 1, "opens": "13:00", "closes": "17:00"},
+	}
+	assert.Equal(t, http.StatusBadRequest, putJSON(admin, "/library/hours", map[string]any{"hours": dup}).Code)
+
+	assert.Equal(t, http.StatusBadRequest, postJSON(admin, "/library/closures", map[string]any{"start_date": "2030-06-05", "end_date": "2030-06-03"}).Code)
+	assert.Equal(t, http.StatusBadRequest, postJSON(admin, "/library/closures", map[string]any{"start_date": "June 3", "end_date": "2030-06-05"}).Code)
+}
+
+// TestGetLibraryCalendar_Public lists each day of the range as open or closed.
+func TestGetLibraryCalendar_Public(t *testing.T) {
+	db := setupTestDB(t)
+	db.Create(&models.Library{Name: "Calendar Library"})
+	admin := setupCalendarRouter(db, adminClaims())
+	putJSON(admin, "/library/hours", weekdayHours())
+	postJSON(admin, "/library/closures", map[string]any{"start_date": "2030-06-03", "end_date": "2030-06-04"})
+	postJSON(admin, "/library/holidays", map[string]any{"date": "2030-06-05", "name": "Founders Day"})
+
+	req, _ := http.NewRequest("GET", "/libraries/1/calendar?from=2030-06-01&to=2030-06-07", nil)
+	w := httptest.NewRecorder()
+	// The calendar is public: no claims are needed.
+	r := gin.New()
+	r.GET("/libraries/:id/calendar", handlers.GetLibraryCalendar(db))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp struct {
+		Hours    []models.LibraryOpeningHours `json:"hours"`
+		Holidays []models.LibraryHoliday      `json:"holidays"`
+		Closures []models.LibraryClosure      `json:"closures"`
+		Days     []handlers.CalendarDay       `json:"days"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Len(t, resp.Hours, 5)
+	assert.Len(t, resp.Holidays, 1)
+	assert.Len(t, resp.Closures, 1)
+	var open []bool
+	for _, d := range resp.Days {
+		open = append(open, d.Open)
+	}
+	assert.Equal(t, []bool{false, false, false, false, false, true, true}, open)
+	assert.Equal(t, "2030-06-07", resp.Days[6].Date)
+
+	for url, code := range map[string]int{
+		"/libraries/2/calendar":                               http.StatusNotFound,
+		"/libraries/1/calendar?from=2030-06-07&to=2030-06-01": http.StatusBadRequest,
+		"/libraries/1/calendar?from=2030-01-01&to=2032-01-01": http.StatusBadRequest,
+		"/libraries/1/calendar?from=01-06-2030":               http.StatusBadRequest,
+	} {
+		req, _ := http.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, code, w.Code, url)
+	}
+}
+
+// TestSweepOverdue_SkipsClosedDays only charges fines for days the library is open.
+func TestSweepOverdue_SkipsClosedDays(t *testing.T) {
+	db := setupTestDB(t)
+	db.Create(&models.FinePolicy{LibraryID: 1, DailyRateCents: 100})
+	putJSON(setupCalendarRouter(db, adminClaims()), "/library/hours", weekdayHours())
+
+	friday := time.Date(2030, 6, 7, 12, 0, 0, 0, time.UTC)
+	issue := models.IssueRegistry{
+		ISBN: testISBN(124), ReaderID: 1, IssueApproverID: 99, IssueStatus: "Issued",
+		ExpectedReturnDate: friday, LibraryID: 1,
+	}
+	db.Create(&issue)
+
+	// Saturday, Sunday and Monday have passed; only Monday is charged.
+	_, err := services.SweepOverdue(db, friday.AddDate(0, 0, 3).Add(time.Hour))
+	assert.NoError(t, err)
+	var fine models.Fine
+	assert.NoError(t, db.First(&fine, "issue_id = ?", issue.ID).Error)
+	assert.Equal(t, int64(100), fine.AccruedCents)
+}
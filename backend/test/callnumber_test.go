@@ -0,0 +1,136 @@
+// /backend/test/callnumber_test.go
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/swapxs/LibMS/backend/src/callnumber"
+	"github.com/swapxs/LibMS/backend/src/handlers"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"gorm.io/gorm"
+)
+
+func setupShelfRouter(db *gorm.DB, claims jwt.MapClaims) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user", claims)
+		c.Next()
+	})
+	r.POST("/books", handlers.AddOrIncrementBook(db, nil))
+	r.PUT("/books/:isbn", handlers.UpdateBook(db))
+	r.GET("/books", handlers.GetBooks(db))
+	r.GET("/books/search", handlers.SearchBooks(db))
+	r.GET("/books/shelflist", handlers.GetShelfList(db))
+	r.POST("/requestEvents", handlers.RaiseRequest(db))
+	return r
+}
+
+// assertShelfOrder checks that sorting call numbers by key keeps them in the order
+// given.
+func assertShelfOrder(t *testing.T, scheme string, inShelfOrder []string) {
+	keys := make([]string, len(inShelfOrder))
+	byKey := map[string]string{}
+	for i, raw := range inShelfOrder {
+		cn, err := callnumber.Parse(scheme, raw)
+		assert.NoError(t, err, raw)
+		keys[i] = cn.SortKey
+		byKey[cn.SortKey] = raw
+	}
+	sort.Strings(keys)
+	sorted := make([]string, len(keys))
+	for i, key := range keys {
+		sorted[i] = byKey[key]
+	}
+	assert.Equal(t, inShelfOrder, sorted)
+}
+
+// TestCallNumberSortKeys orders Dewey and LC call numbers as on the shelves, where
+// class numbers and cutters are decimals.
+func TestCallNumberSortKeys(t *testing.T) {
+	assertShelfOrder(t, callnumber.DDC, []string{
+		"005.133 S", "005.2", "823 T649", "823.9 A1", "823.912 T649L 1954", "823.912 T65", "823.92 B",
+	})
+	assertShelfOrder(t, callnumber.LCC, []string{
+		"P35 .B5", "PS3537.A426 C3 1951", "QA76 .G6", "QA76.73.G63 D66 2016", "QA76.73.G63 D7", "QA76.9 .D3", "QA767 .A1",
+	})
+
+	cn, err := callnumber.Parse("", " qa76.73.g63   d66 2016 ")
+	assert.NoError(t, err)
+	assert.Equal(t, callnumber.LCC, cn.Scheme)
+	assert.Equal(t, "QA76.73.G63 D66 2016", cn.Display)
+	cn, err = callnumber.Parse("", "823.9/12 T649L")
+	assert.NoError(t, err)
+	assert.Equal(t, callnumber.DDC, cn.Scheme)
+
+	for scheme, raw := range map[string]string{"": "-823", "DDC": "82.3", "LCC": "QABC12", "UDC": "821.111"} {
+		_, err := callnumber.Parse(scheme, raw)
+		assert.Error(t, err, raw)
+	}
+	_, err = callnumber.Parse(callnumber.DDC, "823 T-6")
+	assert.ErrorIs(t, err, callnumber.ErrInvalid)
+}
+
+// TestShelfList adds books with call numbers and locations and lists them in shelf
+// order, by floor and by range.
+func TestShelfList(t *testing.T) {
+	db := setupTestDB(t)
+	r := setupShelfRouter(db, adminClaims())
+	shelved := []struct {
+		callNumber, floor string
+	}{{"QA76.73.G63 D66", "2"}, {"823.912 T649L", "1"}, {"QA76.9 .D3", "2"}, {"005.133 S", "1"}, {"", "1"}}
+	for i, s := range shelved {
+		w := postJSON(r, "/books", map[string]any{
+			"isbn": testISBN(i + 1), "title": "Book", "author": "Author", "language": "English", "copies": 1,
+			"call_number": s.callNumber, "floor": s.floor, "section": "Stacks", "shelf": "A",
+		})
+		assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+	w := postJSON(r, "/books", map[string]any{"isbn": testISBN(9), "title": "Bad", "author": "A", "language": "English", "copies": 1, "call_number": "823", "call_number_scheme": "UDC"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var list struct {
+		Books []models.BookInventory `json:"books"`
+	}
+	assert.Equal(t, http.StatusOK, getJSON(r, "/books/shelflist", &list))
+	assert.Equal(t, []string{testISBN(4), testISBN(2), testISBN(1), testISBN(3)}, isbns(list.Books))
+	assert.Equal(t, "DDC", list.Books[0].CallNumberScheme)
+	getJSON(r, "/books/shelflist?floor=2", &list)
+	assert.Equal(t, []string{testISBN(1), testISBN(3)}, isbns(list.Books))
+	getJSON(r, "/books/shelflist?"+url.Values{"from": {"800"}, "to": {"QA76.73"}}.Encode(), &list)
+	assert.Equal(t, []string{testISBN(2), testISBN(1)}, isbns(list.Books))
+	assert.Equal(t, http.StatusBadRequest, getJSON(r, "/books/shelflist?from=QABC1", &list))
+
+	// Search pages through the catalog in call number order; books without one first.
+	code, page := search(t, r, url.Values{"sort": {"call_number"}, "limit": {"3"}})
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{testISBN(5), testISBN(4), testISBN(2)}, isbns(page.Books))
+	code, page = search(t, r, url.Values{"sort": {"call_number"}, "limit": {"3"}, "cursor": {page.NextCursor}})
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{testISBN(1), testISBN(3)}, isbns(page.Books))
+
+	// Updating the call number reparses it.
+	w = putJSON(r, "/books/"+testISBN(5), map[string]any{"call_number": "a1 .b2"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	getJSON(r, "/books/shelflist?scheme=lcc", &list)
+	assert.Equal(t, []string{testISBN(5), testISBN(1), testISBN(3)}, isbns(list.Books))
+	assert.Equal(t, "A1 .B2", list.Books[0].CallNumber)
+	w = putJSON(r, "/books/"+testISBN(5), map[string]any{"call_number": "823", "call_number_scheme": "LCC"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Readers are told where to find the book they request.
+	w = postJSON(setupShelfRouter(db, readerClaims(1)), "/requestEvents", map[string]any{"bookID": testISBN(2)})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var resp struct {
+		Location map[string]string `json:"location"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, map[string]string{"call_number": "823.912 T649L", "floor": "1", "section": "Stacks", "shelf": "A"}, resp.Location)
+}
@@ -0,0 +1,148 @@
+// /backend/test/cancel_request_test.go
+package handlers_test
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/swapxs/LibMS/backend/src/handlers"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"github.com/swapxs/LibMS/backend/src/services"
+	"gorm.io/gorm"
+)
+
+func setupCancelRouter(db *gorm.DB, claims jwt.MapClaims) *gin.Engine {
+	r := setupRequestStateRouter(db, claims)
+	r.POST("/requestEvents/:id/cancel", handlers.CancelRequest(db))
+	return r
+}
+
+func seedCancelReader(t *testing.T, db *gorm.DB, email string) models.User {
+	user := models.User{Name: "R", Email: email, Password: "x", ContactNumber: "1", Role: "Reader", LibraryID: 1}
+	assert.NoError(t, db.Create(&user).Error)
+	return user
+}
+
+func seedCancelBook(t *testing.T, db *gorm.DB, isbn string, copies int) models.BookInventory {
+	book := models.BookInventory{
+		ISBN: isbn, LibraryID: 1, Title: "Cancel", Author: "A", Publisher: "P",
+		Language: "English", Version: "v1",
+	}
+	assert.NoError(t, db.Create(&book).Error)
+	assert.NoError(t, services.AddBookItems(db, &book, copies, nil, "", ""))
+	return book
+}
+
+func cancelURL(reqID uint) string {
+	return "/requestEvents/" + strconv.Itoa(int(reqID)) + "/cancel"
+}
+
+// TestCancelRequest_ReaderCancelsOwn cancels a pending request, records it in the
+// history and frees a slot under the active request limit.
+func TestCancelRequest_ReaderCancelsOwn(t *testing.T) {
+	db := setupTestDB(t)
+	readerUser := seedCancelReader(t, db, "cancel-reader@example.com")
+	reader := setupCancelRouter(db, readerClaims(readerUser.ID))
+	for i := 0; i < 5; i++ {
+		seedCancelBook(t, db, testISBN(i), 1)
+	}
+	for i := 0; i < 4; i++ {
+		w := postJSON(reader, "/requestEvents", map[string]any{"bookID": testISBN(i)})
+		assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+	assert.Equal(t, http.StatusForbidden, postJSON(reader, "/requestEvents", map[string]any{"bookID": testISBN(4)}).Code)
+
+	var req models.RequestEvent
+	assert.NoError(t, db.First(&req, "book_id = ?", testISBN(0)).Error)
+	w := postJSON(reader, cancelURL(req.ReqID), map[string]any{"reason": "changed my mind"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	db.First(&req, req.ReqID)
+	assert.Equal(t, "Cancelled", req.Status)
+
+	_, history := getHistory(reader, req.ReqID)
+	last := history[len(history)-1]
+	assert.Equal(t, "Requested", last.FromStatus)
+	assert.Equal(t, "Cancelled", last.ToStatus)
+	assert.Equal(t, "changed my mind", last.Reason)
+	assert.Equal(t, readerUser.ID, *last.ActorID)
+
+	assert.Equal(t, http.StatusCreated, postJSON(reader, "/requestEvents", map[string]any{"bookID": testISBN(4)}).Code)
+
+	// A cancelled request cannot be cancelled again.
+	assert.Equal(t, http.StatusConflict, postJSON(reader, cancelURL(req.ReqID), nil).Code)
+}
+
+// TestCancelRequest_ReleasesApprovedCopy puts the copy set aside for a request that
+// was approved but never handed out back on the shelf.
+func TestCancelRequest_ReleasesApprovedCopy(t *testing.T) {
+	db := setupTestDB(t)
+	readerUser := seedCancelReader(t, db, "cancel-approved@example.com")
+	book := seedCancelBook(t, db, testISBN(100), 1)
+	reader := setupCancelRouter(db, readerClaims(readerUser.ID))
+
+	assert.Equal(t, http.StatusCreated, postJSON(reader, "/requestEvents", map[string]any{"bookID": book.ISBN}).Code)
+	var req models.RequestEvent
+	assert.NoError(t, db.First(&req).Error)
+	item, err := services.CheckoutItem(db, &book, "")
+	assert.NoError(t, err)
+	assert.NoError(t, services.TransitionRequest(db, &req, "Approved", nil, "", map[string]interface{}{"item_id": item.ID}))
+	db.First(&book, book.ID)
+	assert.Equal(t, 0, book.AvailableCopies)
+
+	w := postJSON(reader, cancelURL(req.ReqID), nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	db.First(&book, book.ID)
+	assert.Equal(t, 1, book.AvailableCopies)
+	assert.NoError(t, db.First(&item, item.ID).Error)
+	assert.Equal(t, "Available", item.Status)
+}
+
+// TestCancelRequest_AlreadyIssued refuses to cancel a request whose copy is on loan.
+func TestCancelRequest_AlreadyIssued(t *testing.T) {
+	db := setupTestDB(t)
+	readerUser := seedCancelReader(t, db, "cancel-issued@example.com")
+	book := seedCancelBook(t, db, testISBN(101), 1)
+	reader := setupCancelRouter(db, readerClaims(readerUser.ID))
+	admin := setupCancelRouter(db, adminClaims())
+
+	assert.Equal(t, http.StatusCreated, postJSON(reader, "/requestEvents", map[string]any{"bookID": book.ISBN}).Code)
+	var req models.RequestEvent
+	assert.NoError(t, db.First(&req).Error)
+	w := putJSON(admin, "/issueRequests/"+strconv.Itoa(int(req.ReqID)), map[string]any{"request_type": "Approve"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	assert.Equal(t, http.StatusConflict, postJSON(reader, cancelURL(req.ReqID), nil).Code)
+
+	// An approval from before issues were created automatically may be on loan too.
+	assert.NoError(t, db.Model(&models.RequestEvent{}).Where("req_id = ?", req.ReqID).Update("status", "Approved").Error)
+	assert.Equal(t, http.StatusConflict, postJSON(reader, cancelURL(req.ReqID), nil).Code)
+	db.First(&book, book.ID)
+	assert.Equal(t, 0, book.AvailableCopies)
+}
+
+// TestCancelRequest_Ownership hides other readers' requests and lets staff cancel on
+// a reader's behalf.
+func TestCancelRequest_Ownership(t *testing.T) {
+	db := setupTestDB(t)
+	owner := seedCancelReader(t, db, "cancel-owner@example.com")
+	other := seedCancelReader(t, db, "cancel-other@example.com")
+	book := seedCancelBook(t, db, testISBN(102), 1)
+
+	assert.Equal(t, http.StatusCreated, postJSON(setupCancelRouter(db, readerClaims(owner.ID)), "/requestEvents", map[string]any{"bookID": book.ISBN}).Code)
+	var req models.RequestEvent
+	assert.NoError(t, db.First(&req).Error)
+
+	assert.Equal(t, http.StatusNotFound, postJSON(setupCancelRouter(db, readerClaims(other.ID)), cancelURL(req.ReqID), nil).Code)
+	assert.Equal(t, http.StatusNotFound, postJSON(setupCancelRouter(db, readerClaims(owner.ID)), cancelURL(req.ReqID+100), nil).Code)
+
+	w := postJSON(setupCancelRouter(db, adminClaims()), cancelURL(req.ReqID), map[string]any{"reason": "duplicate"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var last models.RequestTransition
+	assert.NoError(t, db.Order("id DESC").First(&last, "request_id = ?", req.ReqID).Error)
+	assert.Equal(t, "Cancelled", last.ToStatus)
+	assert.Equal(t, uint(99), *last.ActorID)
+}
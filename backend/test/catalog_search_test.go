@@ -0,0 +1,178 @@
+// /backend/test/catalog_search_test.go
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/swapxs/LibMS/backend/src/handlers"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"github.com/swapxs/LibMS/backend/src/services"
+	"gorm.io/gorm"
+)
+
+func setupCatalogSearchRouter(db *gorm.DB, claims jwt.MapClaims) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user", claims)
+		c.Next()
+	})
+	r.GET("/books/search", handlers.SearchBooks(db))
+	return r
+}
+
+// seedCatalog creates a small catalog in library 1 and one book in library 2.
+func seedCatalog(t *testing.T, db *gorm.DB) {
+	books := []models.BookInventory{
+		{ISBN: "cat-1", LibraryID: 1, Title: "The Go Programming Language", Author: "Donovan", Publisher: "Addison-Wesley", Language: "English", Version: "1", TotalCopies: 2, AvailableCopies: 2},
+		{ISBN: "cat-2", LibraryID: 1, Title: "Concurrency in Practice", Author: "Goetz", Publisher: "Addison-Wesley", Language: "English", Version: "1", TotalCopies: 1, AvailableCopies: 0},
+		{ISBN: "cat-3", LibraryID: 1, Title: "Learning Go", Author: "Bodner", Publisher: "O'Reilly", Language: "English", Version: "1", TotalCopies: 1, AvailableCopies: 1},
+		{ISBN: "cat-4", LibraryID: 1, Title: "Le Petit Prince", Author: "Saint-Exupery", Publisher: "Gallimard", Language: "French", Version: "1", TotalCopies: 1, AvailableCopies: 1},
+		{ISBN: "cat-5", LibraryID: 1, Title: "Distributed Systems", Author: "Gopal", Publisher: "Go Press", Language: "English", Version: "1", TotalCopies: 1, AvailableCopies: 1},
+		{ISBN: "cat-6", LibraryID: 2, Title: "Go in Action", Author: "Kennedy", Publisher: "Manning", Language: "English", Version: "1", TotalCopies: 1, AvailableCopies: 1},
+	}
+	for i := range books {
+		assert.NoError(t, db.Create(&books[i]).Error)
+	}
+}
+
+func search(t *testing.T, r *gin.Engine, params url.Values) (int, services.BookSearchResult) {
+	req, _ := http.NewRequest("GET", "/books/search?"+params.Encode(), nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var resp services.BookSearchResult
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return w.Code, resp
+}
+
+func isbns(books []models.BookInventory) []string {
+	var out []string
+	for _, b := range books {
+		out = append(out, b.ISBN)
+	}
+	return out
+}
+
+// TestSearchBooks_KeywordsAndRelevance matches every keyword across title, author
+// and publisher and ranks title matches first.
+func TestSearchBooks_KeywordsAndRelevance(t *testing.T) {
+	db := setupTestDB(t)
+	seedCatalog(t, db)
+	r := setupCatalogSearchRouter(db, readerClaims(1))
+
+	code, resp := search(t, r, url.Values{"q": {"go"}})
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, int64(4), resp.Total)
+	// A title match outweighs an author match alone; ties keep insertion order.
+	assert.Equal(t, []string{"cat-1", "cat-3", "cat-5", "cat-2"}, isbns(resp.Books))
+
+	_, resp = search(t, r, url.Values{"q": {"Go LANGUAGE"}})
+	assert.Equal(t, []string{"cat-1"}, isbns(resp.Books))
+
+	_, resp = search(t, r, url.Values{"q": {"reilly"}})
+	assert.Equal(t, []string{"cat-3"}, isbns(resp.Books))
+
+	_, resp = search(t, r, url.Values{"q": {"nothing matches"}})
+	assert.Equal(t, int64(0), resp.Total)
+	assert.NotNil(t, resp.Books)
+}
+
+// TestSearchBooks_FiltersAndSort filters by language, publisher and availability and
+// sorts by the requested column.
+func TestSearchBooks_FiltersAndSort(t *testing.T) {
+	db := setupTestDB(t)
+	seedCatalog(t, db)
+	r := setupCatalogSearchRouter(db, readerClaims(1))
+
+	_, resp := search(t, r, url.Values{})
+	assert.Equal(t, []string{"cat-2", "cat-5", "cat-4", "cat-3", "cat-1"}, isbns(resp.Books))
+
+	_, resp = search(t, r, url.Values{"language": {"french"}})
+	assert.Equal(t, []string{"cat-4"}, isbns(resp.Books))
+
+	_, resp = search(t, r, url.Values{"publisher": {"addison-wesley"}, "sort": {"author"}, "order": {"desc"}})
+	assert.Equal(t, []string{"cat-2", "cat-1"}, isbns(resp.Books))
+
+	_, resp = search(t, r, url.Values{"available": {"false"}})
+	assert.Equal(t, []string{"cat-2"}, isbns(resp.Books))
+
+	_, resp = search(t, r, url.Values{"available": {"true"}, "sort": {"newest"}})
+	assert.Equal(t, []string{"cat-5", "cat-4", "cat-3", "cat-1"}, isbns(resp.Books))
+
+	for _, params := range []url.Values{
+		{"sort": {"price"}},
+		{"order": {"sideways"}},
+		{"available": {"maybe"}},
+		{"limit": {"-1"}},
+		{"cursor": {"not-a-cursor"}},
+	} {
+		code, _ := search(t, r, params)
+		assert.Equal(t, http.StatusBadRequest, code, params)
+	}
+}
+
+// TestSearchBooks_CursorPagination walks every page exactly once, for column and
+// relevance sorts alike.
+func TestSearchBooks_CursorPagination(t *testing.T) {
+	db := setupTestDB(t)
+	seedCatalog(t, db)
+	r := setupCatalogSearchRouter(db, readerClaims(1))
+
+	for _, params := range []url.Values{
+		{"sort": {"title"}},
+		{"sort": {"available"}, "order": {"desc"}},
+		{"sort": {"newest"}},
+		{"q": {"go"}},
+	} {
+		params.Set("limit", "2")
+		full := url.Values{}
+		for k, v := range params {
+			full[k] = v
+		}
+		full.Set("limit", "100")
+		_, all := search(t, r, full)
+
+		var seen []string
+		for pages := 0; pages < 5; pages++ {
+			code, resp := search(t, r, params)
+			assert.Equal(t, http.StatusOK, code, params)
+			assert.Equal(t, all.Total, resp.Total)
+			seen = append(seen, isbns(resp.Books)...)
+			if resp.NextCursor == "" {
+				break
+			}
+			params.Set("cursor", resp.NextCursor)
+		}
+		assert.Equal(t, isbns(all.Books), seen, params)
+	}
+
+	// A cursor only continues the sort it was issued for.
+	_, first := search(t, r, url.Values{"sort": {"title"}, "limit": {"1"}})
+	code, _ := search(t, r, url.Values{"sort": {"author"}, "cursor": {first.NextCursor}})
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+// TestSearchBooks_RelevanceCursorStable resumes a relevance search after the last
+// book shown, so books leaving an earlier page do not shift the next one.
+func TestSearchBooks_RelevanceCursorStable(t *testing.T) {
+	db := setupTestDB(t)
+	seedCatalog(t, db)
+	r := setupCatalogSearchRouter(db, readerClaims(1))
+
+	_, all := search(t, r, url.Values{"q": {"go"}, "limit": {"100"}})
+	assert.Len(t, all.Books, 4)
+	_, first := search(t, r, url.Values{"q": {"go"}, "limit": {"2"}})
+	assert.Equal(t, isbns(all.Books[:2]), isbns(first.Books))
+
+	assert.NoError(t, db.Delete(&models.BookInventory{}, first.Books[0].ID).Error)
+	code, next := search(t, r, url.Values{"q": {"go"}, "limit": {"2"}, "cursor": {first.NextCursor}})
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, isbns(all.Books[2:]), isbns(next.Books))
+}
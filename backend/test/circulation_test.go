@@ -0,0 +1,254 @@
+// /backend/test/circulation_test.go
+package handlers_test
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"github.com/swapxs/LibMS/backend/src/services"
+	"gorm.io/gorm"
+)
+
+// parallelism is the number of goroutines each concurrency test runs.
+const parallelism = 12
+
+// setupConcurrentDB opens a file-backed SQLite database that many goroutines can
+// share. Write transactions take the database lock up front and wait for each other.
+func setupConcurrentDB(t *testing.T) *gorm.DB {
+	dsn := filepath.Join(t.TempDir(), "circulation.db") + "?_busy_timeout=10000&_txlock=immediate"
+	db := setupTestDBWithDSN(t, dsn)
+	sqlDB, err := db.DB()
+	assert.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+func seedCirculationBook(t *testing.T, db *gorm.DB, copies int) models.BookInventory {
+	book := models.BookInventory{
+		ISBN: testISBN(117), LibraryID: 1, Title: "Race", Author: "A", Publisher: "P",
+		Language: "English", Version: "v1",
+	}
+	assert.NoError(t, db.Create(&book).Error)
+	assert.NoError(t, services.AddBookItems(db, &book, copies, nil, "", ""))
+	return book
+}
+
+// seedCirculationReaders creates n readers of library 1, with IDs 1 to n in a new database.
+func seedCirculationReaders(t *testing.T, db *gorm.DB, n int) {
+	for i := 1; i <= n; i++ {
+		reader := models.User{Name: "R", Email: fmt.Sprintf("reader%d@example.com", i), Password: "x", ContactNumber: "1", Role: "Reader", LibraryID: 1}
+		assert.NoError(t, db.Create(&reader).Error)
+	}
+}
+
+// runParallel starts n goroutines at once and collects their errors.
+func runParallel(n int, fn func(i int) error) []error {
+	errs := make([]error, n)
+	var start, done sync.WaitGroup
+	start.Add(1)
+	for i := 0; i < n; i++ {
+		done.Add(1)
+		go func(i int) {
+			defer done.Done()
+			start.Wait()
+			errs[i] = fn(i)
+		}(i)
+	}
+	start.Done()
+	done.Wait()
+	return errs
+}
+
+func countErrors(errs []error, target error) (matched, ok int) {
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			ok++
+		case errors.Is(err, target):
+			matched++
+		}
+	}
+	return matched, ok
+}
+
+// TestDecideIssueRequest_ConcurrentApprovalsDoNotOversell approves more requests
+// than there are copies at the same time; exactly one request per copy succeeds.
+func TestDecideIssueRequest_ConcurrentApprovalsDoNotOversell(t *testing.T) {
+	db := setupConcurrentDB(t)
+	book := seedCirculationBook(t, db, 3)
+	seedCirculationReaders(t, db, parallelism)
+
+	requests := make([]models.RequestEvent, parallelism)
+	for i := range requests {
+		requests[i] = models.RequestEvent{BookID: book.ISBN, ReaderID: uint(i + 1), RequestType: "Issue"}
+		assert.NoError(t, db.Create(&requests[i]).Error)
+	}
+
+	errs := runParallel(parallelism, func(i int) error {
+		_, err := services.DecideIssueRequest(db, services.IssueDecision{
+			RequestID: requests[i].ReqID, ApproverID: 99, LibraryID: 1, Approve: true,
+		})
+		return err
+	})
+	soldOut, ok := countErrors(errs, services.ErrNoAvailableItem)
+	assert.Equal(t, 3, ok, fmt.Sprint(errs))
+	assert.Equal(t, parallelism-3, soldOut, fmt.Sprint(errs))
+
+	db.First(&book, book.ID)
+	assert.Equal(t, 0, book.AvailableCopies)
+	assert.Equal(t, 3, book.TotalCopies)
+
+	var issued []models.RequestEvent
+	db.Where("status = ?", "Issued").Find(&issued)
+	assert.Len(t, issued, 3)
+	seen := map[uint]bool{}
+	for _, r := range issued {
+		assert.NotNil(t, r.ItemID)
+		assert.False(t, seen[*r.ItemID], "copy issued twice")
+		seen[*r.ItemID] = true
+	}
+	var issues int64
+	db.Model(&models.IssueRegistry{}).Count(&issues)
+	assert.Equal(t, int64(3), issues)
+
+	// Failed approvals leave their requests pending.
+	var pending int64
+	db.Model(&models.RequestEvent{}).Where("status = ?", "Requested").Count(&pending)
+	assert.Equal(t, int64(parallelism-3), pending)
+}
+
+// TestDecideIssueRequest_ConcurrentSameRequest lets several admins approve the same
+// request at once; it is approved exactly once and only one copy is taken.
+func TestDecideIssueRequest_ConcurrentSameRequest(t *testing.T) {
+	db := setupConcurrentDB(t)
+	book := seedCirculationBook(t, db, 5)
+	seedCirculationReaders(t, db, 1)
+	req := models.RequestEvent{BookID: book.ISBN, ReaderID: 1, RequestType: "Issue"}
+	assert.NoError(t, db.Create(&req).Error)
+
+	errs := runParallel(parallelism, func(i int) error {
+		_, err := services.DecideIssueRequest(db, services.IssueDecision{
+			RequestID: req.ReqID, ApproverID: uint(100 + i), LibraryID: 1, Approve: i%2 == 0,
+		})
+		return err
+	})
+	conflicts, ok := countErrors(errs, services.ErrRequestNotPending)
+	assert.Equal(t, 1, ok, fmt.Sprint(errs))
+	assert.Equal(t, parallelism-1, conflicts, fmt.Sprint(errs))
+
+	db.First(&req, req.ReqID)
+	db.First(&book, book.ID)
+	if req.Status == "Issued" {
+		assert.Equal(t, 4, book.AvailableCopies)
+	} else {
+		assert.Equal(t, "Rejected", req.Status)
+		assert.Equal(t, 5, book.AvailableCopies)
+	}
+}
+
+// TestDecideReturnRequest_ConcurrentApprovals approves one return request from many
+// goroutines; the copy is put back on the shelf once.
+func TestDecideReturnRequest_ConcurrentApprovals(t *testing.T) {
+	db := setupConcurrentDB(t)
+	book := seedCirculationBook(t, db, 2)
+	seedCirculationReaders(t, db, 1)
+	req := models.RequestEvent{BookID: book.ISBN, ReaderID: 1, RequestType: "Issue"}
+	assert.NoError(t, db.Create(&req).Error)
+	req, err := services.DecideIssueRequest(db, services.IssueDecision{RequestID: req.ReqID, ApproverID: 99, LibraryID: 1, Approve: true})
+	assert.NoError(t, err)
+
+	var issue models.IssueRegistry
+	assert.NoError(t, db.First(&issue, *req.IssueID).Error)
+	assert.Equal(t, *req.ItemID, *issue.ItemID)
+	ret := models.RequestEvent{BookID: book.ISBN, ReaderID: 1, RequestType: "Return", IssueID: &issue.ID}
+	assert.NoError(t, db.Create(&ret).Error)
+
+	errs := runParallel(parallelism, func(i int) error {
+		_, _, err := services.DecideReturnRequest(db, services.ReturnDecision{
+			RequestID: ret.ReqID, ApproverID: 99, LibraryID: 1, Approve: true,
+		})
+		return err
+	})
+	conflicts, ok := countErrors(errs, services.ErrRequestNotPending)
+	assert.Equal(t, 1, ok, fmt.Sprint(errs))
+	assert.Equal(t, parallelism-1, conflicts, fmt.Sprint(errs))
+
+	db.First(&book, book.ID)
+	assert.Equal(t, 2, book.AvailableCopies)
+	db.First(&req, req.ReqID)
+	assert.Equal(t, "Returned", req.Status)
+}
+
+// TestIssueCopy_SameCopyTwice refuses to tie one copy to two open issues.
+func TestIssueCopy_SameCopyTwice(t *testing.T) {
+	db := setupConcurrentDB(t)
+	book := seedCirculationBook(t, db, 1)
+	var item models.BookItem
+	assert.NoError(t, db.First(&item, "book_inventory_id = ?", book.ID).Error)
+
+	errs := runParallel(parallelism, func(i int) error {
+		issue := models.IssueRegistry{ISBN: book.ISBN, ReaderID: 1, IssueApproverID: 99, IssueStatus: "Issued", LibraryID: 1, Barcode: item.Barcode}
+		return services.IssueCopy(db, &issue)
+	})
+	onLoan, ok := countErrors(errs, services.ErrItemOnLoan)
+	assert.Equal(t, 1, ok, fmt.Sprint(errs))
+	assert.Equal(t, parallelism-1, onLoan, fmt.Sprint(errs))
+}
+
+// TestDecideIssueRequest_RollsBackOnFailure leaves the request and the copies
+// untouched when the requested barcode cannot be issued.
+func TestDecideIssueRequest_RollsBackOnFailure(t *testing.T) {
+	db := setupTestDB(t)
+	book := seedCirculationBook(t, db, 2)
+	seedCirculationReaders(t, db, 1)
+	req := models.RequestEvent{BookID: book.ISBN, ReaderID: 1, RequestType: "Issue"}
+	assert.NoError(t, db.Create(&req).Error)
+
+	_, err := services.DecideIssueRequest(db, services.IssueDecision{
+		RequestID: req.ReqID, ApproverID: 99, LibraryID: 1, Approve: true, Barcode: "missing",
+	})
+	assert.ErrorIs(t, err, services.ErrItemNotAvailable)
+
+	db.First(&req, req.ReqID)
+	assert.Equal(t, "Requested", req.Status)
+	assert.Nil(t, req.ApproverID)
+	db.First(&book, book.ID)
+	assert.Equal(t, 2, book.AvailableCopies)
+}
+
+// TestDecideRequests_OtherLibrary refuses decisions on the requests of another
+// library's readers, even for a book the deciding library also holds.
+func TestDecideRequests_OtherLibrary(t *testing.T) {
+	db := setupTestDB(t)
+	book := seedCirculationBook(t, db, 1)
+	other := models.User{Name: "R", Email: "other-library@example.com", Password: "x", ContactNumber: "1", Role: "Reader", LibraryID: 2}
+	assert.NoError(t, db.Create(&other).Error)
+	req := models.RequestEvent{BookID: book.ISBN, ReaderID: other.ID, RequestType: "Issue"}
+	assert.NoError(t, db.Create(&req).Error)
+
+	for _, approve := range []bool{true, false} {
+		_, err := services.DecideIssueRequest(db, services.IssueDecision{RequestID: req.ReqID, ApproverID: 99, LibraryID: 1, Approve: approve})
+		assert.ErrorIs(t, err, services.ErrRequestNotFound)
+	}
+	db.First(&req, req.ReqID)
+	assert.Equal(t, "Requested", req.Status)
+	db.First(&book, book.ID)
+	assert.Equal(t, 1, book.AvailableCopies)
+
+	issue := models.IssueRegistry{ISBN: book.ISBN, ReaderID: other.ID, IssueApproverID: 98, IssueStatus: "Issued", ExpectedReturnDate: time.Now(), LibraryID: 2}
+	assert.NoError(t, db.Create(&issue).Error)
+	ret := models.RequestEvent{BookID: book.ISBN, ReaderID: other.ID, RequestType: "Return", IssueID: &issue.ID}
+	assert.NoError(t, db.Create(&ret).Error)
+	for _, approve := range []bool{true, false} {
+		_, _, err := services.DecideReturnRequest(db, services.ReturnDecision{RequestID: ret.ReqID, ApproverID: 99, LibraryID: 1, Approve: approve})
+		assert.ErrorIs(t, err, services.ErrRequestNotFound)
+	}
+	db.First(&ret, ret.ReqID)
+	assert.Equal(t, "Requested", ret.Status)
+}
@@ -2,6 +2,8 @@
 package handlers_test
 
 import (
+	"fmt"
+	"strconv"
 	"testing"
 
 	"github.com/swapxs/LibMS/backend/src/models"
@@ -10,7 +12,12 @@ import (
 )
 
 func setupTestDB(t *testing.T) *gorm.DB {
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	return setupTestDBWithDSN(t, ":memory:")
+}
+
+// setupTestDBWithDSN opens and migrates a SQLite database at the given DSN.
+func setupTestDBWithDSN(t *testing.T, dsn string) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
 	if err != nil {
 		t.Fatalf("failed to open sqlite DB: %v", err)
 	}
@@ -20,9 +27,38 @@ func setupTestDB(t *testing.T) *gorm.DB {
 		&models.BookInventory{},
 		&models.RequestEvent{},
 		&models.IssueRegistry{},
+		&models.Hold{},
+		&models.FinePolicy{},
+		&models.Fine{},
+		&models.FineTransaction{},
+		&models.BookItem{},
+		&models.RequestTransition{},
+		&models.LoanPolicy{},
+		&models.LibraryHoliday{},
+		&models.LibraryOpeningHours{},
+		&models.LibraryClosure{},
+		&models.Author{},
+		&models.AuthorNameVariant{},
+		&models.BookAuthor{},
+		&models.Subject{},
+		&models.BookSubject{},
+		&models.BookTag{},
+		&models.Work{},
+		&models.BookRevision{},
+		&models.BookChange{},
 	)
 	if err != nil {
 		t.Fatalf("failed to auto-migrate models: %v", err)
 	}
 	return db
 }
+
+// testISBN returns the n-th of a series of valid ISBN-13s for fixtures.
+func testISBN(n int) string {
+	body := fmt.Sprintf("978000%06d", n)
+	sum := 0
+	for i, r := range body {
+		sum += int(r-'0') * (1 + 2*(i%2))
+	}
+	return body + strconv.Itoa((10-sum%10)%10)
+}
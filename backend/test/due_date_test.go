@@ -0,0 +1,165 @@
+// /backend/test/due_date_test.go
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/swapxs/LibMS/backend/src/handlers"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"github.com/swapxs/LibMS/backend/src/services"
+	"gorm.io/gorm"
+)
+
+func setupDueDateRouter(db *gorm.DB, claims jwt.MapClaims) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user", claims)
+		c.Next()
+	})
+	r.PUT("/issueRequests/:id", handlers.UpdateIssueRequestStatus(db))
+	r.GET("/library/holidays", handlers.GetHolidays(db))
+	r.POST("/library/holidays", handlers.AddHoliday(db))
+	r.DELETE("/library/holidays/:id", handlers.DeleteHoliday(db))
+	return r
+}
+
+// seedDueDateRequest creates a reader, a book with one copy and a pending request
+// for it.
+func seedDueDateRequest(t *testing.T, db *gorm.DB, isbn string) models.RequestEvent {
+	seedCirculationReaders(t, db, 1)
+	book := models.BookInventory{ISBN: isbn, LibraryID: 1, Title: "Due", Author: "A", Publisher: "P", Language: "English", Version: "v1"}
+	assert.NoError(t, db.Create(&book).Error)
+	assert.NoError(t, services.AddBookItems(db, &book, 1, nil, "", ""))
+	req := models.RequestEvent{BookID: isbn, ReaderID: 1, RequestType: "Issue"}
+	assert.NoError(t, services.CreateRequest(db, &req, nil, ""))
+	return req
+}
+
+type approvalResponse struct {
+	Request models.RequestEvent  `json:"request"`
+	Issue   models.IssueRegistry `json:"issue"`
+}
+
+func approve(t *testing.T, r *gin.Engine, reqID uint, body map[string]any) (int, approvalResponse) {
+	body["request_type"] = "Approve"
+	w := putJSON(r, "/issueRequests/"+strconv.Itoa(int(reqID)), body)
+	var resp approvalResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return w.Code, resp
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Format("2006-01-02") == b.Format("2006-01-02")
+}
+
+// TestApproveIssueRequest_CreatesIssue issues the book on approval with a due date
+// one loan period ahead.
+func TestApproveIssueRequest_CreatesIssue(t *testing.T) {
+	db := setupTestDB(t)
+	req := seedDueDateRequest(t, db, testISBN(118))
+
+	code, resp := approve(t, setupDueDateRouter(db, adminClaims()), req.ReqID, map[string]any{})
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "Issued", resp.Request.Status)
+	assert.Equal(t, resp.Issue.ID, *resp.Request.IssueID)
+	assert.Equal(t, *resp.Request.ItemID, *resp.Issue.ItemID)
+	assert.Equal(t, "Issued", resp.Issue.IssueStatus)
+	assert.WithinDuration(t, time.Now().Add(14*24*time.Hour), resp.Issue.ExpectedReturnDate, time.Minute)
+
+	var item models.BookItem
+	assert.NoError(t, db.First(&item, *resp.Issue.ItemID).Error)
+	assert.Equal(t, resp.Issue.Barcode, item.Barcode)
+	assert.Equal(t, "Issued", item.Status)
+
+	var steps []string
+	var history []models.RequestTransition
+	db.Where("request_id = ?", req.ReqID).Order("id ASC").Find(&history)
+	for _, h := range history {
+		steps = append(steps, h.ToStatus)
+	}
+	assert.Equal(t, []string{"Requested", "Approved", "Issued"}, steps)
+}
+
+// TestApproveIssueRequest_UsesLoanPolicyAndSkipsHolidays takes the loan period from
+// the reader's policy and moves the due date past holidays.
+func TestApproveIssueRequest_UsesLoanPolicyAndSkipsHolidays(t *testing.T) {
+	db := setupTestDB(t)
+	db.Create(&models.LoanPolicy{LibraryID: 1, LoanPeriodDays: intPtr(7)})
+	due := time.Now().AddDate(0, 0, 7)
+	admin := setupDueDateRouter(db, adminClaims())
+	for i := 0; i < 2; i++ {
+		w := postJSON(admin, "/library/holidays", map[string]any{"date": due.AddDate(0, 0, i).Format("2006-01-02"), "name": "Closed"})
+		assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	req := seedDueDateRequest(t, db, testISBN(120))
+	code, resp := approve(t, admin, req.ReqID, map[string]any{})
+	assert.Equal(t, http.StatusOK, code)
+	assert.True(t, sameDay(due.AddDate(0, 0, 2), resp.Issue.ExpectedReturnDate), resp.Issue.ExpectedReturnDate)
+}
+
+// TestApproveIssueRequest_ExplicitDueDate accepts a due date within the loan period
+// and refuses dates outside it without issuing anything.
+func TestApproveIssueRequest_ExplicitDueDate(t *testing.T) {
+	db := setupTestDB(t)
+	admin := setupDueDateRouter(db, adminClaims())
+	req := seedDueDateRequest(t, db, testISBN(119))
+
+	code, _ := approve(t, admin, req.ReqID, map[string]any{"expected_return_date": time.Now().Add(30 * 24 * time.Hour)})
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = approve(t, admin, req.ReqID, map[string]any{"expected_return_date": time.Now().Add(-time.Hour)})
+	assert.Equal(t, http.StatusBadRequest, code)
+	var issues int64
+	db.Model(&models.IssueRegistry{}).Count(&issues)
+	assert.Equal(t, int64(0), issues)
+
+	want := time.Now().Add(3 * 24 * time.Hour)
+	code, resp := approve(t, admin, req.ReqID, map[string]any{"expected_return_date": want})
+	assert.Equal(t, http.StatusOK, code)
+	assert.WithinDuration(t, want, resp.Issue.ExpectedReturnDate, time.Second)
+	// The approval date is the time of approval, not the due date.
+	assert.WithinDuration(t, time.Now(), *resp.Request.ApprovalDate, time.Minute)
+}
+
+// TestHolidayEndpoints adds, lists and deletes holidays as an admin.
+func TestHolidayEndpoints(t *testing.T) {
+	db := setupTestDB(t)
+	admin := setupDueDateRouter(db, adminClaims())
+
+	assert.Equal(t, http.StatusUnauthorized, postJSON(setupDueDateRouter(db, readerClaims(1)), "/library/holidays", map[string]any{"date": "2030-12-25"}).Code)
+	assert.Equal(t, http.StatusBadRequest, postJSON(admin, "/library/holidays", map[string]any{"date": "25/12/2030"}).Code)
+	w := postJSON(admin, "/library/holidays", map[string]any{"date": "2030-12-25", "name": "Christmas"})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, http.StatusConflict, postJSON(admin, "/library/holidays", map[string]any{"date": "2030-12-25"}).Code)
+
+	req, _ := http.NewRequest("GET", "/library/holidays", nil)
+	w = httptest.NewRecorder()
+	setupDueDateRouter(db, readerClaims(1)).ServeHTTP(w, req)
+	var list struct {
+		Holidays []models.LibraryHoliday `json:"holidays"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Len(t, list.Holidays, 1)
+	assert.Equal(t, "Christmas", list.Holidays[0].Name)
+
+	next, err := services.NextOpenDay(db, 1, time.Date(2030, 12, 25, 15, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2030, 12, 26, 15, 0, 0, 0, time.UTC), next)
+
+	req, _ = http.NewRequest("DELETE", "/library/holidays/"+strconv.Itoa(int(list.Holidays[0].ID)), nil)
+	w = httptest.NewRecorder()
+	admin.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	next, err = services.NextOpenDay(db, 1, time.Date(2030, 12, 25, 15, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, 25, next.Day())
+}
@@ -0,0 +1,120 @@
+// /backend/test/export_test.go
+package handlers_test
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/swapxs/LibMS/backend/src/handlers"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"gorm.io/gorm"
+)
+
+func setupExportRouter(db *gorm.DB, claims jwt.MapClaims) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user", claims)
+		c.Next()
+	})
+	r.GET("/books/export", handlers.ExportBooks(db))
+	return r
+}
+
+func export(r *gin.Engine, query string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/books/export?"+query, nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// TestExportBooks_CSV writes a header and one row per book matching the filters.
+func TestExportBooks_CSV(t *testing.T) {
+	db := setupTestDB(t)
+	seedCatalog(t, db)
+	r := setupExportRouter(db, adminClaims())
+
+	w := export(r, "language=english&available=true")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/csv")
+	assert.Contains(t, w.Header().Get("Content-Disposition"), `filename="catalog.csv"`)
+
+	rows, err := csv.NewReader(w.Body).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, rows, 4)
+	assert.Equal(t, []string{"id", "isbn", "title", "author", "publisher", "language", "version", "total_copies", "available_copies"}, rows[0])
+	assert.Equal(t, []string{"1", "cat-1", "The Go Programming Language", "Donovan", "Addison-Wesley", "English", "1", "2", "2"}, rows[1])
+	assert.Equal(t, "cat-3", rows[2][1])
+	assert.Equal(t, "cat-5", rows[3][1])
+}
+
+// TestExportBooks_JSONLines streams one object per line across several batches.
+func TestExportBooks_JSONLines(t *testing.T) {
+	db := setupTestDB(t)
+	var books []models.BookInventory
+	for i := 0; i < 1203; i++ {
+		books = append(books, models.BookInventory{ISBN: testISBN(i), LibraryID: 1, Title: "Title", Author: "Author", Publisher: "P", Language: "English", Version: "1", TotalCopies: 1, AvailableCopies: 1})
+	}
+	assert.NoError(t, db.CreateInBatches(&books, 200).Error)
+	r := setupExportRouter(db, adminClaims())
+
+	w := export(r, "format=jsonl")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	scanner := bufio.NewScanner(w.Body)
+	var lines []handlers.ExportedBook
+	for scanner.Scan() {
+		var book handlers.ExportedBook
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &book))
+		lines = append(lines, book)
+	}
+	assert.Len(t, lines, 1203)
+	assert.Equal(t, testISBN(0), lines[0].ISBN)
+	assert.Equal(t, testISBN(1202), lines[1202].ISBN)
+
+	// Keywords narrow the export like a search.
+	db.Model(&models.BookInventory{}).Where("isbn = ?", testISBN(7)).Update("title", "Learning Go")
+	w = export(r, "format=jsonl&q=learning")
+	assert.Equal(t, 1, strings.Count(w.Body.String(), "\n"))
+	assert.Contains(t, w.Body.String(), `"title":"Learning Go"`)
+}
+
+// TestExportBooks_BibTeX writes @book entries and escapes LaTeX characters.
+func TestExportBooks_BibTeX(t *testing.T) {
+	db := setupTestDB(t)
+	book := models.BookInventory{ISBN: testISBN(1), LibraryID: 1, Title: "C & C++ at 100% {fast}", Author: "Kernighan, Brian", Publisher: "Prentice_Hall", Language: "English", TotalCopies: 1, AvailableCopies: 1}
+	assert.NoError(t, db.Create(&book).Error)
+	r := setupExportRouter(db, adminClaims())
+
+	w := export(r, "format=bibtex")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Disposition"), `filename="catalog.bib"`)
+	assert.Equal(t, "@book{isbn"+testISBN(1)+",\n"+
+		"  isbn = {"+testISBN(1)+"},\n"+
+		`  title = {C \& C++ at 100\% \{fast\}},`+"\n"+
+		"  author = {Kernighan, Brian},\n"+
+		`  publisher = {Prentice\_Hall},`+"\n"+
+		"  language = {English},\n"+
+		"}\n\n", w.Body.String())
+}
+
+// TestExportBooks_RejectsBadRequests refuses readers, unknown formats and malformed
+// filters before anything is streamed.
+func TestExportBooks_RejectsBadRequests(t *testing.T) {
+	db := setupTestDB(t)
+	seedCatalog(t, db)
+
+	assert.Equal(t, http.StatusUnauthorized, export(setupExportRouter(db, readerClaims(1)), "").Code)
+	admin := setupExportRouter(db, adminClaims())
+	assert.Equal(t, http.StatusBadRequest, export(admin, "format=xlsx").Code)
+	assert.Equal(t, http.StatusBadRequest, export(admin, "available=maybe").Code)
+	assert.Equal(t, http.StatusOK, export(admin, "format=MARCXML").Code)
+}
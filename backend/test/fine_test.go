@@ -0,0 +1,181 @@
+// /backend/test/fine_test.go
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/swapxs/LibMS/backend/src/handlers"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"github.com/swapxs/LibMS/backend/src/services"
+	"gorm.io/gorm"
+)
+
+func setupFineRouter(db *gorm.DB, claims jwt.MapClaims) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user", claims)
+		c.Next()
+	})
+	r.GET("/fines", handlers.GetFines(db))
+	r.GET("/fines/balances", handlers.GetFineBalances(db))
+	r.POST("/fines/:id/pay", handlers.PayFine(db))
+	r.POST("/fines/:id/waive", handlers.WaiveFine(db))
+	r.POST("/fines/:id/adjust", handlers.AdjustFine(db))
+	r.POST("/requestEvents", handlers.RaiseRequest(db))
+	return r
+}
+
+// TestSweepOverdue_AccruesWithGraceAndCap marks an issue overdue and charges
+// the daily rate after the grace period, up to the cap.
+func TestSweepOverdue_AccruesWithGraceAndCap(t *testing.T) {
+	db := setupTestDB(t)
+	db.Create(&models.FinePolicy{LibraryID: 1, DailyRateCents: 50, GracePeriodDays: 2, MaxFineCents: 300})
+
+	now := time.Now()
+	issue := models.IssueRegistry{
+		ISBN:               testISBN(121),
+		ReaderID:           1,
+		IssueApproverID:    99,
+		IssueStatus:        "Issued",
+		ExpectedReturnDate: now.Add(-5 * 24 * time.Hour),
+		LibraryID:          1,
+	}
+	db.Create(&issue)
+
+	marked, err := services.SweepOverdue(db, now)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), marked)
+
+	db.First(&issue, issue.ID)
+	assert.Equal(t, "Overdue", issue.IssueStatus)
+
+	var fine models.Fine
+	assert.NoError(t, db.First(&fine, "issue_id = ?", issue.ID).Error)
+	assert.Equal(t, int64(150), fine.AccruedCents) // 5 days late, 2 days grace
+
+	// Ten days later the fine is capped.
+	_, err = services.SweepOverdue(db, now.Add(10*24*time.Hour))
+	assert.NoError(t, err)
+	db.First(&fine, fine.ID)
+	assert.Equal(t, int64(300), fine.AccruedCents)
+}
+
+// TestSweepOverdue_NotYetDue leaves issues that are still within their loan alone.
+func TestSweepOverdue_NotYetDue(t *testing.T) {
+	db := setupTestDB(t)
+	issue := models.IssueRegistry{
+		ISBN:               testISBN(122),
+		ReaderID:           1,
+		IssueApproverID:    99,
+		IssueStatus:        "Issued",
+		ExpectedReturnDate: time.Now().Add(24 * time.Hour),
+		LibraryID:          1,
+	}
+	db.Create(&issue)
+
+	marked, err := services.SweepOverdue(db, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), marked)
+}
+
+// TestRaiseRequest_BlockedByFines refuses new requests above the block threshold.
+func TestRaiseRequest_BlockedByFines(t *testing.T) {
+	db := setupTestDB(t)
+	db.Create(&models.FinePolicy{LibraryID: 1, DailyRateCents: 10, BlockThresholdCents: 100})
+	db.Create(&models.BookInventory{
+		ISBN: testISBN(108), LibraryID: 1, Title: "Fined", Author: "A", Publisher: "P",
+		Language: "English", Version: "v1", TotalCopies: 1, AvailableCopies: 1,
+	})
+	db.Create(&models.Fine{IssueID: 1, ReaderID: 1, LibraryID: 1, AccruedCents: 250})
+
+	r := setupFineRouter(db, readerClaims(1))
+	w := postJSON(r, "/requestEvents", map[string]any{"bookID": testISBN(108)})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+// TestFineTransactions pays, adjusts and waives a fine down to zero.
+func TestFineTransactions(t *testing.T) {
+	db := setupTestDB(t)
+	fine := models.Fine{IssueID: 1, ReaderID: 1, LibraryID: 1, AccruedCents: 500}
+	db.Create(&fine)
+
+	admin := setupFineRouter(db, jwt.MapClaims{"id": float64(99), "role": "LibraryAdmin", "library_id": float64(1)})
+	base := "/fines/" + strconv.Itoa(int(fine.ID))
+
+	assert.Equal(t, http.StatusOK, postJSON(admin, base+"/pay", map[string]any{"amount_cents": 200}).Code)
+	assert.Equal(t, http.StatusOK, postJSON(admin, base+"/adjust", map[string]any{"amount_cents": -100, "note": "goodwill"}).Code)
+	// Paying more than is owed is refused.
+	assert.Equal(t, http.StatusBadRequest, postJSON(admin, base+"/pay", map[string]any{"amount_cents": 1000}).Code)
+	assert.Equal(t, http.StatusOK, postJSON(admin, base+"/waive", map[string]any{}).Code)
+
+	db.First(&fine, fine.ID)
+	assert.Equal(t, int64(0), fine.BalanceCents())
+	assert.Equal(t, int64(200), fine.WaivedCents)
+
+	var count int64
+	db.Model(&models.FineTransaction{}).Where("fine_id = ?", fine.ID).Count(&count)
+	assert.Equal(t, int64(3), count)
+
+	// Readers cannot record payments.
+	reader := setupFineRouter(db, readerClaims(1))
+	assert.Equal(t, http.StatusUnauthorized, postJSON(reader, base+"/pay", map[string]any{"amount_cents": 1}).Code)
+}
+
+// TestFineTransactions_Concurrent keeps every concurrent payment and refuses those
+// that would pay more than is owed.
+func TestFineTransactions_Concurrent(t *testing.T) {
+	db := setupConcurrentDB(t)
+	fine := models.Fine{IssueID: 1, ReaderID: 1, LibraryID: 1, AccruedCents: 1000}
+	db.Create(&fine)
+	admin := setupFineRouter(db, adminClaims())
+	url := "/fines/" + strconv.Itoa(int(fine.ID)) + "/pay"
+
+	codes := make([]int, parallelism)
+	runParallel(parallelism, func(i int) error {
+		codes[i] = postJSON(admin, url, map[string]any{"amount_cents": 100}).Code
+		return nil
+	})
+	paid := 0
+	for _, code := range codes {
+		if code == http.StatusOK {
+			paid++
+		} else {
+			assert.Equal(t, http.StatusBadRequest, code)
+		}
+	}
+	assert.Equal(t, 10, paid)
+	db.First(&fine, fine.ID)
+	assert.Equal(t, int64(1000), fine.PaidCents)
+	var count int64
+	db.Model(&models.FineTransaction{}).Where("fine_id = ?", fine.ID).Count(&count)
+	assert.Equal(t, int64(10), count)
+}
+
+// TestGetFineBalances_Reader only returns the caller's own balance.
+func TestGetFineBalances_Reader(t *testing.T) {
+	db := setupTestDB(t)
+	db.Create(&models.Fine{IssueID: 1, ReaderID: 1, LibraryID: 1, AccruedCents: 120})
+	db.Create(&models.Fine{IssueID: 2, ReaderID: 1, LibraryID: 1, AccruedCents: 30})
+	db.Create(&models.Fine{IssueID: 3, ReaderID: 2, LibraryID: 1, AccruedCents: 999})
+
+	req, _ := http.NewRequest("GET", "/fines/balances", nil)
+	w := httptest.NewRecorder()
+	setupFineRouter(db, readerClaims(1)).ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Balances []handlers.ReaderBalance `json:"balances"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Len(t, resp.Balances, 1)
+	assert.Equal(t, int64(150), resp.Balances[0].BalanceCents)
+}
@@ -0,0 +1,172 @@
+// /backend/test/hold_test.go
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/swapxs/LibMS/backend/src/handlers"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"gorm.io/gorm"
+)
+
+func setupHoldRouter(db *gorm.DB, claims jwt.MapClaims) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user", claims)
+		c.Next()
+	})
+	r.POST("/holds", handlers.PlaceHold(db))
+	r.GET("/holds", handlers.GetHolds(db))
+	r.DELETE("/holds/:id", handlers.CancelHold(db))
+	r.POST("/holds/expire", handlers.ExpireHoldOffers(db))
+	r.POST("/books", handlers.AddOrIncrementBook(db, nil))
+	r.POST("/requestEvents", handlers.RaiseRequest(db))
+	return r
+}
+
+func readerClaims(id uint) jwt.MapClaims {
+	return jwt.MapClaims{
+		"id":         float64(id),
+		"role":       "Reader",
+		"library_id": float64(1),
+	}
+}
+
+func seedUnavailableBook(t *testing.T, db *gorm.DB) models.BookInventory {
+	book := models.BookInventory{
+		ISBN:            testISBN(103),
+		LibraryID:       1,
+		Title:           "Popular Book",
+		Author:          "Author",
+		Publisher:       "Publisher",
+		Language:        "English",
+		Version:         "v1",
+		TotalCopies:     1,
+		AvailableCopies: 0,
+	}
+	assert.NoError(t, db.Create(&book).Error)
+	return book
+}
+
+func postJSON(r *gin.Engine, url string, body any) *httptest.ResponseRecorder {
+	payload, _ := json.Marshal(body)
+	req, _ := http.NewRequest("POST", url, bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// TestPlaceHold_QueuePositions places two holds and checks FIFO positions.
+func TestPlaceHold_QueuePositions(t *testing.T) {
+	db := setupTestDB(t)
+	seedUnavailableBook(t, db)
+
+	for i, readerID := range []uint{1, 2} {
+		w := postJSON(setupHoldRouter(db, readerClaims(readerID)), "/holds", map[string]any{"bookID": testISBN(103)})
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var resp struct {
+			Hold handlers.HoldDetail `json:"hold"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, int64(i+1), resp.Hold.Position)
+	}
+
+	// A second hold by the same reader is refused.
+	w := postJSON(setupHoldRouter(db, readerClaims(1)), "/holds", map[string]any{"bookID": testISBN(103)})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// TestPlaceHold_BookAvailable refuses holds on titles that can be requested directly.
+func TestPlaceHold_BookAvailable(t *testing.T) {
+	db := setupTestDB(t)
+	book := seedUnavailableBook(t, db)
+	db.Model(&book).Update("available_copies", 1)
+
+	w := postJSON(setupHoldRouter(db, readerClaims(1)), "/holds", map[string]any{"bookID": testISBN(103)})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// TestHold_OfferedOnIncrementAndClaimed offers a new copy to the head of the queue,
+// keeps it from other readers, and lets the offered reader claim it.
+func TestHold_OfferedOnIncrementAndClaimed(t *testing.T) {
+	db := setupTestDB(t)
+	seedUnavailableBook(t, db)
+	db.Create(&models.Hold{ISBN: testISBN(103), LibraryID: 1, ReaderID: 1, Status: "Waiting"})
+	db.Create(&models.Hold{ISBN: testISBN(103), LibraryID: 1, ReaderID: 2, Status: "Waiting"})
+
+	admin := setupHoldRouter(db, jwt.MapClaims{"id": float64(99), "role": "LibraryAdmin", "library_id": float64(1)})
+	w := postJSON(admin, "/books", map[string]any{"isbn": testISBN(103), "copies": 1, "increment_only": true})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var first, second models.Hold
+	db.First(&first, "reader_id = ?", 1)
+	db.First(&second, "reader_id = ?", 2)
+	assert.Equal(t, "Offered", first.Status)
+	assert.NotNil(t, first.OfferExpiresAt)
+	assert.Equal(t, "Waiting", second.Status)
+
+	// The offered copy is not available to reader 3.
+	w = postJSON(setupHoldRouter(db, readerClaims(3)), "/requestEvents", map[string]any{"bookID": testISBN(103)})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Reader 1 claims the offer.
+	w = postJSON(setupHoldRouter(db, readerClaims(1)), "/requestEvents", map[string]any{"bookID": testISBN(103)})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	db.First(&first, first.ID)
+	assert.Equal(t, "Fulfilled", first.Status)
+}
+
+// TestExpireHoldOffers passes an expired offer on to the next reader.
+func TestExpireHoldOffers(t *testing.T) {
+	db := setupTestDB(t)
+	book := seedUnavailableBook(t, db)
+	db.Model(&book).Update("available_copies", 1)
+
+	past := time.Now().Add(-time.Hour)
+	db.Create(&models.Hold{ISBN: testISBN(103), LibraryID: 1, ReaderID: 1, Status: "Offered", OfferedAt: &past, OfferExpiresAt: &past})
+	db.Create(&models.Hold{ISBN: testISBN(103), LibraryID: 1, ReaderID: 2, Status: "Waiting"})
+
+	admin := setupHoldRouter(db, jwt.MapClaims{"id": float64(99), "role": "LibraryAdmin", "library_id": float64(1)})
+	w := postJSON(admin, "/holds/expire", map[string]any{})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var first, second models.Hold
+	db.First(&first, "reader_id = ?", 1)
+	db.First(&second, "reader_id = ?", 2)
+	assert.Equal(t, "Expired", first.Status)
+	assert.Equal(t, "Offered", second.Status)
+}
+
+// TestCancelHold_OtherReader ensures readers can only cancel their own holds.
+func TestCancelHold_OtherReader(t *testing.T) {
+	db := setupTestDB(t)
+	seedUnavailableBook(t, db)
+	hold := models.Hold{ISBN: testISBN(103), LibraryID: 1, ReaderID: 1, Status: "Waiting"}
+	db.Create(&hold)
+
+	url := "/holds/" + strconv.Itoa(int(hold.ID))
+	req, _ := http.NewRequest("DELETE", url, nil)
+	w := httptest.NewRecorder()
+	setupHoldRouter(db, readerClaims(2)).ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	req, _ = http.NewRequest("DELETE", url, nil)
+	w = httptest.NewRecorder()
+	setupHoldRouter(db, readerClaims(1)).ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	db.First(&hold, hold.ID)
+	assert.Equal(t, "Cancelled", hold.Status)
+}
@@ -0,0 +1,147 @@
+// /backend/test/import_test.go
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/swapxs/LibMS/backend/src/handlers"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"gorm.io/gorm"
+)
+
+func setupImportRouter(db *gorm.DB, claims jwt.MapClaims) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user", claims)
+		c.Next()
+	})
+	r.POST("/books/import", handlers.ImportBooks(db))
+	return r
+}
+
+// uploadCSV posts content as the file field of a multipart form.
+func uploadCSV(r *gin.Engine, url, content string) (int, handlers.ImportReport, string) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", "books.csv")
+	part.Write([]byte(content))
+	form.Close()
+
+	req, _ := http.NewRequest("POST", url, &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var report handlers.ImportReport
+	json.Unmarshal(w.Body.Bytes(), &report)
+	return w.Code, report, w.Body.String()
+}
+
+func importCSV(rows ...string) string {
+	return strings.Join(append([]string{"ISBN,Title,Author,Publisher,Language,Copies,Barcodes,Increment_Only"}, rows...), "\n") + "\n"
+}
+
+// TestImportBooks_ReportsEachRow creates and increments books and reports failing
+// rows without losing the others.
+func TestImportBooks_ReportsEachRow(t *testing.T) {
+	db := setupTestDB(t)
+	r := setupImportRouter(db, adminClaims())
+	content := importCSV(
+		"978-0-13-468599-1,Effective Java,Bloch,Addison-Wesley,English,2,,",
+		"0134685997,,,,,1,EJ-3,true",
+		"9780134685992,Bad Checksum,A,P,English,1,,",
+		testISBN(1)+",No Language,A,P,,1,,",
+		testISBN(2)+",,,,,1,,true",
+		testISBN(3)+",Too Few Barcodes,A,P,English,2,B-1,",
+		testISBN(4)+",Barcode Taken,A,P,English,1,EJ-3,",
+		testISBN(5)+",Zero Copies,A,P,English,0,,",
+		`"`+testISBN(6)+`","Go, Quoted",Donovan,P,English,1,,`,
+	)
+	code, report, body := uploadCSV(r, "/books/import", content)
+	assert.Equal(t, http.StatusOK, code, body)
+	assert.Equal(t, 2, report.Created)
+	assert.Equal(t, 1, report.Incremented)
+	assert.Equal(t, 6, report.Failed)
+	assert.Len(t, report.Rows, 9)
+
+	statuses := map[int]string{}
+	for _, row := range report.Rows {
+		statuses[row.Row] = row.Status
+		if row.Status == "error" {
+			assert.NotEmpty(t, row.Error, row.Row)
+		}
+	}
+	assert.Equal(t, map[int]string{
+		2: "created", 3: "incremented", 4: "error", 5: "error", 6: "error",
+		7: "error", 8: "error", 9: "error", 10: "created",
+	}, statuses)
+
+	var book models.BookInventory
+	assert.NoError(t, db.First(&book, "isbn = ?", "9780134685991").Error)
+	assert.Equal(t, 3, book.TotalCopies)
+	var quoted models.BookInventory
+	assert.NoError(t, db.First(&quoted, "isbn = ?", testISBN(6)).Error)
+	assert.Equal(t, "Go, Quoted", quoted.Title)
+	// The failed barcode row left nothing behind.
+	var count int64
+	db.Model(&models.BookInventory{}).Where("isbn = ?", testISBN(4)).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+// TestImportBooks_DryRun checks every row, including increments of books created
+// earlier in the file, without saving anything.
+func TestImportBooks_DryRun(t *testing.T) {
+	db := setupTestDB(t)
+	r := setupImportRouter(db, adminClaims())
+	var rows []string
+	for i := 0; i < handlers.ImportBatchSize+5; i++ {
+		rows = append(rows, testISBN(i)+",Title,Author,P,English,1,,")
+	}
+	rows = append(rows, testISBN(0)+",,,,,1,,true")
+
+	code, report, body := uploadCSV(r, "/books/import?dry_run=true", importCSV(rows...))
+	assert.Equal(t, http.StatusOK, code, body)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, handlers.ImportBatchSize+5, report.Created)
+	assert.Equal(t, 1, report.Incremented)
+	var count int64
+	db.Model(&models.BookInventory{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	// The same file imports for real across several batches.
+	code, report, _ = uploadCSV(r, "/books/import", importCSV(rows...))
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 1, report.Incremented)
+	db.Model(&models.BookInventory{}).Count(&count)
+	assert.Equal(t, int64(handlers.ImportBatchSize+5), count)
+}
+
+// TestImportBooks_RejectsBadUploads refuses readers, missing files and unknown or
+// missing columns.
+func TestImportBooks_RejectsBadUploads(t *testing.T) {
+	db := setupTestDB(t)
+	admin := setupImportRouter(db, adminClaims())
+
+	code, _, _ := uploadCSV(setupImportRouter(db, readerClaims(1)), "/books/import", importCSV())
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _, _ = uploadCSV(admin, "/books/import", "isbn,title,price\n")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _, _ = uploadCSV(admin, "/books/import", "isbn,title\n")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _, _ = uploadCSV(admin, "/books/import?dry_run=maybe", importCSV())
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	req, _ := http.NewRequest("POST", "/books/import", nil)
+	w := httptest.NewRecorder()
+	admin.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
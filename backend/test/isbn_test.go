@@ -0,0 +1,167 @@
+// /backend/test/isbn_test.go
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/swapxs/LibMS/backend/src/isbn"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"github.com/swapxs/LibMS/backend/src/services"
+)
+
+// TestISBNNormalize strips hyphens, checks the check digit and converts ISBN-10s.
+func TestISBNNormalize(t *testing.T) {
+	valid := map[string]string{
+		"9780134685991":      "9780134685991",
+		"978-0-13-468599-1":  "9780134685991",
+		"0134685997":         "9780134685991",
+		"0-306-40615-2":      "9780306406157",
+		"080442957x":         "9780804429573",
+		" 979 10 90636 07 1": "9791090636071",
+	}
+	for in, want := range valid {
+		got, err := isbn.Normalize(in)
+		assert.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+
+	for _, in := range []string{"", "12345", "9780134685992", "0134685998", "X134685997", "9770134685994", "97801346859a1"} {
+		_, err := isbn.Normalize(in)
+		assert.ErrorIs(t, err, isbn.ErrInvalid, in)
+		assert.False(t, isbn.Valid(in), in)
+	}
+}
+
+// TestAddBook_NormalizesISBN stores every spelling of an ISBN as the same book.
+func TestAddBook_NormalizesISBN(t *testing.T) {
+	db := setupTestDB(t)
+	r := setupItemRouter(db, adminClaims())
+
+	w := postJSON(r, "/books", map[string]any{
+		"isbn": "978-0-13-468599-1", "title": "Effective Java", "author": "Bloch", "language": "English", "copies": 1,
+	})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = postJSON(r, "/books", map[string]any{"isbn": "0134685997", "copies": 2, "increment_only": true})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var books []models.BookInventory
+	db.Find(&books)
+	assert.Len(t, books, 1)
+	assert.Equal(t, "9780134685991", books[0].ISBN)
+	assert.Equal(t, 3, books[0].TotalCopies)
+
+	w = postJSON(r, "/books", map[string]any{"isbn": "978-0-13-468599-2", "copies": 1})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Invalid ISBN")
+}
+
+// TestNormalizeStoredISBNs merges books stored under different spellings of one
+// ISBN and carries their copies and references along.
+func TestNormalizeStoredISBNs(t *testing.T) {
+	db := setupTestDB(t)
+	hyphenated := models.BookInventory{ISBN: "978-0-13-468599-1", LibraryID: 1, Title: "Effective Java", Author: "Bloch", Publisher: "P", Language: "English", Version: "3"}
+	short := models.BookInventory{ISBN: "0134685997", LibraryID: 1, Title: "Effective Java", Author: "Bloch", Publisher: "P", Language: "English", Version: "3"}
+	// Predates copy tracking: counters only, no items.
+	legacy := models.BookInventory{ISBN: "9780134685991", LibraryID: 1, Title: "Effective Java", Author: "Bloch", Publisher: "P", Language: "English", Version: "3", TotalCopies: 1, AvailableCopies: 1}
+	other := models.BookInventory{ISBN: "0-306-40615-2", LibraryID: 2, Title: "Other", Author: "A", Publisher: "P", Language: "English", Version: "1"}
+	broken := models.BookInventory{ISBN: "not-an-isbn", LibraryID: 1, Title: "Broken", Author: "A", Publisher: "P", Language: "English", Version: "1"}
+	for _, b := range []*models.BookInventory{&hyphenated, &short, &legacy, &other, &broken} {
+		assert.NoError(t, db.Create(b).Error)
+	}
+	assert.NoError(t, services.AddBookItems(db, &hyphenated, 2, nil, "", ""))
+	assert.NoError(t, services.AddBookItems(db, &short, 1, nil, "", ""))
+	db.Create(&models.Hold{ISBN: "0134685997", LibraryID: 1, ReaderID: 1, Status: "Waiting"})
+	db.Create(&models.IssueRegistry{ISBN: "978-0-13-468599-1", ReaderID: 1, IssueApproverID: 99, IssueStatus: "Issued", LibraryID: 1})
+	db.Create(&models.RequestEvent{BookID: "0134685997", ReaderID: 1, RequestType: "Issue"})
+
+	report, err := services.NormalizeStoredISBNs(db)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, report.Normalized)
+	assert.Equal(t, 2, report.Merged)
+	assert.Equal(t, []string{"not-an-isbn"}, report.Invalid)
+
+	var books []models.BookInventory
+	db.Unscoped().Order("id ASC").Find(&books)
+	assert.Len(t, books, 3)
+	assert.Equal(t, hyphenated.ID, books[0].ID)
+	assert.Equal(t, "9780134685991", books[0].ISBN)
+	assert.Equal(t, 4, books[0].TotalCopies)
+	assert.Equal(t, 4, books[0].AvailableCopies)
+	assert.Equal(t, "9780306406157", books[1].ISBN)
+	assert.Equal(t, "not-an-isbn", books[2].ISBN)
+
+	var hold models.Hold
+	db.First(&hold)
+	assert.Equal(t, "9780134685991", hold.ISBN)
+	var issue models.IssueRegistry
+	db.First(&issue)
+	assert.Equal(t, "9780134685991", issue.ISBN)
+	var req models.RequestEvent
+	db.First(&req)
+	assert.Equal(t, "9780134685991", req.BookID)
+
+	// A second run finds nothing left to do.
+	report, err = services.NormalizeStoredISBNs(db)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, report.Normalized)
+	assert.Equal(t, 0, report.Merged)
+}
+
+// TestNormalizeStoredISBNs_MergesClassification moves the credits, subjects, tags
+// and revisions of a merged book to the book kept, without doubling shared ones.
+func TestNormalizeStoredISBNs_MergesClassification(t *testing.T) {
+	db := setupTestDB(t)
+	keep := models.BookInventory{ISBN: "978-0-13-468599-1", LibraryID: 1, Title: "Effective Java", Author: "Bloch", Publisher: "P", Language: "English", Version: "3"}
+	dup := models.BookInventory{ISBN: "0134685997", LibraryID: 1, Title: "Effective Java", Author: "Bloch", Publisher: "P", Language: "English", Version: "3"}
+	for _, b := range []*models.BookInventory{&keep, &dup} {
+		assert.NoError(t, db.Create(b).Error)
+	}
+	bloch := models.Author{LibraryID: 1, Name: "Bloch", NameKey: "bloch"}
+	gafter := models.Author{LibraryID: 1, Name: "Gafter", NameKey: "gafter"}
+	java := models.Subject{LibraryID: 1, Name: "Java", Path: "/1/"}
+	style := models.Subject{LibraryID: 1, Name: "Style", Path: "/2/"}
+	for _, row := range []any{&bloch, &gafter, &java, &style} {
+		assert.NoError(t, db.Create(row).Error)
+	}
+	for _, row := range []any{
+		&models.BookAuthor{BookInventoryID: keep.ID, AuthorID: bloch.ID, Role: "author", Position: 0},
+		&models.BookAuthor{BookInventoryID: dup.ID, AuthorID: bloch.ID, Role: "author", Position: 0},
+		&models.BookAuthor{BookInventoryID: dup.ID, AuthorID: gafter.ID, Role: "editor", Position: 1},
+		&models.BookSubject{BookInventoryID: keep.ID, SubjectID: java.ID},
+		&models.BookSubject{BookInventoryID: dup.ID, SubjectID: java.ID},
+		&models.BookSubject{BookInventoryID: dup.ID, SubjectID: style.ID},
+		&models.BookTag{BookInventoryID: keep.ID, Tag: "java"},
+		&models.BookTag{BookInventoryID: dup.ID, Tag: "java"},
+		&models.BookTag{BookInventoryID: dup.ID, Tag: "classic"},
+		&models.BookRevision{BookInventoryID: dup.ID, EditorID: 99},
+	} {
+		assert.NoError(t, db.Create(row).Error)
+	}
+
+	report, err := services.NormalizeStoredISBNs(db)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Merged)
+
+	var credits []models.BookAuthor
+	db.Where("book_inventory_id = ?", keep.ID).Order("position ASC").Find(&credits)
+	assert.Len(t, credits, 2)
+	assert.Equal(t, bloch.ID, credits[0].AuthorID)
+	assert.Equal(t, gafter.ID, credits[1].AuthorID)
+	assert.Equal(t, "editor", credits[1].Role)
+	assert.Equal(t, 2, credits[1].Position)
+	var subjects, tags, revisions, orphans int64
+	db.Model(&models.BookSubject{}).Where("book_inventory_id = ?", keep.ID).Count(&subjects)
+	assert.Equal(t, int64(2), subjects)
+	db.Model(&models.BookTag{}).Where("book_inventory_id = ?", keep.ID).Count(&tags)
+	assert.Equal(t, int64(2), tags)
+	db.Model(&models.BookRevision{}).Where("book_inventory_id = ?", keep.ID).Count(&revisions)
+	assert.Equal(t, int64(1), revisions)
+	for _, rows := range []any{&models.BookAuthor{}, &models.BookSubject{}, &models.BookTag{}, &models.BookRevision{}} {
+		var n int64
+		db.Model(rows).Where("book_inventory_id = ?", dup.ID).Count(&n)
+		orphans += n
+	}
+	assert.Equal(t, int64(0), orphans)
+}
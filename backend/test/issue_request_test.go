@@ -49,7 +49,7 @@ func TestCreateIssueRequest_Success(t *testing.T) {
 
     // Seed a book that is available.
     book := models.BookInventory{
-        ISBN:            "issue-req-isbn",
+        ISBN:            testISBN(107),
         LibraryID:       1,
         Title:           "Issue Request Book",
         Author:          "Author",
@@ -79,7 +79,7 @@ func TestCreateIssueRequest_Success(t *testing.T) {
     r.POST("/issueRequests", handlers.CreateIssueRequest(db))
 
     payload, _ := json.Marshal(map[string]string{
-        "bookID": "issue-req-isbn",
+        "bookID": testISBN(107),
     })
     req, _ := http.NewRequest("POST", "/issueRequests", bytes.NewBuffer(payload))
     req.Header.Set("Content-Type", "application/json")
@@ -91,7 +91,7 @@ func TestCreateIssueRequest_Success(t *testing.T) {
 
     // Verify a RequestEvent was created with "Issue"
     var reqEvent models.RequestEvent
-    err := db.First(&reqEvent, "book_id = ? AND reader_id = ?", "issue-req-isbn", user.ID).Error
+    err := db.First(&reqEvent, "book_id = ? AND reader_id = ?", testISBN(107), user.ID).Error
     assert.NoError(t, err)
     assert.Equal(t, "Issue", reqEvent.RequestType)
 }
@@ -208,11 +208,12 @@ func TestIssueBook_Success(t *testing.T) {
         c.Next()
     })
     r.POST("/issueRegistry", handlers.IssueBook(db))
+    seedCirculationReaders(t, db, 1)
 
     // Provide all required fields, including expected_return_date
     futureDate := time.Now().Add(48 * time.Hour)
     payload, _ := json.Marshal(map[string]any {
-        "isbn":               "issuebookisbn",
+        "isbn":               testISBN(113),
         "reader_id":          1,
         "issue_approver_id":  999,
         "issue_status":       "Issued",
@@ -230,7 +231,7 @@ func TestIssueBook_Success(t *testing.T) {
 
     // Check that the record was created
     var record models.IssueRegistry
-    err := db.First(&record, "isbn = ? AND reader_id = ?", "issuebookisbn", 1).Error
+    err := db.First(&record, "isbn = ? AND reader_id = ?", testISBN(113), 1).Error
     assert.NoError(t, err)
     assert.Equal(t, "Issued", record.IssueStatus)
     assert.WithinDuration(t, futureDate, record.ExpectedReturnDate, time.Second)
@@ -289,7 +290,8 @@ func TestUpdateIssueRequestStatus_ApproveWithZeroCopies(t *testing.T) {
     }
     db.Create(&book)
 
-    // Seed a request event
+    // Seed the reader and a request event
+    seedCirculationReaders(t, db, 2)
     reqEvent := models.RequestEvent{
         BookID:      "approvezero",
         ReaderID:    2,
@@ -410,7 +412,7 @@ func TestRaiseRequest_MaxRequestsReached(t *testing.T) {
 	db.Create(&user)
 
 	book := models.BookInventory{
-		ISBN:            "12345",
+		ISBN:            testISBN(1),
 		LibraryID:       1,
 		Title:           "Golang Book",
 		Author:          "John Doe",
@@ -422,7 +424,7 @@ func TestRaiseRequest_MaxRequestsReached(t *testing.T) {
 	requests := make([]models.RequestEvent, 4)
 	for i := range requests {
 		requests[i] = models.RequestEvent{
-			BookID:      "12345",
+			BookID:      testISBN(1),
 			ReaderID:    user.ID,
 			RequestType: "Issue",
 			RequestDate: time.Now(),
@@ -440,7 +442,7 @@ func TestRaiseRequest_MaxRequestsReached(t *testing.T) {
 	r := setupRequestEventsRouter(db, claims)
 
 	payload, _ := json.Marshal(map[string]any {
-		"bookID": "12345",
+		"bookID": testISBN(1),
 	})
 
 	req, _ := http.NewRequest("POST", "/requestEvents", bytes.NewBuffer(payload))
@@ -465,7 +467,7 @@ func TestRaiseRequest_DBFailure(t *testing.T) {
 	db.Create(&user)
 
 	book := models.BookInventory{
-		ISBN:            "67890",
+		ISBN:            testISBN(2),
 		LibraryID:       3,
 		Title:           "DB Failure Book",
 		Author:          "Alice Doe",
@@ -487,7 +489,7 @@ func TestRaiseRequest_DBFailure(t *testing.T) {
 	sqlDB.Close()
 
 	payload, _ := json.Marshal(map[string]any {
-		"bookID": "67890",
+		"bookID": testISBN(2),
 	})
 
 	req, _ := http.NewRequest("POST", "/requestEvents", bytes.NewBuffer(payload))
@@ -0,0 +1,299 @@
+// /backend/test/item_test.go
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/swapxs/LibMS/backend/src/handlers"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"gorm.io/gorm"
+)
+
+func adminClaims() jwt.MapClaims {
+	return jwt.MapClaims{"id": float64(99), "role": "LibraryAdmin", "library_id": float64(1)}
+}
+
+func setupItemRouter(db *gorm.DB, claims jwt.MapClaims) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user", claims)
+		c.Next()
+	})
+	r.POST("/books", handlers.AddOrIncrementBook(db, nil))
+	r.POST("/books/remove", handlers.RemoveBook(db))
+	r.GET("/books/:isbn/items", handlers.GetBookItems(db))
+	r.PUT("/items/:barcode", handlers.UpdateItem(db))
+	r.PUT("/issueRequests/:id", handlers.UpdateIssueRequestStatus(db))
+	r.PUT("/returnRequests/:id", handlers.UpdateReturnRequestStatus(db))
+	r.POST("/issueRegistry", handlers.IssueBook(db))
+	return r
+}
+
+func putJSON(r *gin.Engine, url string, body any) *httptest.ResponseRecorder {
+	b, _ := json.Marshal(body)
+	req, _ := http.NewRequest("PUT", url, bytes.NewBuffer(b))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func addItemBook(t *testing.T, r *gin.Engine, copies int, barcodes []string) {
+	w := postJSON(r, "/books", map[string]any{
+		"isbn":      testISBN(104),
+		"title":     "Item Book",
+		"author":    "Author",
+		"publisher": "Publisher",
+		"language":  "English",
+		"version":   "v1",
+		"copies":    copies,
+		"barcodes":  barcodes,
+	})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+}
+
+// TestAddBook_CreatesItems creates one item per copy with the supplied barcodes
+// and refuses a barcode already in use.
+func TestAddBook_CreatesItems(t *testing.T) {
+	db := setupTestDB(t)
+	r := setupItemRouter(db, adminClaims())
+
+	addItemBook(t, r, 2, []string{"BC-1", "BC-2"})
+	addItemBook(t, r, 1, nil)
+
+	var items []models.BookItem
+	db.Order("id ASC").Find(&items)
+	assert.Len(t, items, 3)
+	assert.Equal(t, "BC-1", items[0].Barcode)
+	assert.NotEmpty(t, items[2].Barcode)
+
+	var book models.BookInventory
+	db.Where("isbn = ?", testISBN(104)).First(&book)
+	assert.Equal(t, 3, book.TotalCopies)
+	assert.Equal(t, 3, book.AvailableCopies)
+
+	w := postJSON(r, "/books", map[string]any{
+		"isbn": testISBN(104), "title": "Item Book", "author": "Author", "publisher": "Publisher",
+		"language": "English", "version": "v1", "copies": 1, "barcodes": []string{"BC-1"},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// TestIssueAndReturn_TracksItem issues a specific barcode and brings it back damaged.
+func TestIssueAndReturn_TracksItem(t *testing.T) {
+	db := setupTestDB(t)
+	r := setupItemRouter(db, adminClaims())
+	addItemBook(t, r, 2, []string{"BC-1", "BC-2"})
+
+	reader := models.User{Name: "R", Email: "item-reader@example.com", Password: "x", ContactNumber: "1", Role: "Reader", LibraryID: 1}
+	db.Create(&reader)
+	req := models.RequestEvent{BookID: testISBN(104), ReaderID: reader.ID, RequestType: "Issue"}
+	db.Create(&req)
+
+	w := putJSON(r, "/issueRequests/"+strconv.Itoa(int(req.ReqID)), map[string]any{"request_type": "Approve", "barcode": "BC-2"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var item models.BookItem
+	db.Where("barcode = ?", "BC-2").First(&item)
+	assert.Equal(t, "Issued", item.Status)
+	db.First(&req, req.ReqID)
+	assert.Equal(t, item.ID, *req.ItemID)
+
+	issue := models.IssueRegistry{ISBN: testISBN(104), ReaderID: reader.ID, IssueApproverID: 99, IssueStatus: "Issued", LibraryID: 1, ItemID: &item.ID, Barcode: item.Barcode}
+	db.Create(&issue)
+	ret := models.RequestEvent{BookID: testISBN(104), ReaderID: reader.ID, RequestType: "Return", IssueID: &issue.ID}
+	db.Create(&ret)
+
+	w = putJSON(r, "/returnRequests/"+strconv.Itoa(int(ret.ReqID)), map[string]any{"request_type": "Approve", "condition": "Damaged"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	db.First(&item, item.ID)
+	assert.Equal(t, "Damaged", item.Status)
+	var book models.BookInventory
+	db.Where("isbn = ?", testISBN(104)).First(&book)
+	assert.Equal(t, 2, book.TotalCopies)
+	assert.Equal(t, 1, book.AvailableCopies)
+}
+
+// TestIssueBook_ClaimsBarcode takes a copy issued by barcode off the shelf, so
+// that approving another request hands out a different copy.
+func TestIssueBook_ClaimsBarcode(t *testing.T) {
+	db := setupTestDB(t)
+	r := setupItemRouter(db, adminClaims())
+	seedCirculationReaders(t, db, 2)
+	addItemBook(t, r, 2, []string{"BC-1", "BC-2"})
+	w := postJSON(r, "/books", map[string]any{"isbn": testISBN(105), "title": "Other", "author": "A", "language": "English", "copies": 1, "barcodes": []string{"BC-9"}})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	issue := map[string]any{
+		"isbn": testISBN(104), "reader_id": 1, "issue_approver_id": 99, "issue_status": "Issued",
+		"expected_return_date": time.Now().Add(48 * time.Hour), "library_id": 1,
+	}
+	issue["barcode"] = "BC-9"
+	w = postJSON(r, "/issueRegistry", issue)
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	issue["barcode"] = "BC-1"
+	w = postJSON(r, "/issueRegistry", issue)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var item models.BookItem
+	db.Where("barcode = ?", "BC-1").First(&item)
+	assert.Equal(t, "Issued", item.Status)
+	var book models.BookInventory
+	db.Where("isbn = ?", testISBN(104)).First(&book)
+	assert.Equal(t, 1, book.AvailableCopies)
+
+	req := models.RequestEvent{BookID: testISBN(104), ReaderID: 2, RequestType: "Issue"}
+	db.Create(&req)
+	w = putJSON(r, "/issueRequests/"+strconv.Itoa(int(req.ReqID)), map[string]any{"request_type": "Approve"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	db.First(&req, req.ReqID)
+	var approved models.BookItem
+	db.First(&approved, *req.ItemID)
+	assert.Equal(t, "BC-2", approved.Barcode)
+	db.First(&book, book.ID)
+	assert.Equal(t, 0, book.AvailableCopies)
+}
+
+// TestIssueBook_AdminOnly lets only admins issue books by hand, in their own
+// library and as the approver, whatever the body says.
+func TestIssueBook_AdminOnly(t *testing.T) {
+	db := setupTestDB(t)
+	r := setupItemRouter(db, adminClaims())
+	seedCirculationReaders(t, db, 1)
+	other := models.User{Name: "O", Email: "other-reader@example.com", Password: "x", ContactNumber: "1", Role: "Reader", LibraryID: 2}
+	db.Create(&other)
+	addItemBook(t, r, 2, []string{"BC-1", "BC-2"})
+	issue := map[string]any{
+		"isbn": testISBN(104), "reader_id": 1, "issue_approver_id": 1, "issue_status": "Issued",
+		"expected_return_date": time.Now().Add(48 * time.Hour), "library_id": 2, "barcode": "BC-1",
+	}
+
+	w := postJSON(setupItemRouter(db, readerClaims(1)), "/issueRegistry", issue)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	issue["reader_id"] = other.ID
+	w = postJSON(r, "/issueRegistry", issue)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	var issues int64
+	db.Model(&models.IssueRegistry{}).Count(&issues)
+	assert.Equal(t, int64(0), issues)
+
+	issue["reader_id"] = 1
+	w = postJSON(r, "/issueRegistry", issue)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var record models.IssueRegistry
+	assert.NoError(t, db.First(&record, "reader_id = ?", 1).Error)
+	assert.Equal(t, uint(1), record.LibraryID)
+	assert.Equal(t, uint(99), record.IssueApproverID)
+}
+
+// TestUpdateItem_StatusRules repairs a copy and refuses to shelve an issued one.
+func TestUpdateItem_StatusRules(t *testing.T) {
+	db := setupTestDB(t)
+	r := setupItemRouter(db, adminClaims())
+	addItemBook(t, r, 2, []string{"BC-1", "BC-2"})
+
+	w := putJSON(r, "/items/BC-1", map[string]any{"status": "Damaged", "shelf_location": "A-3"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var book models.BookInventory
+	db.Where("isbn = ?", testISBN(104)).First(&book)
+	assert.Equal(t, 1, book.AvailableCopies)
+
+	db.Model(&models.BookItem{}).Where("barcode = ?", "BC-2").UpdateColumn("status", "Issued")
+	w = putJSON(r, "/items/BC-2", map[string]any{"status": "Available"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	reader := setupItemRouter(db, readerClaims(1))
+	w = putJSON(reader, "/items/BC-1", map[string]any{"status": "Available"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+// TestUpdateItem_LostOnLoan lets a copy on loan be reported lost but keeps it off
+// the shelf while its issue is still open.
+func TestUpdateItem_LostOnLoan(t *testing.T) {
+	db := setupTestDB(t)
+	r := setupItemRouter(db, adminClaims())
+	seedCirculationReaders(t, db, 1)
+	addItemBook(t, r, 2, []string{"BC-1", "BC-2"})
+	w := postJSON(r, "/issueRegistry", map[string]any{
+		"isbn": testISBN(104), "reader_id": 1, "issue_approver_id": 99, "issue_status": "Issued",
+		"expected_return_date": time.Now().Add(48 * time.Hour), "library_id": 1, "barcode": "BC-1",
+	})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = putJSON(r, "/items/BC-1", map[string]any{"status": "Lost"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	for _, status := range []string{"Available", "Damaged"} {
+		w = putJSON(r, "/items/BC-1", map[string]any{"status": status})
+		assert.Equal(t, http.StatusBadRequest, w.Code, status)
+	}
+	w = putJSON(r, "/items/BC-1", map[string]any{"shelf_location": "B-1"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var item models.BookItem
+	db.Where("barcode = ?", "BC-1").First(&item)
+	assert.Equal(t, "Lost", item.Status)
+	var book models.BookInventory
+	db.Where("isbn = ?", testISBN(104)).First(&book)
+	assert.Equal(t, 1, book.AvailableCopies)
+}
+
+// TestGetBookItems_BackfillsLegacyBook materializes items for a book that predates
+// copy tracking, keeping copies on loan as issued.
+func TestGetBookItems_BackfillsLegacyBook(t *testing.T) {
+	db := setupTestDB(t)
+	db.Create(&models.BookInventory{
+		ISBN: testISBN(104), LibraryID: 1, Title: "Legacy", Author: "A", Publisher: "P",
+		Language: "English", Version: "v1", TotalCopies: 3, AvailableCopies: 1,
+	})
+
+	req, _ := http.NewRequest("GET", "/books/"+testISBN(104)+"/items", nil)
+	w := httptest.NewRecorder()
+	setupItemRouter(db, readerClaims(1)).ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Items []models.BookItem `json:"items"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Len(t, resp.Items, 3)
+	issued := 0
+	for _, item := range resp.Items {
+		if item.Status == "Issued" {
+			issued++
+		}
+	}
+	assert.Equal(t, 2, issued)
+}
+
+// TestRemoveBook_WithdrawsItems withdraws named copies and refuses issued ones.
+func TestRemoveBook_WithdrawsItems(t *testing.T) {
+	db := setupTestDB(t)
+	r := setupItemRouter(db, adminClaims())
+	addItemBook(t, r, 3, []string{"BC-1", "BC-2", "BC-3"})
+	db.Model(&models.BookItem{}).Where("barcode = ?", "BC-3").UpdateColumn("status", "Issued")
+
+	w := postJSON(r, "/books/remove", map[string]any{"isbn": testISBN(104), "copies": 1, "barcodes": []string{"BC-3"}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = postJSON(r, "/books/remove", map[string]any{"isbn": testISBN(104), "copies": 1, "barcodes": []string{"BC-1"}})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var item models.BookItem
+	db.Where("barcode = ?", "BC-1").First(&item)
+	assert.Equal(t, "Withdrawn", item.Status)
+	var book models.BookInventory
+	db.Where("isbn = ?", testISBN(104)).First(&book)
+	assert.Equal(t, 2, book.TotalCopies)
+	assert.Equal(t, 1, book.AvailableCopies)
+}
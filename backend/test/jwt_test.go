@@ -21,11 +21,11 @@ func generateTestToken(claims jwt.MapClaims) (string, error) {
 	return token.SignedString(jwtSecret)
 }
 
-func setupRouterWithMiddleware() *gin.Engine {
+func setupRouterWithMiddleware(t *testing.T) *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	os.Setenv("JWT_SECRET", string(jwtSecret))
 	r := gin.Default()
-	r.Use(middleware.JWTAuthMiddleware())
+	r.Use(middleware.JWTAuthMiddleware(setupTestDB(t)))
 	r.GET("/protected", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"message": "Authorized"})
 	})
@@ -33,7 +33,7 @@ func setupRouterWithMiddleware() *gin.Engine {
 }
 
 func TestJWTAuthMiddleware_ExpiredToken(t *testing.T) {
-	router := setupRouterWithMiddleware()
+	router := setupRouterWithMiddleware(t)
 	expiredClaims := jwt.MapClaims{
 		"id": 1,
 		"email": "test@example.com",
@@ -51,7 +51,7 @@ func TestJWTAuthMiddleware_ExpiredToken(t *testing.T) {
 }
 
 func TestJWTAuthMiddleware_MissingToken(t *testing.T) {
-	router := setupRouterWithMiddleware()
+	router := setupRouterWithMiddleware(t)
 
 	req, _ := http.NewRequest("GET", "/protected", nil)
 	w := httptest.NewRecorder()
@@ -61,7 +61,7 @@ func TestJWTAuthMiddleware_MissingToken(t *testing.T) {
 }
 
 func TestJWTAuthMiddleware_InvalidSignature(t *testing.T) {
-	router := setupRouterWithMiddleware()
+	router := setupRouterWithMiddleware(t)
 
 	claims := jwt.MapClaims{
 		"id": 1,
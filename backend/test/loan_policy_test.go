@@ -0,0 +1,211 @@
+// /backend/test/loan_policy_test.go
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/swapxs/LibMS/backend/src/handlers"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"github.com/swapxs/LibMS/backend/src/services"
+	"gorm.io/gorm"
+)
+
+func setupLoanPolicyRouter(db *gorm.DB, claims jwt.MapClaims) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user", claims)
+		c.Next()
+	})
+	r.GET("/loanPolicies", handlers.GetLoanPolicies(db))
+	r.POST("/loanPolicies", handlers.CreateLoanPolicy(db))
+	r.GET("/loanPolicies/effective", handlers.GetEffectiveLoanPolicy(db))
+	r.PUT("/loanPolicies/:id", handlers.UpdateLoanPolicy(db))
+	r.DELETE("/loanPolicies/:id", handlers.DeleteLoanPolicy(db))
+	r.PUT("/users/:id/patron-category", handlers.UpdatePatronCategory(db))
+	r.POST("/requestEvents", handlers.RaiseRequest(db))
+	r.POST("/issueRegistry", handlers.IssueBook(db))
+	r.POST("/issueRegistry/:id/renew", handlers.RenewIssue(db))
+	return r
+}
+
+func intPtr(v int) *int { return &v }
+
+func int64Ptr(v int64) *int64 { return &v }
+
+// TestResolveLoanPolicy_Precedence applies rules from least to most specific on top
+// of the library defaults.
+func TestResolveLoanPolicy_Precedence(t *testing.T) {
+	db := setupTestDB(t)
+	db.Create(&models.Library{Name: "Policy Library", MaxRenewals: 5})
+	db.Create(&models.FinePolicy{LibraryID: 1, DailyRateCents: 50})
+	db.Create(&models.LoanPolicy{LibraryID: 1, MaxActiveRequests: intPtr(6)})
+	db.Create(&models.LoanPolicy{LibraryID: 1, Role: "Reader", MaxActiveRequests: intPtr(3), DailyRateCents: int64Ptr(20)})
+	db.Create(&models.LoanPolicy{LibraryID: 1, PatronCategory: "Student", LoanPeriodDays: intPtr(21)})
+	db.Create(&models.LoanPolicy{LibraryID: 1, Role: "Reader", PatronCategory: "Student", MaxActiveRequests: intPtr(1)})
+	db.Create(&models.LoanPolicy{LibraryID: 2, MaxActiveRequests: intPtr(9)})
+
+	cases := []struct {
+		role, category          string
+		maxActive, period, renw int
+		rate                    int64
+	}{
+		{"", "", 6, 14, 5, 50},
+		{"LibraryAdmin", "", 6, 14, 5, 50},
+		{"Reader", "", 3, 14, 5, 20},
+		{"LibraryAdmin", "Student", 6, 21, 5, 50},
+		{"Reader", "Student", 1, 21, 5, 20},
+	}
+	for _, tc := range cases {
+		policy, err := services.ResolveLoanPolicyFor(db, 1, tc.role, tc.category)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.maxActive, policy.MaxActiveRequests, tc)
+		assert.Equal(t, tc.period, policy.LoanPeriodDays, tc)
+		assert.Equal(t, tc.renw, policy.MaxRenewals, tc)
+		assert.Equal(t, tc.rate, policy.Fine.DailyRateCents, tc)
+	}
+
+	// A library without rules gets the built-in defaults.
+	policy, err := services.ResolveLoanPolicyFor(db, 3, "Reader", "")
+	assert.NoError(t, err)
+	assert.Equal(t, services.DefaultMaxActiveRequests, policy.MaxActiveRequests)
+	assert.Equal(t, services.DefaultLoanPeriodDays, policy.LoanPeriodDays)
+	assert.Equal(t, services.DefaultMaxRenewals, policy.MaxRenewals)
+}
+
+// TestLoanPolicyEndpoints creates, updates and deletes rules as an admin and refuses
+// readers and duplicate scopes.
+func TestLoanPolicyEndpoints(t *testing.T) {
+	db := setupTestDB(t)
+	admin := setupLoanPolicyRouter(db, adminClaims())
+
+	w := postJSON(setupLoanPolicyRouter(db, readerClaims(1)), "/loanPolicies", map[string]any{"max_active_requests": 10})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = postJSON(admin, "/loanPolicies", map[string]any{"role": "Guest"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = postJSON(admin, "/loanPolicies", map[string]any{"role": "Reader", "max_active_requests": 2})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Policy models.LoanPolicy `json:"policy"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, uint(1), created.Policy.LibraryID)
+	assert.Equal(t, http.StatusConflict, postJSON(admin, "/loanPolicies", map[string]any{"role": "Reader"}).Code)
+
+	url := "/loanPolicies/" + strconv.Itoa(int(created.Policy.ID))
+	w = putJSON(admin, url, map[string]any{"role": "Reader", "loan_period_days": 7})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var rule models.LoanPolicy
+	assert.NoError(t, db.First(&rule, created.Policy.ID).Error)
+	assert.Nil(t, rule.MaxActiveRequests)
+	assert.Equal(t, 7, *rule.LoanPeriodDays)
+
+	// Rules of other libraries are not visible.
+	other := models.LoanPolicy{LibraryID: 2}
+	db.Create(&other)
+	assert.Equal(t, http.StatusNotFound, putJSON(admin, "/loanPolicies/"+strconv.Itoa(int(other.ID)), map[string]any{}).Code)
+
+	req, _ := http.NewRequest("GET", "/loanPolicies", nil)
+	w = httptest.NewRecorder()
+	admin.ServeHTTP(w, req)
+	var list struct {
+		Policies []models.LoanPolicy `json:"policies"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Len(t, list.Policies, 1)
+
+	req, _ = http.NewRequest("DELETE", url, nil)
+	w = httptest.NewRecorder()
+	admin.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	// The scope can be configured again after a delete.
+	assert.Equal(t, http.StatusCreated, postJSON(admin, "/loanPolicies", map[string]any{"role": "Reader"}).Code)
+}
+
+// TestLoanPolicy_AppliedToCirculation limits requests, renewals and due dates by the
+// reader's patron category.
+func TestLoanPolicy_AppliedToCirculation(t *testing.T) {
+	db := setupTestDB(t)
+	db.Create(&models.Library{Name: "Policy Library", MaxRenewals: 2})
+	readerUser := models.User{Name: "R", Email: "policy-reader@example.com", Password: "x", ContactNumber: "1", Role: "Reader", LibraryID: 1}
+	db.Create(&readerUser)
+	admin := setupLoanPolicyRouter(db, adminClaims())
+	reader := setupLoanPolicyRouter(db, readerClaims(readerUser.ID))
+
+	w := postJSON(admin, "/loanPolicies", map[string]any{
+		"patron_category": "Visitor", "max_active_requests": 1, "loan_period_days": 3, "max_renewals": 0,
+	})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = putJSON(admin, "/users/"+strconv.Itoa(int(readerUser.ID))+"/patron-category", map[string]any{"patron_category": "Visitor"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	req, _ := http.NewRequest("GET", "/loanPolicies/effective", nil)
+	w = httptest.NewRecorder()
+	reader.ServeHTTP(w, req)
+	var effective struct {
+		Policy services.EffectiveLoanPolicy `json:"policy"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &effective))
+	assert.Equal(t, "Visitor", effective.Policy.PatronCategory)
+	assert.Equal(t, 1, effective.Policy.MaxActiveRequests)
+
+	for _, isbn := range []string{testISBN(111), testISBN(112)} {
+		book := models.BookInventory{ISBN: isbn, LibraryID: 1, Title: "P", Author: "A", Publisher: "P", Language: "English", Version: "v1"}
+		db.Create(&book)
+		assert.NoError(t, services.AddBookItems(db, &book, 1, nil, "", ""))
+	}
+	assert.Equal(t, http.StatusCreated, postJSON(reader, "/requestEvents", map[string]any{"bookID": testISBN(111)}).Code)
+	w = postJSON(reader, "/requestEvents", map[string]any{"bookID": testISBN(112)})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "Maximum of 1 active requests")
+
+	issue := map[string]any{
+		"isbn": testISBN(111), "reader_id": readerUser.ID, "issue_approver_id": 99, "issue_status": "Issued",
+		"expected_return_date": time.Now().Add(7 * 24 * time.Hour), "library_id": 1,
+	}
+	assert.Equal(t, http.StatusBadRequest, postJSON(admin, "/issueRegistry", issue).Code)
+	issue["expected_return_date"] = time.Now().Add(2 * 24 * time.Hour)
+	assert.Equal(t, http.StatusOK, postJSON(admin, "/issueRegistry", issue).Code)
+
+	var record models.IssueRegistry
+	assert.NoError(t, db.First(&record, "reader_id = ?", readerUser.ID).Error)
+	w = renew(reader, record.ID, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "Maximum of 0 renewals")
+}
+
+// TestSweepOverdue_PerReaderRates charges each reader the rate of their own rules.
+func TestSweepOverdue_PerReaderRates(t *testing.T) {
+	db := setupTestDB(t)
+	db.Create(&models.FinePolicy{LibraryID: 1, DailyRateCents: 50})
+	staff := models.User{Name: "S", Email: "policy-staff@example.com", Password: "x", ContactNumber: "1", Role: "Reader", LibraryID: 1, PatronCategory: "Faculty"}
+	student := models.User{Name: "T", Email: "policy-student@example.com", Password: "x", ContactNumber: "1", Role: "Reader", LibraryID: 1}
+	db.Create(&staff)
+	db.Create(&student)
+	db.Create(&models.LoanPolicy{LibraryID: 1, PatronCategory: "Faculty", DailyRateCents: int64Ptr(0)})
+
+	now := time.Now()
+	for _, readerID := range []uint{staff.ID, student.ID} {
+		db.Create(&models.IssueRegistry{
+			ISBN: testISBN(125), ReaderID: readerID, IssueApproverID: 99, IssueStatus: "Issued",
+			ExpectedReturnDate: now.Add(-4 * 24 * time.Hour), LibraryID: 1,
+		})
+	}
+	_, err := services.SweepOverdue(db, now)
+	assert.NoError(t, err)
+
+	var fines []models.Fine
+	db.Find(&fines)
+	assert.Len(t, fines, 1)
+	assert.Equal(t, student.ID, fines[0].ReaderID)
+	assert.Equal(t, int64(200), fines[0].AccruedCents)
+}
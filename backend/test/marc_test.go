@@ -0,0 +1,242 @@
+// /backend/test/marc_test.go
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/swapxs/LibMS/backend/src/handlers"
+	"github.com/swapxs/LibMS/backend/src/marc"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"gorm.io/gorm"
+)
+
+func setupMARCRouter(db *gorm.DB, claims jwt.MapClaims) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user", claims)
+		c.Next()
+	})
+	r.POST("/books/import/marc", handlers.ImportMARC(db))
+	r.GET("/books/export/marc", handlers.ExportMARC(db))
+	return r
+}
+
+// encodeMARC writes records in the binary ISO 2709 form.
+func encodeMARC(records ...marc.Record) []byte {
+	var out bytes.Buffer
+	for _, r := range records {
+		var directory, data bytes.Buffer
+		for _, f := range r.Fields {
+			var field bytes.Buffer
+			if f.IsControl() {
+				field.WriteString(f.Value)
+			} else {
+				field.WriteString(f.Ind1 + f.Ind2)
+				for _, sf := range f.Subfields {
+					field.WriteString("\x1f" + sf.Code + sf.Value)
+				}
+			}
+			field.WriteByte(0x1e)
+			fmt.Fprintf(&directory, "%s%04d%05d", f.Tag, field.Len(), data.Len())
+			data.Write(field.Bytes())
+		}
+		directory.WriteByte(0x1e)
+		base := 24 + directory.Len()
+		length := base + data.Len() + 1
+		leader := fmt.Sprintf("%05d%s%05d%s", length, r.Leader[5:12], base, r.Leader[17:])
+		out.WriteString(leader)
+		out.Write(directory.Bytes())
+		out.Write(data.Bytes())
+		out.WriteByte(0x1d)
+	}
+	return out.Bytes()
+}
+
+func marcBookRecord(isbn, title, author string, extra ...marc.Field) marc.Record {
+	fields := []marc.Field{
+		{Tag: "001", Value: "ocm" + isbn},
+		{Tag: "008", Value: "180101s2018    mau           001 0 eng d"},
+		{Tag: "020", Ind1: " ", Ind2: " ", Subfields: []marc.Subfield{{Code: "a", Value: isbn + " (pbk.)"}}},
+		{Tag: "100", Ind1: "1", Ind2: " ", Subfields: []marc.Subfield{{Code: "a", Value: author + ","}, {Code: "e", Value: "author."}}},
+		{Tag: "245", Ind1: "1", Ind2: "0", Subfields: []marc.Subfield{{Code: "a", Value: title + " :"}, {Code: "b", Value: "a guide /"}, {Code: "c", Value: author + "."}}},
+		{Tag: "250", Ind1: " ", Ind2: " ", Subfields: []marc.Subfield{{Code: "a", Value: "Third edition."}}},
+		{Tag: "264", Ind1: " ", Ind2: "1", Subfields: []marc.Subfield{{Code: "a", Value: "Boston :"}, {Code: "b", Value: "Addison-Wesley,"}, {Code: "c", Value: "2018."}}},
+	}
+	return marc.Record{Leader: "00000nam a2200000 i 4500", Fields: append(fields, extra...)}
+}
+
+func uploadMARC(r *gin.Engine, url string, content []byte) (int, handlers.MARCImportReport, string) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", "catalog.mrc")
+	part.Write(content)
+	form.Close()
+	req, _ := http.NewRequest("POST", url, &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var report handlers.MARCImportReport
+	json.Unmarshal(w.Body.Bytes(), &report)
+	return w.Code, report, w.Body.String()
+}
+
+// TestMARC_MapRecord reads the catalog fields and strips ISBD punctuation.
+func TestMARC_MapRecord(t *testing.T) {
+	subject := marc.Field{Tag: "650", Ind1: " ", Ind2: "0", Subfields: []marc.Subfield{{Code: "a", Value: "Java."}}}
+	records, err := marc.ReadBinary(bytes.NewReader(encodeMARC(marcBookRecord("9780134685991", "Effective Java", "Bloch, Joshua", subject))))
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+
+	book, unmapped := marc.MapRecord(records[0])
+	assert.Equal(t, marc.Book{
+		ISBN: "9780134685991", Title: "Effective Java: a guide", Author: "Bloch, Joshua",
+		Publisher: "Addison-Wesley", Language: "English", Edition: "Third edition",
+	}, book)
+	assert.Equal(t, []string{"001", "650"}, unmapped)
+
+	// 041 wins over 008, 260 stands in for 264 and unknown codes are kept.
+	record := marc.Record{Fields: []marc.Field{
+		{Tag: "008", Value: strings.Repeat(" ", 35) + "eng d"},
+		{Tag: "041", Ind1: "0", Ind2: " ", Subfields: []marc.Subfield{{Code: "a", Value: "fre"}}},
+		{Tag: "260", Ind1: " ", Ind2: " ", Subfields: []marc.Subfield{{Code: "b", Value: "Gallimard,"}}},
+	}}
+	book, _ = marc.MapRecord(record)
+	assert.Equal(t, "French", book.Language)
+	assert.Equal(t, "Gallimard", book.Publisher)
+	record.Fields[1].Subfields[0].Value = "zul"
+	book, _ = marc.MapRecord(record)
+	assert.Equal(t, "zul", book.Language)
+}
+
+// TestMARC_ReadXML reads collections and single records, prefixed or not.
+func TestMARC_ReadXML(t *testing.T) {
+	doc := `<?xml version="1.0"?>
+<marc:collection xmlns:marc="http://www.loc.gov/MARC21/slim">
+  <marc:record>
+    <marc:leader>00000nam a2200000 i 4500</marc:leader>
+    <marc:controlfield tag="001">42</marc:controlfield>
+    <marc:datafield tag="245" ind1="1" ind2="0"><marc:subfield code="a">Dune /</marc:subfield></marc:datafield>
+  </marc:record>
+  <marc:record>
+    <marc:datafield tag="245" ind1="1" ind2="0"><marc:subfield code="a">Emma</marc:subfield></marc:datafield>
+  </marc:record>
+</marc:collection>`
+	records, err := marc.Parse([]byte(doc))
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "42", records[0].FieldsByTag("001")[0].Value)
+	book, _ := marc.MapRecord(records[0])
+	assert.Equal(t, "Dune", book.Title)
+
+	records, err = marc.Parse([]byte(`<record><datafield tag="100" ind1="1" ind2=" "><subfield code="a">Austen, Jane.</subfield></datafield></record>`))
+	assert.NoError(t, err)
+	book, _ = marc.MapRecord(records[0])
+	assert.Equal(t, "Austen, Jane", book.Author)
+
+	_, err = marc.Parse([]byte("<collection><record>"))
+	assert.ErrorIs(t, err, marc.ErrMalformed)
+	_, err = marc.Parse([]byte("00042nam  22000251  4500"))
+	assert.ErrorIs(t, err, marc.ErrMalformed)
+}
+
+// TestMARC_MalformedDirectory refuses directory entries with signed or empty
+// numbers instead of reading outside the record.
+func TestMARC_MalformedDirectory(t *testing.T) {
+	valid := encodeMARC(marc.Record{Leader: "00000nam a2200000 i 4500", Fields: []marc.Field{{Tag: "001", Value: "42"}}})
+	records, err := marc.Parse(valid)
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+
+	// The directory entry starts after the 24 byte leader: tag, length, offset.
+	for _, entry := range []string{"001-00300000", "0010003-0001", "001+003+0000", "001000000000", "001 00300000"} {
+		record := append([]byte{}, valid...)
+		copy(record[24:36], entry)
+		_, err := marc.Parse(record)
+		assert.ErrorIs(t, err, marc.ErrMalformed, entry)
+	}
+	record := append([]byte{}, valid...)
+	copy(record[12:17], "+0037")
+	_, err = marc.Parse(record)
+	assert.ErrorIs(t, err, marc.ErrMalformed)
+}
+
+// TestImportMARC_AddsBooks imports binary records, reports failures and unmapped
+// tags, and saves nothing in a dry run.
+func TestImportMARC_AddsBooks(t *testing.T) {
+	db := setupTestDB(t)
+	r := setupMARCRouter(db, adminClaims())
+	subject := marc.Field{Tag: "650", Ind1: " ", Ind2: "0", Subfields: []marc.Subfield{{Code: "a", Value: "Java."}}}
+	file := encodeMARC(
+		marcBookRecord("0134685997", "Effective Java", "Bloch, Joshua", subject),
+		marcBookRecord("9780134685991", "Effective Java", "Bloch, Joshua"),
+		marcBookRecord("9780134685992", "Bad ISBN", "Nobody"),
+		marcBookRecord(testISBN(1), "Second", "Author", subject),
+	)
+
+	code, report, body := uploadMARC(r, "/books/import/marc?dry_run=true", file)
+	assert.Equal(t, http.StatusOK, code, body)
+	assert.Equal(t, 2, report.Created)
+	var count int64
+	db.Model(&models.BookInventory{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	code, report, body = uploadMARC(r, "/books/import/marc?copies=2", file)
+	assert.Equal(t, http.StatusOK, code, body)
+	assert.Equal(t, 2, report.Created)
+	assert.Equal(t, 1, report.Incremented)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, 3, report.Rows[2].Row)
+	assert.Equal(t, "error", report.Rows[2].Status)
+	assert.Equal(t, map[string]int{"001": 4, "650": 2}, report.Unmapped)
+
+	var book models.BookInventory
+	assert.NoError(t, db.First(&book, "isbn = ?", "9780134685991").Error)
+	assert.Equal(t, "Effective Java: a guide", book.Title)
+	assert.Equal(t, "Third edition", book.Version)
+	assert.Equal(t, 4, book.TotalCopies)
+
+	code, _, _ = uploadMARC(r, "/books/import/marc", []byte("not marc at all"))
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _, _ = uploadMARC(setupMARCRouter(db, readerClaims(1)), "/books/import/marc", file)
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
+
+// TestExportMARC_RoundTrips exports the library's books as MARCXML that maps back
+// to the same catalog fields.
+func TestExportMARC_RoundTrips(t *testing.T) {
+	db := setupTestDB(t)
+	seedCatalog(t, db)
+	r := setupMARCRouter(db, adminClaims())
+
+	req, _ := http.NewRequest("GET", "/books/export/marc", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/marcxml+xml")
+	assert.Contains(t, w.Body.String(), `xmlns="http://www.loc.gov/MARC21/slim"`)
+
+	records, err := marc.ReadXML(w.Body)
+	assert.NoError(t, err)
+	assert.Len(t, records, 5)
+	var books []models.BookInventory
+	db.Where("library_id = ?", 1).Order("id ASC").Find(&books)
+	for i, record := range records {
+		got, unmapped := marc.MapRecord(record)
+		assert.Equal(t, marc.Book{
+			ISBN: books[i].ISBN, Title: books[i].Title, Author: books[i].Author,
+			Publisher: books[i].Publisher, Language: books[i].Language, Edition: books[i].Version,
+		}, got)
+		assert.Equal(t, []string{"001"}, unmapped)
+	}
+}
@@ -0,0 +1,145 @@
+// /backend/test/metadata_test.go
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/swapxs/LibMS/backend/src/handlers"
+	"github.com/swapxs/LibMS/backend/src/metadata"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"gorm.io/gorm"
+)
+
+// openLibraryStub serves two editions: testISBN(1) with its author on the edition,
+// testISBN(2) with its author on the work. It counts the requests it gets.
+func openLibraryStub(t *testing.T) (*httptest.Server, *int32) {
+	var requests int32
+	records := map[string]string{
+		"/isbn/" + testISBN(1) + ".json": `{"title": "Effective Java", "subtitle": "Best practices", "publishers": ["Addison-Wesley"],
+			"edition_name": "3rd ed.", "languages": [{"key": "/languages/eng"}], "authors": [{"key": "/authors/OL1A"}]}`,
+		"/isbn/" + testISBN(2) + ".json": `{"title": "Le Petit Prince", "languages": [{"key": "/languages/fre"}], "works": [{"key": "/works/OL2W"}]}`,
+		"/works/OL2W.json":               `{"authors": [{"author": {"key": "/authors/OL2A"}}, {"author": {"key": "/authors/OL3A"}}]}`,
+		"/authors/OL1A.json":             `{"name": "Joshua Bloch"}`,
+		"/authors/OL2A.json":             `{"name": "Antoine de Saint-Exupéry"}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.URL.Path == "/isbn/"+testISBN(3)+".json" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		body, ok := records[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func setupMetadataRouter(db *gorm.DB, claims jwt.MapClaims, provider metadata.Provider) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user", claims)
+		c.Next()
+	})
+	r.POST("/books", handlers.AddOrIncrementBook(db, provider))
+	r.GET("/books/metadata/:isbn", handlers.LookupBookMetadata(provider))
+	return r
+}
+
+// TestOpenLibrary_Lookup maps editions, follows author and work links, and tells
+// missing records from failures.
+func TestOpenLibrary_Lookup(t *testing.T) {
+	server, _ := openLibraryStub(t)
+	provider := metadata.NewOpenLibrary(server.URL + "/")
+	ctx := context.Background()
+
+	md, err := provider.Lookup(ctx, testISBN(1))
+	assert.NoError(t, err)
+	assert.Equal(t, metadata.Metadata{
+		ISBN: testISBN(1), Title: "Effective Java: Best practices", Author: "Joshua Bloch",
+		Publisher: "Addison-Wesley", Language: "English", Version: "3rd ed.",
+	}, md)
+
+	// The second author of the work has no record and is skipped.
+	md, err = provider.Lookup(ctx, testISBN(2))
+	assert.NoError(t, err)
+	assert.Equal(t, "Antoine de Saint-Exupéry", md.Author)
+	assert.Equal(t, "French", md.Language)
+
+	_, err = provider.Lookup(ctx, testISBN(4))
+	assert.ErrorIs(t, err, metadata.ErrNotFound)
+	_, err = provider.Lookup(ctx, testISBN(3))
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, metadata.ErrNotFound)
+}
+
+// TestAddBook_FillsMissingDetails completes new books from the provider, keeping
+// what the admin typed, and looks nothing up for books the library already has.
+func TestAddBook_FillsMissingDetails(t *testing.T) {
+	db := setupTestDB(t)
+	server, requests := openLibraryStub(t)
+	r := setupMetadataRouter(db, adminClaims(), metadata.NewOpenLibrary(server.URL))
+
+	w := postJSON(r, "/books", map[string]interface{}{"isbn": testISBN(1), "title": "EJ", "copies": 1})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var book models.BookInventory
+	assert.NoError(t, db.First(&book, "isbn = ?", testISBN(1)).Error)
+	assert.Equal(t, "EJ", book.Title)
+	assert.Equal(t, "Joshua Bloch", book.Author)
+	assert.Equal(t, "Addison-Wesley", book.Publisher)
+	assert.Equal(t, "English", book.Language)
+	assert.Equal(t, "3rd ed.", book.Version)
+
+	atomic.StoreInt32(requests, 0)
+	w = postJSON(r, "/books", map[string]interface{}{"isbn": testISBN(1), "copies": 2})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, int32(0), atomic.LoadInt32(requests))
+
+	// Without a record the usual details are still required.
+	w = postJSON(r, "/books", map[string]interface{}{"isbn": testISBN(4), "copies": 1})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = postJSON(r, "/books", map[string]interface{}{"isbn": testISBN(3), "title": "T", "author": "A", "language": "English", "copies": 1})
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+// TestLookupBookMetadata previews a record for admins only.
+func TestLookupBookMetadata(t *testing.T) {
+	db := setupTestDB(t)
+	server, _ := openLibraryStub(t)
+	r := setupMetadataRouter(db, adminClaims(), metadata.NewOpenLibrary(server.URL))
+
+	get := func(r *gin.Engine, isbn string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/books/metadata/"+isbn, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	w := get(r, "978-0-00-000002-6")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp struct {
+		Metadata metadata.Metadata `json:"metadata"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, testISBN(2), resp.Metadata.ISBN)
+	assert.Equal(t, "Le Petit Prince", resp.Metadata.Title)
+
+	assert.Equal(t, http.StatusNotFound, get(r, testISBN(4)).Code)
+	assert.Equal(t, http.StatusBadGateway, get(r, testISBN(3)).Code)
+	assert.Equal(t, http.StatusBadRequest, get(r, "12345").Code)
+	assert.Equal(t, http.StatusUnauthorized, get(setupMetadataRouter(db, readerClaims(1), nil), testISBN(2)).Code)
+	assert.Equal(t, http.StatusServiceUnavailable, get(setupMetadataRouter(db, adminClaims(), nil), testISBN(2)).Code)
+}
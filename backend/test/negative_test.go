@@ -21,7 +21,7 @@ func TestUnauthorizedAccess_NoToken(t *testing.T) {
 
 	r := gin.Default()
 	protected := r.Group("/api")
-	protected.Use(middleware.JWTAuthMiddleware())
+	protected.Use(middleware.JWTAuthMiddleware(db))
 	{
 		protected.GET("/books", handlers.GetBooks(db))
 	}
@@ -41,7 +41,7 @@ func TestUnauthorizedAccess_InvalidToken(t *testing.T) {
 
 	r := gin.Default()
 	protected := r.Group("/api")
-	protected.Use(middleware.JWTAuthMiddleware())
+	protected.Use(middleware.JWTAuthMiddleware(db))
 	{
 		protected.GET("/books", handlers.GetBooks(db))
 	}
@@ -89,7 +89,7 @@ func TestInvalidJSON_Book(t *testing.T) {
 		})
 		c.Next()
 	})
-	r.POST("/books", handlers.AddOrIncrementBook(db))
+	r.POST("/books", handlers.AddOrIncrementBook(db, nil))
 
 	// Intentionally broken JSON
 	body := []byte(`{ "isbn": "99999", "copies": 3, `) // missing closing brace etc.
@@ -116,7 +116,7 @@ func TestProtectedRoute_NonJSONBody(t *testing.T) {
 		})
 		c.Next()
 	})
-	r.POST("/books", handlers.AddOrIncrementBook(db))
+	r.POST("/books", handlers.AddOrIncrementBook(db, nil))
 
 	// Content-Type is plain text, not JSON
 	body := []byte(`isbn=12345&copies=3`)
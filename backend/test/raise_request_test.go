@@ -20,7 +20,7 @@ func TestRaiseRequest(t *testing.T) {
 
 	// Pre-seed a book record (needed for checking availability).
 	book := models.BookInventory{
-		ISBN:            "12345",
+		ISBN:            testISBN(1),
 		LibraryID:       1,
 		Title:           "Golang Book",
 		Author:          "John Doe",
@@ -49,7 +49,7 @@ func TestRaiseRequest(t *testing.T) {
 	r.POST("/requestEvents", handlers.RaiseRequest(db))
 
 	payload, _ := json.Marshal(map[string]any {
-		"bookID": "12345",
+		"bookID": testISBN(1),
 	})
 	req, _ := http.NewRequest("POST", "/requestEvents", bytes.NewBuffer(payload))
 	req.Header.Set("Content-Type", "application/json")
@@ -62,7 +62,7 @@ func TestRaiseRequest(t *testing.T) {
 
 	// Verify that a request event is created in the database.
 	var reqEvent models.RequestEvent
-	err = db.First(&reqEvent, "book_id = ? AND reader_id = ?", "12345", 1).Error
+	err = db.First(&reqEvent, "book_id = ? AND reader_id = ?", testISBN(1), 1).Error
 	assert.NoError(t, err)
 	assert.Equal(t, "Issue", reqEvent.RequestType)
 }
@@ -85,7 +85,7 @@ func TestRaiseRequest_TooManyExistingRequests(t *testing.T) {
 
     // Seed a book
     book := models.BookInventory{
-        ISBN:            "testisbn",
+        ISBN:            testISBN(114),
         LibraryID:       1,
         Title:           "Test Book",
         Author:          "Test Author",
@@ -101,7 +101,7 @@ func TestRaiseRequest_TooManyExistingRequests(t *testing.T) {
     // request_type = "Issue" or "Approve" indicates active
     for i := 0; i < 4; i++ {
         re := models.RequestEvent{
-            BookID:      "testisbn",
+            BookID:      testISBN(114),
             ReaderID:    userID,
             RequestDate: book.Model.CreatedAt, // arbitrary date
             RequestType: "Issue",              // active
@@ -127,7 +127,7 @@ func TestRaiseRequest_TooManyExistingRequests(t *testing.T) {
 
     // Attempt to raise a new request
     payload, _ := json.Marshal(map[string]string{
-        "bookID": "testisbn",
+        "bookID": testISBN(114),
     })
     req, _ := http.NewRequest("POST", "/requestEvents", bytes.NewBuffer(payload))
     req.Header.Set("Content-Type", "application/json")
@@ -156,7 +156,7 @@ func TestRaiseRequest_BookNotAvailable(t *testing.T) {
 
     // Seed a book with 0 available copies
     book := models.BookInventory{
-        ISBN:            "noavail",
+        ISBN:            testISBN(115),
         LibraryID:       1,
         Title:           "Zero Copies",
         Author:          "Test Author",
@@ -183,7 +183,7 @@ func TestRaiseRequest_BookNotAvailable(t *testing.T) {
     r.POST("/requestEvents", handlers.RaiseRequest(db))
 
     payload, _ := json.Marshal(map[string]string{
-        "bookID": "noavail",
+        "bookID": testISBN(115),
     })
     req, _ := http.NewRequest("POST", "/requestEvents", bytes.NewBuffer(payload))
     req.Header.Set("Content-Type", "application/json")
@@ -0,0 +1,161 @@
+// /backend/test/renewal_test.go
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/swapxs/LibMS/backend/src/handlers"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"gorm.io/gorm"
+)
+
+func setupRenewalRouter(db *gorm.DB, claims jwt.MapClaims) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user", claims)
+		c.Next()
+	})
+	r.POST("/issueRegistry/:id/renew", handlers.RenewIssue(db))
+	r.PUT("/library/renewal-limit", handlers.UpdateRenewalLimit(db))
+	return r
+}
+
+func renew(r *gin.Engine, issueID uint, body any) *httptest.ResponseRecorder {
+	payload, _ := json.Marshal(body)
+	url := "/issueRegistry/" + strconv.Itoa(int(issueID)) + "/renew"
+	req, _ := http.NewRequest("POST", url, bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// TestRenewIssue_Success extends the due date and records a Renew event.
+func TestRenewIssue_Success(t *testing.T) {
+	db := setupTestDB(t)
+	db.Create(&models.Library{Name: "Renewal Library", MaxRenewals: 2})
+	issue := seedIssuedBook(t, db, 1)
+
+	r := setupRenewalRouter(db, jwt.MapClaims{
+		"id":         float64(1),
+		"role":       "Reader",
+		"library_id": float64(1),
+	})
+	w := renew(r, issue.ID, map[string]any{"days": 7})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var renewed models.IssueRegistry
+	assert.NoError(t, db.First(&renewed, issue.ID).Error)
+	assert.Equal(t, 1, renewed.RenewalCount)
+	assert.WithinDuration(t, issue.ExpectedReturnDate.Add(7*24*time.Hour), renewed.ExpectedReturnDate, time.Second)
+
+	var event models.RequestEvent
+	assert.NoError(t, db.First(&event, "request_type = ?", "Renew").Error)
+	assert.Equal(t, issue.ID, *event.IssueID)
+}
+
+// TestRenewIssue_DefaultsToLoanPeriod extends each renewal by the loan period, not
+// by the loan so far, and refuses an extension longer than the loan period.
+func TestRenewIssue_DefaultsToLoanPeriod(t *testing.T) {
+	db := setupTestDB(t)
+	db.Create(&models.Library{Name: "Renewal Library", MaxRenewals: 2})
+	issue := seedIssuedBook(t, db, 1)
+	r := setupRenewalRouter(db, jwt.MapClaims{"id": float64(1), "role": "Reader", "library_id": float64(1)})
+
+	w := renew(r, issue.ID, map[string]any{"days": 100000})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	loan := 14 * 24 * time.Hour
+	var renewed models.IssueRegistry
+	for n := 1; n <= 2; n++ {
+		w = renew(r, issue.ID, nil)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.NoError(t, db.First(&renewed, issue.ID).Error)
+		assert.WithinDuration(t, issue.ExpectedReturnDate.Add(time.Duration(n)*loan), renewed.ExpectedReturnDate, time.Second)
+	}
+}
+
+// TestRenewIssue_LimitReached refuses renewals beyond the library's limit.
+func TestRenewIssue_LimitReached(t *testing.T) {
+	db := setupTestDB(t)
+	db.Create(&models.Library{Name: "Strict Library", MaxRenewals: 1})
+	issue := seedIssuedBook(t, db, 1)
+
+	r := setupRenewalRouter(db, jwt.MapClaims{
+		"id":         float64(1),
+		"role":       "Reader",
+		"library_id": float64(1),
+	})
+	assert.Equal(t, http.StatusOK, renew(r, issue.ID, map[string]any{}).Code)
+	assert.Equal(t, http.StatusForbidden, renew(r, issue.ID, map[string]any{}).Code)
+}
+
+// TestRenewIssue_PendingRequestByOtherReader refuses when someone else is waiting.
+func TestRenewIssue_PendingRequestByOtherReader(t *testing.T) {
+	db := setupTestDB(t)
+	issue := seedIssuedBook(t, db, 1)
+
+	db.Create(&models.User{Name: "Holding Reader", Email: "holding@xenonstack.com", Role: "Reader", LibraryID: 1})
+	other := models.User{Name: "Waiting Reader", Email: "waiting@xenonstack.com", Role: "Reader", LibraryID: 1}
+	db.Create(&other)
+	db.Create(&models.RequestEvent{
+		BookID:      issue.ISBN,
+		ReaderID:    other.ID,
+		RequestDate: time.Now(),
+		RequestType: "Issue",
+	})
+
+	r := setupRenewalRouter(db, jwt.MapClaims{
+		"id":         float64(99),
+		"role":       "LibraryAdmin",
+		"library_id": float64(1),
+	})
+	w := renew(r, issue.ID, map[string]any{})
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+// TestRenewIssue_OtherReadersIssue hides issues belonging to a different reader.
+func TestRenewIssue_OtherReadersIssue(t *testing.T) {
+	db := setupTestDB(t)
+	issue := seedIssuedBook(t, db, 1)
+
+	r := setupRenewalRouter(db, jwt.MapClaims{
+		"id":         float64(2),
+		"role":       "Reader",
+		"library_id": float64(1),
+	})
+	assert.Equal(t, http.StatusNotFound, renew(r, issue.ID, map[string]any{}).Code)
+}
+
+// TestUpdateRenewalLimit_Owner lets the owner set a limit of zero.
+func TestUpdateRenewalLimit_Owner(t *testing.T) {
+	db := setupTestDB(t)
+	lib := models.Library{Name: "Configurable Library"}
+	db.Create(&lib)
+
+	r := setupRenewalRouter(db, jwt.MapClaims{
+		"id":         float64(1),
+		"role":       "Owner",
+		"library_id": float64(lib.ID),
+	})
+	payload, _ := json.Marshal(map[string]any{"max_renewals": 0})
+	req, _ := http.NewRequest("PUT", "/library/renewal-limit", bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var updated models.Library
+	assert.NoError(t, db.First(&updated, lib.ID).Error)
+	assert.Equal(t, 0, updated.MaxRenewals)
+}
@@ -0,0 +1,272 @@
+// /backend/test/request_state_test.go
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/swapxs/LibMS/backend/src/handlers"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"github.com/swapxs/LibMS/backend/src/services"
+	"gorm.io/gorm"
+)
+
+func setupRequestStateRouter(db *gorm.DB, claims jwt.MapClaims) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user", claims)
+		c.Next()
+	})
+	r.POST("/requestEvents", handlers.RaiseRequest(db))
+	r.GET("/requestEvents/:id/history", handlers.GetRequestHistory(db))
+	r.GET("/issueRequests", handlers.GetIssueRequests(db))
+	r.PUT("/issueRequests/:id", handlers.UpdateIssueRequestStatus(db))
+	r.POST("/issueRegistry", handlers.IssueBook(db))
+	r.POST("/issueRegistry/return", handlers.RaiseReturnRequest(db))
+	r.PUT("/returnRequests/:id", handlers.UpdateReturnRequestStatus(db))
+	return r
+}
+
+func getHistory(r *gin.Engine, reqID uint) (int, []models.RequestTransition) {
+	req, _ := http.NewRequest("GET", "/requestEvents/"+strconv.Itoa(int(reqID))+"/history", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var resp struct {
+		History []models.RequestTransition `json:"history"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return w.Code, resp.History
+}
+
+// TestRequestLifecycle_RecordsHistory walks an issue request from Requested to
+// Returned and checks every step is in its history.
+func TestRequestLifecycle_RecordsHistory(t *testing.T) {
+	db := setupTestDB(t)
+	readerUser := models.User{Name: "R", Email: "state-reader@example.com", Password: "x", ContactNumber: "1", Role: "Reader", LibraryID: 1}
+	db.Create(&readerUser)
+	book := models.BookInventory{
+		ISBN: testISBN(109), LibraryID: 1, Title: "State", Author: "A", Publisher: "P",
+		Language: "English", Version: "v1",
+	}
+	db.Create(&book)
+	assert.NoError(t, services.AddBookItems(db, &book, 1, nil, "", ""))
+
+	reader := setupRequestStateRouter(db, readerClaims(readerUser.ID))
+	admin := setupRequestStateRouter(db, adminClaims())
+
+	assert.Equal(t, http.StatusCreated, postJSON(reader, "/requestEvents", map[string]any{"bookID": testISBN(109)}).Code)
+	var req models.RequestEvent
+	assert.NoError(t, db.First(&req).Error)
+	assert.Equal(t, "Issue", req.RequestType)
+	assert.Equal(t, "Requested", req.Status)
+
+	w := putJSON(admin, "/issueRequests/"+strconv.Itoa(int(req.ReqID)), map[string]any{"request_type": "Approve", "reason": "looks fine"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	// A decided request cannot be decided again.
+	w = putJSON(admin, "/issueRequests/"+strconv.Itoa(int(req.ReqID)), map[string]any{"request_type": "Reject"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// Approval issues the book straight away.
+	db.First(&req, req.ReqID)
+	assert.Equal(t, "Issued", req.Status)
+
+	var issue models.IssueRegistry
+	assert.NoError(t, db.First(&issue).Error)
+	assert.Equal(t, http.StatusCreated, postJSON(reader, "/issueRegistry/return", map[string]any{"issue_id": issue.ID}).Code)
+	var ret models.RequestEvent
+	assert.NoError(t, db.First(&ret, "request_type = ?", "Return").Error)
+	w = putJSON(admin, "/returnRequests/"+strconv.Itoa(int(ret.ReqID)), map[string]any{"request_type": "Approve"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	db.First(&req, req.ReqID)
+	assert.Equal(t, "Returned", req.Status)
+	db.First(&ret, ret.ReqID)
+	assert.Equal(t, "Approved", ret.Status)
+
+	code, history := getHistory(reader, req.ReqID)
+	assert.Equal(t, http.StatusOK, code)
+	var steps []string
+	for _, h := range history {
+		steps = append(steps, h.FromStatus+">"+h.ToStatus)
+	}
+	assert.Equal(t, []string{">Requested", "Requested>Approved", "Approved>Issued", "Issued>Returned"}, steps)
+	assert.Equal(t, readerUser.ID, *history[0].ActorID)
+	assert.Equal(t, uint(99), *history[1].ActorID)
+	assert.Equal(t, "looks fine", history[1].Reason)
+
+	// Other readers cannot see the history.
+	code, _ = getHistory(setupRequestStateRouter(db, readerClaims(readerUser.ID+1)), req.ReqID)
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+// TestTransitionRequest_Invalid refuses transitions outside the state machine.
+func TestTransitionRequest_Invalid(t *testing.T) {
+	db := setupTestDB(t)
+	req := models.RequestEvent{BookID: "isbn", ReaderID: 1, RequestType: "Issue"}
+	assert.NoError(t, services.CreateRequest(db, &req, nil, ""))
+
+	assert.NoError(t, services.TransitionRequest(db, &req, "Rejected", nil, "no", nil))
+	err := services.TransitionRequest(db, &req, "Approved", nil, "", nil)
+	assert.ErrorIs(t, err, services.ErrInvalidTransition)
+	assert.False(t, services.CanTransition("Issue", "Requested", "Returned"))
+	assert.False(t, services.CanTransition("Return", "Requested", "Issued"))
+
+	var count int64
+	db.Model(&models.RequestTransition{}).Where("request_id = ?", req.ReqID).Count(&count)
+	assert.Equal(t, int64(2), count)
+}
+
+// TestBackfillRequestStatuses converts legacy request types once.
+func TestBackfillRequestStatuses(t *testing.T) {
+	db := setupTestDB(t)
+	legacy := map[string][2]string{
+		"Issue":          {"Issue", "Requested"},
+		"Approve":        {"Issue", "Approved"},
+		"Reject":         {"Issue", "Rejected"},
+		"Returned":       {"Issue", "Returned"},
+		"Return":         {"Return", "Requested"},
+		"ReturnApproved": {"Return", "Approved"},
+		"ReturnRejected": {"Return", "Rejected"},
+		"Renew":          {"Renew", "Approved"},
+	}
+	ids := map[string]uint{}
+	for requestType := range legacy {
+		req := models.RequestEvent{BookID: "isbn", ReaderID: 1, RequestType: requestType}
+		assert.NoError(t, db.Create(&req).Error)
+		ids[requestType] = req.ReqID
+	}
+
+	assert.NoError(t, services.BackfillRequestStatuses(db))
+	assert.NoError(t, services.BackfillRequestStatuses(db))
+
+	for requestType, want := range legacy {
+		var req models.RequestEvent
+		assert.NoError(t, db.First(&req, ids[requestType]).Error)
+		assert.Equal(t, want[0], req.RequestType, requestType)
+		assert.Equal(t, want[1], req.Status, requestType)
+	}
+	var count int64
+	db.Model(&models.RequestTransition{}).Count(&count)
+	assert.Equal(t, int64(len(legacy)), count)
+}
+
+// TestGetIssueRequests_StatusFilter filters the list by lifecycle status.
+func TestGetIssueRequests_StatusFilter(t *testing.T) {
+	db := setupTestDB(t)
+	readerUser := models.User{Name: "R", Email: "filter-reader@example.com", Password: "x", ContactNumber: "1", Role: "Reader", LibraryID: 1}
+	db.Create(&readerUser)
+	db.Create(&models.BookInventory{
+		ISBN: testISBN(116), LibraryID: 1, Title: "Filter", Author: "A", Publisher: "P",
+		Language: "English", Version: "v1", TotalCopies: 1, AvailableCopies: 1,
+	})
+	db.Create(&models.RequestEvent{BookID: testISBN(116), ReaderID: readerUser.ID, RequestType: "Issue", Status: "Requested"})
+	db.Create(&models.RequestEvent{BookID: testISBN(116), ReaderID: readerUser.ID, RequestType: "Issue", Status: "Rejected"})
+
+	req, _ := http.NewRequest("GET", "/issueRequests?status=Rejected", nil)
+	w := httptest.NewRecorder()
+	setupRequestStateRouter(db, adminClaims()).ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Requests []handlers.IssueRequestDetail `json:"requests"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Len(t, resp.Requests, 1)
+	assert.Equal(t, "Rejected", resp.Requests[0].IssueStatus)
+}
+
+// TestGetIssueRequests_RepeatLoans lists each request once with the return status
+// of its own issue when a reader borrowed the same book twice.
+func TestGetIssueRequests_RepeatLoans(t *testing.T) {
+	db := setupTestDB(t)
+	readerUser := models.User{Name: "R", Email: "repeat-reader@example.com", Password: "x", ContactNumber: "1", Role: "Reader", LibraryID: 1}
+	db.Create(&readerUser)
+	db.Create(&models.BookInventory{
+		ISBN: testISBN(116), LibraryID: 1, Title: "Again", Author: "A", Publisher: "P",
+		Language: "English", Version: "v1", TotalCopies: 1, AvailableCopies: 1,
+	})
+	now := time.Now()
+	returned := models.IssueRegistry{ISBN: testISBN(116), ReaderID: readerUser.ID, IssueApproverID: 99, IssueStatus: "Returned", IssueDate: now, ExpectedReturnDate: now, ReturnDate: &now, LibraryID: 1}
+	open := models.IssueRegistry{ISBN: testISBN(116), ReaderID: readerUser.ID, IssueApproverID: 99, IssueStatus: "Issued", IssueDate: now, ExpectedReturnDate: now.Add(time.Hour), LibraryID: 1}
+	db.Create(&returned)
+	db.Create(&open)
+	first := models.RequestEvent{BookID: testISBN(116), ReaderID: readerUser.ID, RequestType: "Issue", Status: "Returned", IssueID: &returned.ID}
+	second := models.RequestEvent{BookID: testISBN(116), ReaderID: readerUser.ID, RequestType: "Issue", Status: "Issued", IssueID: &open.ID}
+	db.Create(&first)
+	db.Create(&second)
+
+	req, _ := http.NewRequest("GET", "/issueRequests", nil)
+	w := httptest.NewRecorder()
+	setupRequestStateRouter(db, adminClaims()).ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Requests []handlers.IssueRequestDetail `json:"requests"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Len(t, resp.Requests, 2)
+	statuses := map[uint]string{}
+	for _, r := range resp.Requests {
+		statuses[r.ReqID] = r.ReturnStatus
+	}
+	assert.Equal(t, map[uint]string{first.ReqID: "Returned", second.ReqID: "Not Returned"}, statuses)
+}
+
+// TestExpireRequests expires stale pending and uncollected approved requests with a
+// history row each, and puts the copy set aside back on the shelf.
+func TestExpireRequests(t *testing.T) {
+	db := setupTestDB(t)
+	readerUser := seedCancelReader(t, db, "expire-reader@example.com")
+	book := seedCancelBook(t, db, testISBN(116), 2)
+	now := time.Now()
+	old, recent := now.Add(-20*24*time.Hour), now.Add(-time.Hour)
+
+	request := func(status string, requested time.Time, approved *time.Time) models.RequestEvent {
+		req := models.RequestEvent{BookID: book.ISBN, ReaderID: readerUser.ID, RequestType: "Issue", RequestDate: requested}
+		assert.NoError(t, services.CreateRequest(db, &req, nil, ""))
+		if status == "Approved" {
+			item, err := services.CheckoutItem(db, &book, "")
+			assert.NoError(t, err)
+			assert.NoError(t, services.TransitionRequest(db, &req, "Approved", nil, "",
+				map[string]interface{}{"item_id": item.ID, "approval_date": *approved}))
+		}
+		return req
+	}
+	stalePending := request("Requested", old, nil)
+	freshPending := request("Requested", recent, nil)
+	pickupDue := now.Add(-5 * 24 * time.Hour)
+	staleApproved := request("Approved", old, &pickupDue)
+	freshApproved := request("Approved", old, &recent)
+	db.First(&book, book.ID)
+	assert.Equal(t, 0, book.AvailableCopies)
+
+	expired, err := services.ExpireRequests(db, now)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), expired)
+	for req, status := range map[uint]string{
+		stalePending.ReqID: "Expired", freshPending.ReqID: "Requested",
+		staleApproved.ReqID: "Expired", freshApproved.ReqID: "Approved",
+	} {
+		var got models.RequestEvent
+		db.First(&got, req)
+		assert.Equal(t, status, got.Status, req)
+	}
+	var last models.RequestTransition
+	db.Where("request_id = ?", staleApproved.ReqID).Order("id DESC").First(&last)
+	assert.Equal(t, "Approved", last.FromStatus)
+	assert.Equal(t, "Expired", last.ToStatus)
+	db.First(&book, book.ID)
+	assert.Equal(t, 1, book.AvailableCopies)
+
+	expired, err = services.ExpireRequests(db, now)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), expired)
+}
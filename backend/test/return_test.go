@@ -0,0 +1,233 @@
+// /backend/test/return_test.go
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/swapxs/LibMS/backend/src/handlers"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"gorm.io/gorm"
+)
+
+// seedIssuedBook creates a book with one copy out on loan to readerID,
+// the approved issue request and the matching issue registry row.
+func seedIssuedBook(t *testing.T, db *gorm.DB, readerID uint) models.IssueRegistry {
+	book := models.BookInventory{
+		ISBN:            testISBN(110),
+		LibraryID:       1,
+		Title:           "Returnable Book",
+		Author:          "Author",
+		Publisher:       "Publisher",
+		Language:        "English",
+		Version:         "v1",
+		TotalCopies:     2,
+		AvailableCopies: 1,
+	}
+	assert.NoError(t, db.Create(&book).Error)
+
+	approverID := uint(99)
+	now := time.Now()
+	reqEvent := models.RequestEvent{
+		BookID:       testISBN(110),
+		ReaderID:     readerID,
+		RequestDate:  now,
+		ApprovalDate: &now,
+		ApproverID:   &approverID,
+		RequestType:  "Issue",
+		Status:       "Approved",
+	}
+	assert.NoError(t, db.Create(&reqEvent).Error)
+
+	issue := models.IssueRegistry{
+		ISBN:               testISBN(110),
+		ReaderID:           readerID,
+		IssueApproverID:    approverID,
+		IssueStatus:        "Issued",
+		ExpectedReturnDate: now.Add(14 * 24 * time.Hour),
+		LibraryID:          1,
+	}
+	assert.NoError(t, db.Create(&issue).Error)
+	return issue
+}
+
+func setupReturnRouter(db *gorm.DB, claims jwt.MapClaims) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user", claims)
+		c.Next()
+	})
+	r.POST("/issueRegistry/return", handlers.RaiseReturnRequest(db))
+	r.PUT("/returnRequests/:id", handlers.UpdateReturnRequestStatus(db))
+	return r
+}
+
+// TestReturnFlow_Success raises a return as a reader and approves it as an admin.
+func TestReturnFlow_Success(t *testing.T) {
+	db := setupTestDB(t)
+	seedCirculationReaders(t, db, 1)
+	issue := seedIssuedBook(t, db, 1)
+
+	readerRouter := setupReturnRouter(db, jwt.MapClaims{
+		"id":         float64(1),
+		"role":       "Reader",
+		"library_id": float64(1),
+	})
+	payload, _ := json.Marshal(map[string]any{"issue_id": issue.ID})
+	req, _ := http.NewRequest("POST", "/issueRegistry/return", bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	readerRouter.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var returnReq models.RequestEvent
+	assert.NoError(t, db.First(&returnReq, "request_type = ?", "Return").Error)
+	assert.Equal(t, issue.ID, *returnReq.IssueID)
+
+	adminRouter := setupReturnRouter(db, jwt.MapClaims{
+		"id":         float64(99),
+		"role":       "LibraryAdmin",
+		"library_id": float64(1),
+	})
+	payload, _ = json.Marshal(map[string]any{"request_type": "Approve"})
+	url := "/returnRequests/" + strconv.Itoa(int(returnReq.ReqID))
+	req, _ = http.NewRequest("PUT", url, bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	adminRouter.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var closed models.IssueRegistry
+	assert.NoError(t, db.First(&closed, issue.ID).Error)
+	assert.NotNil(t, closed.ReturnDate)
+	assert.Equal(t, uint(99), *closed.ReturnApproverID)
+	assert.Equal(t, "Returned", closed.IssueStatus)
+
+	var book models.BookInventory
+	assert.NoError(t, db.First(&book, "isbn = ?", testISBN(110)).Error)
+	assert.Equal(t, 2, book.AvailableCopies)
+
+	// The approved issue request no longer counts towards the active limit.
+	var active int64
+	db.Model(&models.RequestEvent{}).
+		Where("reader_id = ? AND request_type = ? AND status IN (?)", 1, "Issue", []string{"Requested", "Approved", "Issued"}).
+		Count(&active)
+	assert.Equal(t, int64(0), active)
+}
+
+// TestRaiseReturnRequest_NotOwnIssue ensures a reader cannot return someone else's book.
+func TestRaiseReturnRequest_NotOwnIssue(t *testing.T) {
+	db := setupTestDB(t)
+	seedCirculationReaders(t, db, 1)
+	issue := seedIssuedBook(t, db, 1)
+
+	r := setupReturnRouter(db, jwt.MapClaims{
+		"id":         float64(2),
+		"role":       "Reader",
+		"library_id": float64(1),
+	})
+	payload, _ := json.Marshal(map[string]any{"issue_id": issue.ID})
+	req, _ := http.NewRequest("POST", "/issueRegistry/return", bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// TestRaiseReturnRequest_Duplicate rejects a second pending return for the same issue.
+func TestRaiseReturnRequest_Duplicate(t *testing.T) {
+	db := setupTestDB(t)
+	seedCirculationReaders(t, db, 1)
+	issue := seedIssuedBook(t, db, 1)
+
+	r := setupReturnRouter(db, jwt.MapClaims{
+		"id":         float64(1),
+		"role":       "Reader",
+		"library_id": float64(1),
+	})
+	for _, expected := range []int{http.StatusCreated, http.StatusBadRequest} {
+		payload, _ := json.Marshal(map[string]any{"issue_id": issue.ID})
+		req, _ := http.NewRequest("POST", "/issueRegistry/return", bytes.NewBuffer(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, expected, w.Code)
+	}
+}
+
+// TestUpdateReturnRequestStatus_Reject leaves the issue open and the copies untouched.
+func TestUpdateReturnRequestStatus_Reject(t *testing.T) {
+	db := setupTestDB(t)
+	seedCirculationReaders(t, db, 1)
+	issue := seedIssuedBook(t, db, 1)
+
+	returnReq := models.RequestEvent{
+		BookID:      issue.ISBN,
+		ReaderID:    1,
+		RequestDate: time.Now(),
+		RequestType: "Return",
+		IssueID:     &issue.ID,
+	}
+	db.Create(&returnReq)
+
+	r := setupReturnRouter(db, jwt.MapClaims{
+		"id":         float64(99),
+		"role":       "LibraryAdmin",
+		"library_id": float64(1),
+	})
+	payload, _ := json.Marshal(map[string]any{"request_type": "Reject"})
+	url := "/returnRequests/" + strconv.Itoa(int(returnReq.ReqID))
+	req, _ := http.NewRequest("PUT", url, bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var open models.IssueRegistry
+	assert.NoError(t, db.First(&open, issue.ID).Error)
+	assert.Nil(t, open.ReturnDate)
+
+	var book models.BookInventory
+	assert.NoError(t, db.First(&book, "isbn = ?", testISBN(110)).Error)
+	assert.Equal(t, 1, book.AvailableCopies)
+}
+
+// TestUpdateReturnRequestStatus_NonAdmin ensures readers cannot approve returns.
+func TestUpdateReturnRequestStatus_NonAdmin(t *testing.T) {
+	db := setupTestDB(t)
+	seedCirculationReaders(t, db, 1)
+	issue := seedIssuedBook(t, db, 1)
+
+	returnReq := models.RequestEvent{
+		BookID:      issue.ISBN,
+		ReaderID:    1,
+		RequestDate: time.Now(),
+		RequestType: "Return",
+		IssueID:     &issue.ID,
+	}
+	db.Create(&returnReq)
+
+	r := setupReturnRouter(db, jwt.MapClaims{
+		"id":         float64(1),
+		"role":       "Reader",
+		"library_id": float64(1),
+	})
+	payload, _ := json.Marshal(map[string]any{"request_type": "Approve"})
+	url := "/returnRequests/" + strconv.Itoa(int(returnReq.ReqID))
+	req, _ := http.NewRequest("PUT", url, bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
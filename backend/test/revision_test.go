@@ -0,0 +1,145 @@
+// /backend/test/revision_test.go
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/swapxs/LibMS/backend/src/handlers"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"gorm.io/gorm"
+)
+
+func setupRevisionRouter(db *gorm.DB, claims jwt.MapClaims) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user", claims)
+		c.Next()
+	})
+	r.POST("/books", handlers.AddOrIncrementBook(db, nil))
+	r.PUT("/books/:isbn", handlers.UpdateBook(db))
+	r.GET("/books/:isbn/revisions", handlers.GetBookRevisions(db))
+	r.POST("/books/:isbn/revisions/:id/revert", handlers.RevertBookRevision(db))
+	return r
+}
+
+type updateResponse struct {
+	Book     models.BookInventory `json:"book"`
+	Revision *models.BookRevision `json:"revision"`
+}
+
+func updateBook(t *testing.T, r *gin.Engine, isbn string, body map[string]any) (int, updateResponse) {
+	w := putJSON(r, "/books/"+isbn, body)
+	var resp updateResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return w.Code, resp
+}
+
+func revisionFields(revision *models.BookRevision) map[string][2]string {
+	fields := map[string][2]string{}
+	for _, change := range revision.Changes {
+		fields[change.Field] = [2]string{change.OldValue, change.NewValue}
+	}
+	return fields
+}
+
+// TestUpdateBook_AllowList refuses fields outside the catalog details and empty
+// required details, leaving the book as it was.
+func TestUpdateBook_AllowList(t *testing.T) {
+	db := setupTestDB(t)
+	r := setupRevisionRouter(db, adminClaims())
+	w := postJSON(r, "/books", map[string]any{"isbn": testISBN(1), "title": "Dune", "author": "Frank Herbert", "language": "English", "copies": 2})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	for _, body := range []map[string]any{
+		{"library_id": 2}, {"total_copies": 50}, {"available_copies": 50}, {"id": 7},
+		{"title": "Dune", "cover_content_type": "image/png"}, {"title": "  "}, {"language": ""}, {"title": 5},
+		{"call_number_scheme": "LCC", "call_number": "823"},
+	} {
+		w := putJSON(r, "/books/"+testISBN(1), body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, fmt.Sprint(body))
+	}
+	w = putJSON(r, "/books/"+testISBN(1), map[string]any{"total_copies": 50})
+	assert.Contains(t, w.Body.String(), `\"total_copies\" cannot be edited`)
+
+	var book models.BookInventory
+	db.First(&book)
+	assert.Equal(t, uint(1), book.LibraryID)
+	assert.Equal(t, 2, book.TotalCopies)
+	assert.Equal(t, "Dune", book.Title)
+	var revisions int64
+	db.Model(&models.BookRevision{}).Count(&revisions)
+	assert.Equal(t, int64(0), revisions)
+
+	w = putJSON(setupRevisionRouter(db, readerClaims(1)), "/books/"+testISBN(1), map[string]any{"title": "Mine"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+// TestBookRevisions records who changed what and reverts edits.
+func TestBookRevisions(t *testing.T) {
+	db := setupTestDB(t)
+	r := setupRevisionRouter(db, adminClaims())
+	for n := 1; n <= 2; n++ {
+		w := postJSON(r, "/books", map[string]any{"isbn": testISBN(n), "title": "Dune", "author": "Frank Herbert", "language": "English", "copies": 1})
+		assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	code, resp := updateBook(t, r, testISBN(1), map[string]any{"title": " Dune Messiah ", "publisher": "Ace", "language": "English"})
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "Dune Messiah", resp.Book.Title)
+	assert.Equal(t, uint(99), resp.Revision.EditorID)
+	assert.Equal(t, map[string][2]string{"title": {"Dune", "Dune Messiah"}, "publisher": {"", "Ace"}}, revisionFields(resp.Revision))
+	titleEdit := resp.Revision.ID
+
+	code, resp = updateBook(t, r, testISBN(1), map[string]any{"authors": []map[string]string{{"name": "Frank Herbert"}, {"name": "Brian Herbert", "role": "editor"}}})
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, map[string][2]string{"authors": {
+		`[{"name":"Frank Herbert","role":"author"}]`,
+		`[{"name":"Frank Herbert","role":"author"},{"name":"Brian Herbert","role":"editor"}]`,
+	}}, revisionFields(resp.Revision))
+
+	// Nothing changed, nothing recorded.
+	code, resp = updateBook(t, r, testISBN(1), map[string]any{"title": "Dune Messiah"})
+	assert.Equal(t, http.StatusOK, code)
+	assert.Nil(t, resp.Revision)
+
+	var history struct {
+		Revisions []models.BookRevision `json:"revisions"`
+	}
+	assert.Equal(t, http.StatusOK, getJSON(r, "/books/"+testISBN(1)+"/revisions", &history))
+	assert.Len(t, history.Revisions, 2)
+	assert.Equal(t, titleEdit, history.Revisions[1].ID)
+
+	// Reverting an edit restores the old values as a new revision.
+	w := postJSON(r, fmt.Sprintf("/books/%s/revisions/%d/revert", testISBN(1), titleEdit), nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var reverted updateResponse
+	json.Unmarshal(w.Body.Bytes(), &reverted)
+	assert.Equal(t, "Dune", reverted.Book.Title)
+	assert.Equal(t, "", reverted.Book.Publisher)
+	assert.Equal(t, titleEdit, *reverted.Revision.RevertOf)
+	w = postJSON(r, fmt.Sprintf("/books/%s/revisions/%d/revert", testISBN(1), titleEdit), nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = postJSON(r, fmt.Sprintf("/books/%s/revisions/%d/revert", testISBN(2), titleEdit), nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// A new ISBN must be free and takes the book's holds along.
+	hold := models.Hold{ISBN: testISBN(1), LibraryID: 1, ReaderID: 1, Status: "Waiting"}
+	db.Create(&hold)
+	w = putJSON(r, "/books/"+testISBN(1), map[string]any{"isbn": testISBN(2)})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	code, resp = updateBook(t, r, testISBN(1), map[string]any{"isbn": testISBN(3)})
+	assert.Equal(t, http.StatusOK, code)
+	db.First(&hold, hold.ID)
+	assert.Equal(t, testISBN(3), hold.ISBN)
+	w = postJSON(r, fmt.Sprintf("/books/%s/revisions/%d/revert", testISBN(3), resp.Revision.ID), nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	db.First(&hold, hold.ID)
+	assert.Equal(t, testISBN(1), hold.ISBN)
+}
@@ -0,0 +1,68 @@
+// /backend/test/suggest_test.go
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/swapxs/LibMS/backend/src/handlers"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"github.com/swapxs/LibMS/backend/src/services"
+)
+
+func suggest(t *testing.T, r *gin.Engine, q string) (int, []services.Suggestion) {
+	req, _ := http.NewRequest("GET", "/books/suggest?"+url.Values{"q": {q}}.Encode(), nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var resp struct {
+		Suggestions []services.Suggestion `json:"suggestions"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return w.Code, resp.Suggestions
+}
+
+// TestSuggestBooks_ToleratesTypos offers titles and authors for prefixes and
+// misspellings, best match first, from the caller's library only.
+func TestSuggestBooks_ToleratesTypos(t *testing.T) {
+	db := setupTestDB(t)
+	seedCatalog(t, db)
+	db.Create(&models.BookInventory{ISBN: "cat-7", LibraryID: 1, Title: "The Hobbit", Author: "J. R. R. Tolkien", Publisher: "Allen & Unwin", Language: "English", Version: "1"})
+	db.Create(&models.BookInventory{ISBN: "cat-8", LibraryID: 2, Title: "The Silmarillion", Author: "J. R. R. Tolkien", Publisher: "Allen & Unwin", Language: "English", Version: "1"})
+	r := setupCatalogSearchRouter(db, readerClaims(1))
+	r.GET("/books/suggest", handlers.SuggestBooks(db))
+
+	code, got := suggest(t, r, "tolkein")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, got, 1)
+	assert.Equal(t, services.Suggestion{Text: "J. R. R. Tolkien", Field: "author", Score: got[0].Score}, got[0])
+
+	_, got = suggest(t, r, "Lear")
+	assert.NotEmpty(t, got)
+	assert.Equal(t, "Learning Go", got[0].Text)
+	assert.Equal(t, "title", got[0].Field)
+
+	// Scores never increase down the list.
+	_, got = suggest(t, r, "go")
+	assert.NotEmpty(t, got)
+	for i := 1; i < len(got); i++ {
+		assert.GreaterOrEqual(t, got[i-1].Score, got[i].Score)
+	}
+
+	_, got = suggest(t, r, "zzzz")
+	assert.Empty(t, got)
+	code, _ = suggest(t, r, "")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+// TestWordSimilarity scores prefixes and near misses above unrelated words.
+func TestWordSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, services.WordSimilarity("hobbit", "The Hobbit"))
+	assert.Greater(t, services.WordSimilarity("hobit", "The Hobbit"), services.SuggestThreshold)
+	assert.Less(t, services.WordSimilarity("dune", "The Hobbit"), services.SuggestThreshold)
+	assert.Equal(t, 0.0, services.WordSimilarity("", "The Hobbit"))
+}
@@ -0,0 +1,180 @@
+// /backend/test/taxonomy_test.go
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/swapxs/LibMS/backend/src/handlers"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"github.com/swapxs/LibMS/backend/src/services"
+	"gorm.io/gorm"
+)
+
+func setupTaxonomyRouter(db *gorm.DB, claims jwt.MapClaims) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user", claims)
+		c.Next()
+	})
+	r.GET("/subjects", handlers.GetSubjects(db))
+	r.POST("/subjects", handlers.CreateSubject(db))
+	r.PUT("/subjects/:id", handlers.UpdateSubject(db))
+	r.DELETE("/subjects/:id", handlers.DeleteSubject(db))
+	r.GET("/tags", handlers.GetTags(db))
+	r.GET("/books", handlers.GetBooks(db))
+	r.GET("/books/search", handlers.SearchBooks(db))
+	r.GET("/books/:isbn/classification", handlers.GetBookClassification(db))
+	r.PUT("/books/:isbn/classification", handlers.SetBookClassification(db))
+	return r
+}
+
+func createSubject(t *testing.T, r *gin.Engine, name string, parentID *uint) models.Subject {
+	w := postJSON(r, "/subjects", map[string]interface{}{"name": name, "parent_id": parentID})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var resp struct {
+		Subject models.Subject `json:"subject"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return resp.Subject
+}
+
+func getJSON(r *gin.Engine, url string, out interface{}) int {
+	req, _ := http.NewRequest("GET", url, nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	json.Unmarshal(w.Body.Bytes(), out)
+	return w.Code
+}
+
+// TestSubjects_Hierarchy creates, moves and deletes subjects, keeping sibling names
+// unique and the tree free of cycles.
+func TestSubjects_Hierarchy(t *testing.T) {
+	db := setupTestDB(t)
+	r := setupTaxonomyRouter(db, adminClaims())
+	fiction := createSubject(t, r, "Fiction", nil)
+	scifi := createSubject(t, r, "Science fiction", &fiction.ID)
+	cyberpunk := createSubject(t, r, "Cyberpunk", &scifi.ID)
+	assert.Equal(t, fmt.Sprintf("/%d/%d/%d/", fiction.ID, scifi.ID, cyberpunk.ID), cyberpunk.Path)
+
+	w := postJSON(r, "/subjects", map[string]interface{}{"name": "science FICTION", "parent_id": fiction.ID})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = postJSON(r, "/subjects", map[string]interface{}{"name": "Orphan", "parent_id": 999})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = postJSON(setupTaxonomyRouter(db, readerClaims(1)), "/subjects", map[string]interface{}{"name": "Poetry"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// Moving a subject carries the subjects under it along.
+	w = putJSON(r, fmt.Sprintf("/subjects/%d", scifi.ID), map[string]interface{}{"name": "Sci-fi"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	db.First(&cyberpunk, cyberpunk.ID)
+	assert.Equal(t, fmt.Sprintf("/%d/%d/", scifi.ID, cyberpunk.ID), cyberpunk.Path)
+	w = putJSON(r, fmt.Sprintf("/subjects/%d", scifi.ID), map[string]interface{}{"name": "Sci-fi", "parent_id": cyberpunk.ID})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var tree struct {
+		Subjects []services.SubjectNode `json:"subjects"`
+	}
+	assert.Equal(t, http.StatusOK, getJSON(r, "/subjects", &tree))
+	assert.Len(t, tree.Subjects, 2)
+	assert.Equal(t, "Fiction", tree.Subjects[0].Name)
+	assert.Equal(t, "Sci-fi", tree.Subjects[1].Name)
+	assert.Equal(t, "Cyberpunk", tree.Subjects[1].Children[0].Name)
+
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/subjects/%d", scifi.ID), nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	req, _ = http.NewRequest("DELETE", fmt.Sprintf("/subjects/%d", cyberpunk.ID), nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+// TestClassification_Filters files books under subjects and tags and browses the
+// catalog by them.
+func TestClassification_Filters(t *testing.T) {
+	db := setupTestDB(t)
+	seedCatalog(t, db)
+	r := setupTaxonomyRouter(db, adminClaims())
+	fiction := createSubject(t, r, "Fiction", nil)
+	scifi := createSubject(t, r, "Science fiction", &fiction.ID)
+	other, err := services.CreateSubject(db, 2, "Elsewhere", nil)
+	assert.NoError(t, err)
+
+	// The seeded ISBNs do not pass validation, so file the books directly.
+	var first, third models.BookInventory
+	db.First(&first, "isbn = ?", "cat-1")
+	db.First(&third, "isbn = ?", "cat-3")
+	assert.NoError(t, services.SetBookSubjects(db, first, []uint{scifi.ID}))
+	assert.NoError(t, services.SetBookTags(db, first, []string{"#Classic", " Go  "}))
+	assert.NoError(t, services.SetBookSubjects(db, third, []uint{fiction.ID, scifi.ID}))
+	assert.NoError(t, services.SetBookTags(db, third, []string{"go"}))
+	assert.ErrorIs(t, services.SetBookSubjects(db, third, []uint{other.ID}), services.ErrSubjectNotFound)
+	assert.ErrorIs(t, services.SetBookTags(db, third, []string{strings.Repeat("x", services.MaxTagLength+1)}), services.ErrInvalidTag)
+
+	var list struct {
+		Books []models.BookInventory `json:"books"`
+	}
+	getJSON(r, fmt.Sprintf("/books?subject_id=%d", fiction.ID), &list)
+	assert.Equal(t, []string{"cat-1", "cat-3"}, isbns(list.Books))
+	getJSON(r, fmt.Sprintf("/books?subject_id=%d&tag=classic", scifi.ID), &list)
+	assert.Equal(t, []string{"cat-1"}, isbns(list.Books))
+	code, result := search(t, r, url.Values{"tag": {"GO"}})
+	assert.Equal(t, http.StatusOK, code)
+	assert.ElementsMatch(t, []string{"cat-1", "cat-3"}, isbns(result.Books))
+	assert.Equal(t, http.StatusBadRequest, getJSON(r, "/books?subject_id=fiction", &list))
+
+	var tree struct {
+		Subjects []services.SubjectNode `json:"subjects"`
+	}
+	getJSON(r, "/subjects", &tree)
+	assert.Equal(t, 2, tree.Subjects[0].BookCount)
+	assert.Equal(t, 2, tree.Subjects[0].Children[0].BookCount)
+	var tags struct {
+		Tags []services.TagCount `json:"tags"`
+	}
+	getJSON(r, "/tags", &tags)
+	assert.Equal(t, []services.TagCount{{Tag: "go", BookCount: 2}, {Tag: "classic", BookCount: 1}}, tags.Tags)
+}
+
+// TestBookClassification_Endpoints replaces the subjects and tags of a book given
+// in the request and leaves the others alone.
+func TestBookClassification_Endpoints(t *testing.T) {
+	db := setupTestDB(t)
+	r := setupTaxonomyRouter(db, adminClaims())
+	book := models.BookInventory{ISBN: testISBN(1), LibraryID: 1, Title: "Neuromancer", Author: "Gibson", Language: "English"}
+	assert.NoError(t, db.Create(&book).Error)
+	scifi := createSubject(t, r, "Science fiction", nil)
+
+	w := putJSON(r, "/books/"+testISBN(1)+"/classification", map[string]interface{}{"subject_ids": []uint{scifi.ID}, "tags": []string{"Cyberpunk", "classic"}})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = putJSON(r, "/books/"+testISBN(1)+"/classification", map[string]interface{}{"tags": []string{"cyberpunk"}})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Subjects []models.Subject `json:"subjects"`
+		Tags     []string         `json:"tags"`
+	}
+	assert.Equal(t, http.StatusOK, getJSON(setupTaxonomyRouter(db, readerClaims(1)), "/books/"+testISBN(1)+"/classification", &resp))
+	assert.Len(t, resp.Subjects, 1)
+	assert.Equal(t, []string{"cyberpunk"}, resp.Tags)
+
+	w = putJSON(r, "/books/"+testISBN(1)+"/classification", map[string]interface{}{"subject_ids": []uint{999}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = putJSON(r, "/books/"+testISBN(1)+"/classification", map[string]interface{}{"tags": []string{"  "}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = putJSON(r, "/books/"+testISBN(2)+"/classification", map[string]interface{}{"tags": []string{"x"}})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = putJSON(setupTaxonomyRouter(db, readerClaims(1)), "/books/"+testISBN(1)+"/classification", map[string]interface{}{"tags": []string{"x"}})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
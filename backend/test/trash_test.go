@@ -0,0 +1,227 @@
+// /backend/test/trash_test.go
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/swapxs/LibMS/backend/src/handlers"
+	"github.com/swapxs/LibMS/backend/src/middleware"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"github.com/swapxs/LibMS/backend/src/services"
+	"github.com/swapxs/LibMS/backend/src/storage"
+	"gorm.io/gorm"
+)
+
+func setupTrashRouter(db *gorm.DB, claims jwt.MapClaims) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user", claims)
+		c.Next()
+	})
+	r.POST("/auth/register", handlers.RegisterUser(db))
+	r.POST("/books", handlers.AddOrIncrementBook(db, nil))
+	r.POST("/books/remove", handlers.RemoveBook(db))
+	r.GET("/books", handlers.GetBooks(db))
+	r.DELETE("/users/:id", handlers.DeleteUser(db))
+	r.GET("/trash", handlers.GetTrash(db))
+	r.POST("/trash/books/:isbn/restore", handlers.RestoreBook(db))
+	r.DELETE("/trash/books/:isbn", handlers.PurgeBook(db, nil))
+	r.POST("/trash/users/:id/restore", handlers.RestoreUser(db))
+	r.DELETE("/trash/users/:id", handlers.PurgeUser(db))
+	return r
+}
+
+type trashResponse struct {
+	Books []models.BookInventory `json:"books"`
+	Users []services.TrashedUser `json:"users"`
+}
+
+// TestTrash_Books deletes books by removing their last copies, then revives,
+// restores and purges them.
+func TestTrash_Books(t *testing.T) {
+	db := setupTestDB(t)
+	r := setupTrashRouter(db, adminClaims())
+	for n := 1; n <= 2; n++ {
+		w := postJSON(r, "/books", map[string]any{
+			"isbn": testISBN(n), "title": "Book", "author": "Ann Author", "language": "English",
+			"copies": 1, "barcodes": []string{"BC-" + strconv.Itoa(n)},
+		})
+		assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		w = postJSON(r, "/books/remove", map[string]any{"isbn": testISBN(n), "copies": 1})
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+	var trash trashResponse
+	assert.Equal(t, http.StatusOK, getJSON(r, "/trash", &trash))
+	assert.ElementsMatch(t, []string{testISBN(1), testISBN(2)}, isbns(trash.Books))
+	assert.True(t, trash.Books[0].DeletedAt.Valid)
+	var first models.BookInventory
+	db.Unscoped().Where("isbn = ?", testISBN(1)).First(&first)
+
+	// Adding a deleted ISBN again brings its record back, credits and all.
+	w := postJSON(r, "/books", map[string]any{"isbn": testISBN(1), "title": "Other", "author": "Other", "language": "English", "copies": 2})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var revived models.BookInventory
+	assert.NoError(t, db.Where("isbn = ?", testISBN(1)).First(&revived).Error)
+	assert.Equal(t, first.ID, revived.ID)
+	assert.Equal(t, "Book", revived.Title)
+	assert.Equal(t, 2, revived.TotalCopies)
+	var credits int64
+	db.Model(&models.BookAuthor{}).Where("book_inventory_id = ?", revived.ID).Count(&credits)
+	assert.Equal(t, int64(1), credits)
+
+	// A restored book is back in the catalog, without copies.
+	w = postJSON(r, "/trash/books/"+testISBN(2)+"/restore", nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var list struct {
+		Books []models.BookInventory `json:"books"`
+	}
+	getJSON(r, "/books", &list)
+	assert.ElementsMatch(t, []string{testISBN(1), testISBN(2)}, isbns(list.Books))
+	getJSON(r, "/trash", &trash)
+	assert.Empty(t, trash.Books)
+	w = postJSON(r, "/trash/books/"+testISBN(2)+"/restore", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Purging waits for the readers holding the book, then frees its barcodes.
+	w = postJSON(r, "/books", map[string]any{"isbn": testISBN(3), "title": "Gone", "author": "A", "language": "English", "copies": 1, "barcodes": []string{"BC-3"}})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	postJSON(r, "/books/remove", map[string]any{"isbn": testISBN(3), "copies": 1})
+	hold := models.Hold{ISBN: testISBN(3), LibraryID: 1, ReaderID: 1, Status: "Waiting"}
+	db.Create(&hold)
+	w = doRequest(r, "DELETE", "/trash/books/"+testISBN(3))
+	assert.Equal(t, http.StatusConflict, w.Code)
+	db.Model(&hold).Update("status", "Cancelled")
+	w = doRequest(r, "DELETE", "/trash/books/"+testISBN(3))
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var count int64
+	db.Unscoped().Model(&models.BookInventory{}).Where("isbn = ?", testISBN(3)).Count(&count)
+	assert.Equal(t, int64(0), count)
+	w = postJSON(r, "/books", map[string]any{"isbn": testISBN(4), "title": "New", "author": "A", "language": "English", "copies": 1, "barcodes": []string{"BC-3"}})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = doRequest(setupTrashRouter(db, readerClaims(1)), "GET", "/trash")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+// TestTrash_Users deletes reader accounts and restores or purges them.
+func TestTrash_Users(t *testing.T) {
+	db := setupTestDB(t)
+	r := setupTrashRouter(db, adminClaims())
+	reader := seedCancelReader(t, db, "reader@example.com")
+	busy := seedCancelReader(t, db, "busy@example.com")
+	seedIssuedBook(t, db, busy.ID)
+
+	w := doRequest(r, "DELETE", "/users/"+strconv.Itoa(int(busy.ID)))
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = doRequest(r, "DELETE", "/users/"+strconv.Itoa(int(reader.ID)))
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var trash trashResponse
+	getJSON(r, "/trash", &trash)
+	assert.Len(t, trash.Users, 1)
+	assert.Equal(t, "reader@example.com", trash.Users[0].Email)
+
+	// The address stays taken until the account is purged.
+	register := map[string]any{"name": "Again", "email": "reader@example.com", "password": "secret123", "contact_number": "1", "library_id": 1}
+	w = postJSON(r, "/auth/register", register)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "restore")
+
+	w = postJSON(r, "/trash/users/"+strconv.Itoa(int(reader.ID))+"/restore", nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.NoError(t, db.First(&models.User{}, reader.ID).Error)
+
+	doRequest(r, "DELETE", "/users/"+strconv.Itoa(int(reader.ID)))
+	w = doRequest(r, "DELETE", "/trash/users/"+strconv.Itoa(int(reader.ID)))
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = postJSON(r, "/auth/register", register)
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	admin := models.User{Name: "Admin", Email: "admin@example.com", Password: "x", ContactNumber: "1", Role: "LibraryAdmin", LibraryID: 1}
+	db.Create(&admin)
+	w = doRequest(r, "DELETE", "/users/"+strconv.Itoa(int(admin.ID)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// TestTrash_DeletedReaderToken refuses the token of a reader deleted after logging in.
+func TestTrash_DeletedReaderToken(t *testing.T) {
+	db := setupTestDB(t)
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/auth/register", handlers.RegisterUser(db))
+	r.POST("/auth/login", handlers.Login(db))
+	r.POST("/requestEvents", middleware.JWTAuthMiddleware(db), handlers.RaiseRequest(db))
+	book := seedCirculationBook(t, db, 2)
+
+	w := postJSON(r, "/auth/register", map[string]any{"name": "Reader", "email": "reader@example.com", "password": "secret123", "contact_number": "1", "library_id": 1})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = postJSON(r, "/auth/login", map[string]any{"email": "reader@example.com", "password": "secret123"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var login struct {
+		Token string `json:"token"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &login))
+
+	raise := func() *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]any{"bookID": book.ISBN})
+		req, _ := http.NewRequest("POST", "/requestEvents", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+login.Token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	w = raise()
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var reader models.User
+	db.Where("email = ?", "reader@example.com").First(&reader)
+	db.Model(&models.RequestEvent{}).Where("reader_id = ?", reader.ID).Update("status", "Cancelled")
+
+	assert.NoError(t, db.Delete(&reader).Error)
+	w = raise()
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	var pending int64
+	db.Model(&models.RequestEvent{}).Where("reader_id = ? AND status = ?", reader.ID, "Requested").Count(&pending)
+	assert.Equal(t, int64(0), pending)
+}
+
+// TestTrash_PurgeBookCover keeps the cover of a book whose purge is refused and
+// deletes it with the book.
+func TestTrash_PurgeBookCover(t *testing.T) {
+	db := setupTestDB(t)
+	store := storage.NewLocal(t.TempDir())
+	r := setupCoverRouter(db, adminClaims(), store)
+	r.POST("/books/remove", handlers.RemoveBook(db))
+	r.DELETE("/trash/books/:isbn", handlers.PurgeBook(db, store))
+	w := postJSON(r, "/books", map[string]any{"isbn": testISBN(1), "title": "Book", "author": "A", "language": "English", "copies": 1})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = uploadCover(r, testISBN(1), coverPNG(60, 90))
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	postJSON(r, "/books/remove", map[string]any{"isbn": testISBN(1), "copies": 1})
+	var book models.BookInventory
+	db.Unscoped().Where("isbn = ?", testISBN(1)).First(&book)
+
+	hold := models.Hold{ISBN: testISBN(1), LibraryID: 1, ReaderID: 1, Status: "Waiting"}
+	db.Create(&hold)
+	w = doRequest(r, "DELETE", "/trash/books/"+testISBN(1))
+	assert.Equal(t, http.StatusConflict, w.Code)
+	_, err := store.Get(context.Background(), services.CoverKey(book, false))
+	assert.NoError(t, err)
+
+	db.Model(&hold).Update("status", "Cancelled")
+	w = doRequest(r, "DELETE", "/trash/books/"+testISBN(1))
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	for _, thumb := range []bool{false, true} {
+		_, err = store.Get(context.Background(), services.CoverKey(book, thumb))
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+	}
+}
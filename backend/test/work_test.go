@@ -0,0 +1,157 @@
+// /backend/test/work_test.go
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/swapxs/LibMS/backend/src/handlers"
+	"github.com/swapxs/LibMS/backend/src/models"
+	"gorm.io/gorm"
+)
+
+func setupWorkRouter(db *gorm.DB, claims jwt.MapClaims) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user", claims)
+		c.Next()
+	})
+	r.GET("/works", handlers.GetWorks(db))
+	r.POST("/works", handlers.CreateWork(db))
+	r.GET("/works/:id", handlers.GetWork(db))
+	r.PUT("/works/:id", handlers.UpdateWork(db))
+	r.DELETE("/works/:id", handlers.DeleteWork(db))
+	r.POST("/books", handlers.AddOrIncrementBook(db, nil))
+	r.GET("/books", handlers.GetBooks(db))
+	r.POST("/requestEvents", handlers.RaiseRequest(db))
+	return r
+}
+
+type workResponse struct {
+	Work     models.Work            `json:"work"`
+	Editions []models.BookInventory `json:"editions"`
+}
+
+// seedEditions stores three editions of a work in library 1: testISBN(1) in English
+// with no copy free, testISBN(2) in French with two and testISBN(3) in English with
+// one. testISBN(4) is another book.
+func seedEditions(t *testing.T, db *gorm.DB) {
+	for i, edition := range []struct {
+		language  string
+		available int
+	}{{"English", 0}, {"French", 2}, {"English", 1}, {"English", 1}} {
+		n := i + 1
+		book := models.BookInventory{
+			ISBN:            testISBN(n),
+			LibraryID:       1,
+			Title:           "The Little Prince",
+			Author:          "Antoine de Saint-Exupéry",
+			Publisher:       "Publisher",
+			Language:        edition.language,
+			Version:         fmt.Sprintf("v%d", n),
+			TotalCopies:     2,
+			AvailableCopies: edition.available,
+		}
+		assert.NoError(t, db.Create(&book).Error)
+	}
+}
+
+func createWork(t *testing.T, r *gin.Engine, isbns ...string) workResponse {
+	w := postJSON(r, "/works", map[string]any{"title": "The Little Prince", "author": "Saint-Exupéry", "isbns": isbns})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var resp workResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return resp
+}
+
+// TestWorks_GroupEditions groups books into a work, lists it with its editions and
+// changes the grouping.
+func TestWorks_GroupEditions(t *testing.T) {
+	db := setupTestDB(t)
+	seedEditions(t, db)
+	r := setupWorkRouter(db, adminClaims())
+
+	created := createWork(t, r, testISBN(1), "978-0-00-000002-6", testISBN(3))
+	assert.Equal(t, []string{testISBN(1), testISBN(3), testISBN(2)}, isbns(created.Editions))
+	id := created.Work.ID
+
+	w := postJSON(setupWorkRouter(db, readerClaims(1)), "/works", map[string]any{"title": "Mine"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = postJSON(r, "/works", map[string]any{"title": "Unknown", "isbns": []string{testISBN(9)}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var list struct {
+		Works []handlers.WorkSummary `json:"works"`
+	}
+	assert.Equal(t, http.StatusOK, getJSON(r, "/works?q=prince", &list))
+	assert.Len(t, list.Works, 1)
+	assert.Equal(t, int64(3), list.Works[0].EditionCount)
+	assert.Equal(t, int64(3), list.Works[0].AvailableCopies)
+	var books struct {
+		Books []models.BookInventory `json:"books"`
+	}
+	getJSON(r, fmt.Sprintf("/books?work_id=%d", id), &books)
+	assert.Equal(t, []string{testISBN(1), testISBN(2), testISBN(3)}, isbns(books.Books))
+
+	// A new book can join the work as it is added.
+	w = postJSON(r, "/books", map[string]any{"isbn": testISBN(5), "title": "Le Petit Prince", "author": "Saint-Exupéry", "language": "French", "copies": 1, "work_id": id})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = postJSON(r, "/books", map[string]any{"isbn": testISBN(6), "title": "Orphan", "author": "Nobody", "language": "English", "copies": 1, "work_id": 999})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = putJSON(r, fmt.Sprintf("/works/%d", id), map[string]any{"title": "Le Petit Prince", "isbns": []string{testISBN(2), testISBN(5)}})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp workResponse
+	assert.Equal(t, http.StatusOK, getJSON(r, fmt.Sprintf("/works/%d", id), &resp))
+	assert.Equal(t, "Le Petit Prince", resp.Work.Title)
+	assert.Equal(t, []string{testISBN(5), testISBN(2)}, isbns(resp.Editions))
+
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/works/%d", id), nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var grouped int64
+	db.Model(&models.BookInventory{}).Where("work_id IS NOT NULL").Count(&grouped)
+	assert.Zero(t, grouped)
+	assert.Equal(t, http.StatusNotFound, getJSON(r, fmt.Sprintf("/works/%d", id), &resp))
+}
+
+// TestRaiseRequest_AnyEdition requests a work and gets the edition with a copy free,
+// in the language asked for if any.
+func TestRaiseRequest_AnyEdition(t *testing.T) {
+	db := setupTestDB(t)
+	seedEditions(t, db)
+	work := createWork(t, setupWorkRouter(db, adminClaims()), testISBN(1), testISBN(2), testISBN(3)).Work
+	r := setupWorkRouter(db, readerClaims(1))
+
+	var resp struct {
+		Request models.RequestEvent `json:"request"`
+	}
+	w := postJSON(r, "/requestEvents", map[string]any{"workID": work.ID})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, testISBN(2), resp.Request.BookID)
+	assert.Equal(t, work.ID, *resp.Request.WorkID)
+
+	w = postJSON(r, "/requestEvents", map[string]any{"workID": work.ID, "language": "english"})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, testISBN(3), resp.Request.BookID)
+
+	// The only free English copy is offered to another reader from the hold queue.
+	assert.NoError(t, db.Create(&models.Hold{ISBN: testISBN(3), LibraryID: 1, ReaderID: 2, Status: "Offered"}).Error)
+	w = postJSON(r, "/requestEvents", map[string]any{"workID": work.ID, "language": "English"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = postJSON(r, "/requestEvents", map[string]any{"workID": 999})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = postJSON(r, "/requestEvents", map[string]any{"workID": work.ID, "bookID": testISBN(2)})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -37,6 +37,37 @@ function AddBookForm() {
     setFormData({ ...formData, [e.target.name]: e.target.value });
   };
 
+  // Fill the empty fields with the details the metadata provider has for the ISBN.
+  const handleLookup = async () => {
+    if (!formData.isbn) {
+      setError("Enter an ISBN to look up.");
+      return;
+    }
+    try {
+      const result = await apiService.lookupBookMetadata(formData.isbn, user.token);
+      if (!result.metadata) {
+        setError(result.error || "No details found for this ISBN.");
+        setMessage("");
+        return;
+      }
+      const found = result.metadata;
+      setFormData({
+        ...formData,
+        title: formData.title || found.title,
+        author: formData.author || found.author,
+        publisher: formData.publisher || found.publisher,
+        language: formData.language || found.language,
+        version: formData.version || found.version,
+      });
+      setMessage("Details filled in from the catalog lookup. Check them before saving.");
+      setError("");
+    } catch (err) {
+      console.error("Error looking up book:", err);
+      setError("An error occurred while looking up the book.");
+      setMessage("");
+    }
+  };
+
   const handleSubmit = async (e) => {
     e.preventDefault();
     // If adding a new book, require Title, Author, and Language.
@@ -102,6 +133,11 @@ function AddBookForm() {
             onChange={handleChange}
             required
           />
+          {isNewBook && (
+            <button type="button" onClick={handleLookup}>
+              Look Up Details
+            </button>
+          )}
         </div>
         {isNewBook && (
           <>
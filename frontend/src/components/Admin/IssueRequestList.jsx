@@ -58,11 +58,8 @@ const IssueRequestList = () => {
       const now = new Date().toISOString();
       try {
         // Example API call (replace with your real API call):
-        const updatePayload = {
-          request_type: "Approve",
-          // We'll assume the API sets an ApprovalDate.
-          expected_return_date: new Date(new Date().getTime() + 7 * 24 * 60 * 60 * 1000).toISOString(),
-        };
+        // Approval issues the book; the API computes the due date from the loan policy.
+        const updatePayload = { request_type: "Approve" };
         const updateResponse = await apiService.updateIssueRequest(reqId, updatePayload, user.token);
         console.log("Update issue request response:", updateResponse);
         if (
@@ -73,7 +70,7 @@ const IssueRequestList = () => {
           setRequests((prev) =>
             prev.map((r) =>
               r.ReqID === reqId
-                ? { ...r, RequestType: "Approve", ApprovalDate: now }
+                ? { ...r, IssueStatus: "Issued", ApprovalDate: now }
                 : r
             )
           );
@@ -101,7 +98,7 @@ const IssueRequestList = () => {
               r.ReqID === reqId
                 ? {
                     ...r,
-                    RequestType: "Reject",
+                    IssueStatus: "Rejected",
                     RequestDate: "Rejected",
                     ApprovalDate: "Rejected",
                     ReturnDate: "Rejected",
@@ -162,7 +159,13 @@ const IssueRequestList = () => {
                   ? new Date(req.ReturnDate).toLocaleString()
                   : req.ReturnDate || "N/A";
               const issueApprovalAdmin = req.IssueApproverEmail || "N/A";
-              const currentIssueAction = req.RequestType || "Pending";
+              // Map the request's lifecycle status onto the action dropdown.
+              const currentIssueAction =
+                req.IssueStatus === "Rejected"
+                  ? "Reject"
+                  : ["Approved", "Issued", "Returned"].includes(req.IssueStatus)
+                  ? "Approve"
+                  : "Pending";
 
               return (
                 <tr key={req.ReqID}>
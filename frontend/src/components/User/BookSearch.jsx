@@ -1,5 +1,5 @@
 // frontend/src/components/User/BookSearch.jsx
-import React, { useState } from "react";
+import React, { useEffect, useState } from "react";
 import { useAuth } from "../../context/AuthContext";
 
 const BookSearch = () => {
@@ -7,17 +7,56 @@ const BookSearch = () => {
   const [query, setQuery] = useState("");
   const [books, setBooks] = useState([]);
   const [message, setMessage] = useState("");
+  const [suggestions, setSuggestions] = useState([]);
+  const [subjects, setSubjects] = useState([]);
+  const [subjectId, setSubjectId] = useState("");
+
+  // Load the library's subjects for browsing by genre, flattened in tree order.
+  useEffect(() => {
+    const flatten = (nodes, depth) =>
+      nodes.flatMap((n) => [{ id: n.ID, name: n.name, depth }, ...flatten(n.children || [], depth + 1)]);
+    fetch(`${process.env.REACT_APP_API_URL || "http://localhost:5000"}/api/subjects`, {
+      headers: { Authorization: `Bearer ${user.token}` },
+    })
+      .then((response) => response.json())
+      .then((data) => setSubjects(flatten(data.subjects || [], 0)))
+      .catch(() => setSubjects([]));
+  }, [user.token]);
+
+  // Offer titles and authors while typing, once the reader pauses.
+  useEffect(() => {
+    if (query.trim().length < 2) {
+      setSuggestions([]);
+      return;
+    }
+    const timer = setTimeout(async () => {
+      try {
+        const response = await fetch(
+          `${process.env.REACT_APP_API_URL || "http://localhost:5000"}/api/books/suggest?q=${encodeURIComponent(query)}`,
+          { headers: { Authorization: `Bearer ${user.token}` } }
+        );
+        const data = await response.json();
+        setSuggestions(data.suggestions || []);
+      } catch (err) {
+        setSuggestions([]);
+      }
+    }, 250);
+    return () => clearTimeout(timer);
+  }, [query, user.token]);
 
   const handleSearch = async (e) => {
     e.preventDefault();
+    const params = new URLSearchParams({ q: query });
+    if (subjectId) params.set("subject_id", subjectId);
     try {
       const response = await fetch(
-        `${process.env.REACT_APP_API_URL || "http://localhost:5000"}/api/books?query=${query}`,
+        `${process.env.REACT_APP_API_URL || "http://localhost:5000"}/api/books/search?${params}`,
         { headers: { Authorization: `Bearer ${user.token}` } }
       );
       const data = await response.json();
       if (data.books) {
         setBooks(data.books);
+        setMessage(data.books.length ? "" : "No books found");
       } else {
         setMessage(data.error || "No books found");
       }
@@ -56,21 +95,45 @@ const BookSearch = () => {
           placeholder="Search by title, author, or publisher"
           value={query}
           onChange={(e) => setQuery(e.target.value)}
-          required
+          list="book-suggestions"
+          autoComplete="off"
+          required={!subjectId}
         />
+        <datalist id="book-suggestions">
+          {suggestions.map((s) => (
+            <option key={`${s.field}-${s.text}`} value={s.text} label={s.field} />
+          ))}
+        </datalist>
+        <select value={subjectId} onChange={(e) => setSubjectId(e.target.value)}>
+          <option value="">All genres</option>
+          {subjects.map((s) => (
+            <option key={s.id} value={s.id}>
+              {"\u00a0\u00a0".repeat(s.depth) + s.name}
+            </option>
+          ))}
+        </select>
         <button type="submit">Search</button>
       </form>
       <div className="card-container">
         {books.map((book) => (
-          <div key={book.isbn} className="book-card">
+          <div key={book.ISBN} className="book-card">
             <div className="book-card-header">
-              <h3>{book.title}</h3>
+              <h3>{book.Title}</h3>
             </div>
             <hr />
             <div className="book-card-body">
-              <p><strong>Authors:</strong> {book.authors}</p>
-              <p><strong>Publisher:</strong> {book.publisher}</p>
-              <button onClick={() => handleIssue(book.isbn)}>Issue Book</button>
+              <p><strong>Authors:</strong> {book.Author}</p>
+              <p><strong>Publisher:</strong> {book.Publisher}</p>
+              {book.CallNumber && <p><strong>Call number:</strong> {book.CallNumber}</p>}
+              {(book.Floor || book.Section || book.Shelf) && (
+                <p>
+                  <strong>Location:</strong>{" "}
+                  {[book.Floor && `Floor ${book.Floor}`, book.Section, book.Shelf && `Shelf ${book.Shelf}`]
+                    .filter(Boolean)
+                    .join(", ")}
+                </p>
+              )}
+              <button onClick={() => handleIssue(book.ISBN)}>Issue Book</button>
             </div>
           </div>
         ))}
@@ -41,6 +41,14 @@ const apiService = {
     return response.json();
   },
 
+  // Look up a book's details by ISBN in the external metadata provider
+  lookupBookMetadata: async (isbn, token) => {
+    const response = await fetch(`${API_BASE_URL}/books/metadata/${encodeURIComponent(isbn)}`, {
+      headers: { Authorization: `Bearer ${token}` },
+    });
+    return response.json();
+  },
+
   // Remove copies of a book (or delete the book if copies become 0)
   removeBook: async (isbn, copies, token) => {
     const response = await fetch(`${API_BASE_URL}/books/remove`, {